@@ -1,6 +1,7 @@
 package sls
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/aliyun/aliyun-log-go-sdk/util"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // GlobalForceUsingHTTP if GlobalForceUsingHTTP is true, then all request will use HTTP(ignore LogProject's UsingHTTP flag)
@@ -56,6 +58,8 @@ type Error struct {
 	Code      string `json:"errorCode"`
 	Message   string `json:"errorMessage"`
 	RequestID string `json:"requestID"`
+
+	retryAfter time.Duration // parsed from Retry-After header
 }
 
 func IsDebugLevelMatched(level int) bool {
@@ -118,10 +122,27 @@ type Client struct {
 	// be ignored
 	CommonHeaders map[string]string
 	InnerHeaders  map[string]string
+
+	// ctx is the context all requests of the client are bound to,
+	// nil means context.Background()
+	ctx          context.Context
+	interceptors []RequestInterceptor
+	retryPolicy  *RetryPolicy
+	// tracerProvider enables OpenTelemetry tracing if not nil
+	tracerProvider trace.TracerProvider
 }
 
-// repeated calls only create one http client
+// initHttpClient sets the default timeouts and http client, repeated calls only create one http client.
+// It takes accessKeyLock, so it must be called before the lock is held.
 func (c *Client) initHttpClient() {
+	c.accessKeyLock.RLock()
+	inited := c.RequestTimeOut != 0 && c.RetryTimeOut != 0 && c.HTTPClient != nil
+	c.accessKeyLock.RUnlock()
+	if inited {
+		return
+	}
+	c.accessKeyLock.Lock()
+	defer c.accessKeyLock.Unlock()
 	if c.RequestTimeOut == 0 {
 		c.RequestTimeOut = defaultRequestTimeout
 	}
@@ -134,13 +155,14 @@ func (c *Client) initHttpClient() {
 }
 
 func convert(c *Client, projName string) *LogProject {
+	c.initHttpClient()
 	c.accessKeyLock.RLock()
 	defer c.accessKeyLock.RUnlock()
 	return convertLocked(c, projName)
 }
 
+// convertLocked must be called with accessKeyLock held, after initHttpClient
 func convertLocked(c *Client, projName string) *LogProject {
-	c.initHttpClient()
 	var p *LogProject
 	if c.credentialsProvider != nil {
 		p, _ = NewLogProjectV2(projName, c.Endpoint, c.credentialsProvider)
@@ -156,15 +178,70 @@ func convertLocked(c *Client, projName string) *LogProject {
 	p.innerHeaders = c.InnerHeaders
 	p.httpClient = c.HTTPClient
 	p.retryTimeout = c.RetryTimeOut
+	p.ctx = c.ctx
+	p.interceptors = c.interceptors
+	p.retryPolicy = c.retryPolicy
+	p.tracerProvider = c.tracerProvider
 	return p
 }
 
+// WithContext returns a shallow copy of the client whose requests are bound to ctx.
+// Cancellation or deadline of ctx aborts in-flight requests and pending retries.
+// The copy shares the http client and credentials with the original client,
+// settings changed on one of them later are not visible to the other.
+func (c *Client) WithContext(ctx context.Context) ClientInterface {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c.initHttpClient()
+	c.accessKeyLock.RLock()
+	defer c.accessKeyLock.RUnlock()
+	return &Client{
+		Endpoint:            c.Endpoint,
+		AccessKeyID:         c.AccessKeyID,
+		AccessKeySecret:     c.AccessKeySecret,
+		SecurityToken:       c.SecurityToken,
+		UserAgent:           c.UserAgent,
+		RequestTimeOut:      c.RequestTimeOut,
+		RetryTimeOut:        c.RetryTimeOut,
+		HTTPClient:          c.HTTPClient,
+		Region:              c.Region,
+		AuthVersion:         c.AuthVersion,
+		credentialsProvider: c.credentialsProvider,
+		CommonHeaders:       c.CommonHeaders,
+		InnerHeaders:        c.InnerHeaders,
+		ctx:                 ctx,
+		interceptors:        c.interceptors,
+		retryPolicy:         c.retryPolicy,
+		tracerProvider:      c.tracerProvider,
+	}
+}
+
+// Context returns the context of the client, default is context.Background()
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
 // Set credentialsProvider for client and returns the same client.
 func (c *Client) WithCredentialsProvider(provider CredentialsProvider) *Client {
 	c.credentialsProvider = provider
 	return c
 }
 
+// WithRequestInterceptors appends interceptors to the client's interceptor chain and returns the same client.
+// Interceptors are called in the order they are added.
+func (c *Client) WithRequestInterceptors(interceptors ...RequestInterceptor) *Client {
+	c.accessKeyLock.Lock()
+	defer c.accessKeyLock.Unlock()
+	chain := make([]RequestInterceptor, 0, len(c.interceptors)+len(interceptors))
+	chain = append(chain, c.interceptors...)
+	c.interceptors = append(chain, interceptors...)
+	return c
+}
+
 // SetUserAgent set a custom userAgent
 func (c *Client) SetUserAgent(userAgent string) {
 	c.UserAgent = userAgent
@@ -172,12 +249,24 @@ func (c *Client) SetUserAgent(userAgent string) {
 
 // SetHTTPClient set a custom http client, all request will send to sls by this client
 func (c *Client) SetHTTPClient(client *http.Client) {
+	c.accessKeyLock.Lock()
 	c.HTTPClient = client
+	c.accessKeyLock.Unlock()
 }
 
 // SetRetryTimeout set retry timeout
 func (c *Client) SetRetryTimeout(timeout time.Duration) {
+	c.accessKeyLock.Lock()
 	c.RetryTimeOut = timeout
+	c.accessKeyLock.Unlock()
+}
+
+// SetRetryPolicy set the retry policy of the client, nil means the default retry behavior
+// controlled by package level RetryOnServerErrorEnabled.
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.accessKeyLock.Lock()
+	c.retryPolicy = policy
+	c.accessKeyLock.Unlock()
 }
 
 // SetAuthVersion set signature version that the client used
@@ -0,0 +1,134 @@
+package sls
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServerHTTPClient returns a http client which sends all requests to server,
+// whatever the project domain is.
+func newTestServerHTTPClient(server *httptest.Server) *http.Client {
+	addr := server.Listener.Addr().String()
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+	return &http.Client{Transport: transport}
+}
+
+func TestClientWithContextAbortRetry(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"errorCode":"ServerBusy","errorMessage":"server busy"}`))
+	}))
+	defer server.Close()
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(ClientWithContext)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.WithContext(ctx).GetLogStore("test-project", "test-logstore")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&requestCount), int32(1))
+}
+
+func TestClientWithContextCancelInflight(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(block)
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(ClientWithContext)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := client.WithContext(ctx).ListConsumerGroup("test-project", "test-logstore")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// the original client is not bound to the canceled context
+	assert.Equal(t, context.Background(), client.(*Client).Context())
+}
+
+func TestLogProjectWithContext(t *testing.T) {
+	p, _ := NewLogProject("test-project", "127.0.0.1", "id", "secret")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p2 := p.WithContext(ctx)
+	assert.Equal(t, ctx, p2.Context())
+	assert.Equal(t, context.Background(), p.Context())
+	assert.Equal(t, p.Name, p2.Name)
+}
+
+func TestTokenAutoUpdateClientWithContext(t *testing.T) {
+	var validToken atomic.Value
+	validToken.Store("token-1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HTTPHeaderAcsSecurityToken) != validToken.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errorCode":"Unauthorized","errorMessage":"token expired"}`))
+			return
+		}
+		w.Write([]byte(`{"logstoreName":"test-logstore"}`))
+	}))
+	defer server.Close()
+
+	var fetchCount int32
+	updateFunc := func() (string, string, string, time.Time, error) {
+		n := atomic.AddInt32(&fetchCount, 1)
+		return "id", "secret", "token-" + strconv.Itoa(int(n)), time.Now().Add(time.Hour), nil
+	}
+	shutdown := make(chan struct{})
+	defer close(shutdown)
+	client, err := CreateTokenAutoUpdateClient(server.URL, updateFunc, shutdown)
+	require.NoError(t, err)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	ctxClient := client.(ClientWithContext).WithContext(context.Background())
+
+	// the token refreshed by the context client on Unauthorized is used by the parent
+	validToken.Store("token-2")
+	_, err = ctxClient.GetLogStore("test-project", "test-logstore")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetchCount))
+	_, err = client.GetLogStore("test-project", "test-logstore")
+	require.NoError(t, err)
+
+	// the token reset on the parent is used by the context client
+	validToken.Store("token-reset")
+	client.ResetAccessKeyToken("id", "secret", "token-reset")
+	_, err = ctxClient.GetLogStore("test-project", "test-logstore")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetchCount))
+
+	// calls of a canceled context client fail
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.(ClientWithContext).WithContext(ctx).GetLogStore("test-project", "test-logstore")
+	assert.Error(t, err)
+}
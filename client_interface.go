@@ -1,6 +1,7 @@
 package sls
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -78,6 +79,18 @@ func CreateTokenAutoUpdateClient(endpoint string, tokenUpdateFunc UpdateTokenFun
 	return tauc, nil
 }
 
+// ClientWithContext is implemented by clients whose api calls can be bound to a
+// caller supplied context, eg. the client returned by CreateNormalInterfaceV2.
+//
+//	ctxClient := client.(ClientWithContext).WithContext(ctx)
+//	err := ctxClient.PutLogs(project, logstore, logGroup)
+type ClientWithContext interface {
+	ClientInterface
+	// WithContext returns a client whose calls are canceled when ctx is done,
+	// ctx also bounds all retries of a call.
+	WithContext(ctx context.Context) ClientInterface
+}
+
 // ClientInterface for all log's open api
 type ClientInterface interface {
 	// SetUserAgent set userAgent for sls client
@@ -335,6 +348,9 @@ type ClientInterface interface {
 	GetLogsToCompletedV2(project, logstore string, req *GetLogRequest) (*GetLogsResponse, error)
 	// GetLogsToCompletedV3 query logs with [from, to) time range to completed
 	GetLogsToCompletedV3(project, logstore string, req *GetLogRequest) (*GetLogsV3Response, error)
+	// GetContextLogs query backLines and forwardLines logs around the log of packID and packMeta,
+	// which are the __tag__:__pack_id__ and __pack_meta__ of logs returned by GetLogs
+	GetContextLogs(project, logstore string, backLines, forwardLines int32, packID, packMeta string) (*GetContextLogsResponse, error)
 
 	// #################### Index Operations #####################
 	// CreateIndex ...
@@ -0,0 +1,178 @@
+package sls
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// PromQLClient is implemented by the clients supporting PromQL queries on metric stores,
+// eg. the client returned by CreateNormalInterface and CreateTokenAutoUpdateClient.
+//
+//	result, err := client.(PromQLClient).QueryRange(project, metricStore, query, start, end, step)
+type PromQLClient interface {
+	// QueryInstant evaluates a PromQL query at ts on a metric store.
+	QueryInstant(project, metricStore, query string, ts time.Time) (*PromQueryResult, error)
+	// QueryRange evaluates a PromQL query over a time range on a metric store.
+	QueryRange(project, metricStore, query string, start, end time.Time, step time.Duration) (*PromQueryResult, error)
+	// Series returns the label sets of the series matching the matchers.
+	Series(project, metricStore string, matchers []string, start, end time.Time) ([]model.LabelSet, error)
+	// LabelValues returns the values of a label in the series matching the matchers.
+	LabelValues(project, metricStore, label string, matchers []string, start, end time.Time) ([]string, error)
+}
+
+// PromQueryResult is the result of a PromQL query, only the field of ResultType is set
+type PromQueryResult struct {
+	ResultType model.ValueType
+	Matrix     model.Matrix  // result of range queries, and instant queries of range vectors
+	Vector     model.Vector  // result of instant queries
+	Scalar     *model.Scalar // result of scalar expressions
+	String     *model.String // result of string literals
+	Warnings   []string
+}
+
+// promResponse is the response envelope of the Prometheus HTTP API
+type promResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+	Warnings  []string        `json:"warnings"`
+}
+
+type promQueryData struct {
+	ResultType model.ValueType `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+// QueryInstant evaluates a PromQL query at ts on a metric store, ts is now if it is zero
+func (c *Client) QueryInstant(project, metricStore, query string, ts time.Time) (*PromQueryResult, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	if !ts.IsZero() {
+		params.Set("time", formatPromTime(ts))
+	}
+	return c.promQuery(project, metricStore, "query", params)
+}
+
+// QueryRange evaluates a PromQL query over [start, end] with step on a metric store
+func (c *Client) QueryRange(project, metricStore, query string, start, end time.Time, step time.Duration) (*PromQueryResult, error) {
+	if step <= 0 {
+		return nil, NewClientError(fmt.Errorf("invalid step %v", step))
+	}
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", formatPromTime(start))
+	params.Set("end", formatPromTime(end))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	return c.promQuery(project, metricStore, "query_range", params)
+}
+
+// Series returns the label sets of the series that match any of the matchers, eg. `up{job="node"}`, in [start, end].
+// The zero start and end are not sent, the server decides the time range then.
+func (c *Client) Series(project, metricStore string, matchers []string, start, end time.Time) ([]model.LabelSet, error) {
+	params := promMatchParams(matchers, start, end)
+	var series []model.LabelSet
+	if _, err := c.promGet(project, metricStore, "series", params, &series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// LabelValues returns the values of label in the series that match any of the matchers in [start, end],
+// all series are matched if matchers is empty.
+func (c *Client) LabelValues(project, metricStore, label string, matchers []string, start, end time.Time) ([]string, error) {
+	params := promMatchParams(matchers, start, end)
+	var values []string
+	if _, err := c.promGet(project, metricStore, "label/"+url.PathEscape(label)+"/values", params, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (c *Client) promQuery(project, metricStore, api string, params url.Values) (*PromQueryResult, error) {
+	var data promQueryData
+	warnings, err := c.promGet(project, metricStore, api, params, &data)
+	if err != nil {
+		return nil, err
+	}
+	result := &PromQueryResult{ResultType: data.ResultType, Warnings: warnings}
+	var v interface{}
+	switch data.ResultType {
+	case model.ValMatrix:
+		v = &result.Matrix
+	case model.ValVector:
+		v = &result.Vector
+	case model.ValScalar:
+		result.Scalar = &model.Scalar{}
+		v = result.Scalar
+	case model.ValString:
+		result.String = &model.String{}
+		v = result.String
+	default:
+		return nil, NewClientError(fmt.Errorf("unknown result type %q", data.ResultType))
+	}
+	if err := json.Unmarshal(data.Result, v); err != nil {
+		return nil, NewClientError(err)
+	}
+	return result, nil
+}
+
+// promGet calls the Prometheus HTTP API of metric store, eg. /prometheus/{project}/{metricStore}/api/v1/query,
+// with the signing and retry of the project, and decodes the data of response to v
+func (c *Client) promGet(project, metricStore, api string, params url.Values, v interface{}) ([]string, error) {
+	h := map[string]string{
+		"x-log-bodyrawsize": "0",
+		"Accept":            "application/json",
+	}
+	uri := fmt.Sprintf("/prometheus/%s/%s/api/v1/%s?%s", project, metricStore, api, params.Encode())
+	r, err := request(convert(c, project), "GET", uri, h, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, readResponseError(err)
+	}
+	resp := &promResponse{}
+	if err := json.Unmarshal(buf, resp); err != nil {
+		return nil, NewBadResponseError(string(buf), r.Header, r.StatusCode)
+	}
+	if resp.Status != "success" {
+		return nil, &Error{
+			HTTPCode:  int32(r.StatusCode),
+			Code:      resp.ErrorType,
+			Message:   resp.Error,
+			RequestID: r.Header.Get(RequestIDHeader),
+		}
+	}
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		return nil, NewClientError(err)
+	}
+	return resp.Warnings, nil
+}
+
+func promMatchParams(matchers []string, start, end time.Time) url.Values {
+	params := url.Values{}
+	for _, matcher := range matchers {
+		params.Add("match[]", matcher)
+	}
+	if !start.IsZero() {
+		params.Set("start", formatPromTime(start))
+	}
+	if !end.IsZero() {
+		params.Set("end", formatPromTime(end))
+	}
+	return params
+}
+
+// formatPromTime formats t as unix seconds with fraction, eg. 1700000000.123
+func formatPromTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+}
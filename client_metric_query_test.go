@@ -0,0 +1,85 @@
+package sls
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromQuery(t *testing.T) {
+	client := newHandlerTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/prometheus/project/metrics/api/v1/query_range":
+			assert.Equal(t, "rate(up[1m])", query.Get("query"))
+			assert.Equal(t, "1700000000", query.Get("start"))
+			assert.Equal(t, "1700000060", query.Get("end"))
+			assert.Equal(t, "30", query.Get("step"))
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"node"},"values":[[1700000000,"1"],[1700000030,"0.5"]]}]}}`))
+		case "/prometheus/project/metrics/api/v1/query":
+			assert.Equal(t, "1700000000.5", query.Get("time"))
+			w.Write([]byte(`{"status":"success","warnings":["partial"],"data":{"resultType":"vector","result":[{"metric":{"__name__":"up"},"value":[1700000000.5,"1"]}]}}`))
+		case "/prometheus/project/metrics/api/v1/series":
+			assert.Equal(t, []string{`up{job="node"}`}, query["match[]"])
+			w.Write([]byte(`{"status":"success","data":[{"__name__":"up","job":"node"}]}`))
+		case "/prometheus/project/metrics/api/v1/label/job/values":
+			w.Write([]byte(`{"status":"success","data":["node","prometheus"]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errorCode":"ParameterInvalid","errorMessage":"bad query"}`))
+		}
+	})
+
+	start := time.Unix(1700000000, 0)
+	result, err := client.QueryRange("project", "metrics", "rate(up[1m])", start, start.Add(time.Minute), 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, model.ValMatrix, result.ResultType)
+	require.Len(t, result.Matrix, 1)
+	assert.Equal(t, model.LabelValue("node"), result.Matrix[0].Metric["job"])
+	require.Len(t, result.Matrix[0].Values, 2)
+	assert.Equal(t, model.SampleValue(0.5), result.Matrix[0].Values[1].Value)
+
+	result, err = client.QueryInstant("project", "metrics", "up", start.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, model.ValVector, result.ResultType)
+	require.Len(t, result.Vector, 1)
+	assert.Equal(t, model.SampleValue(1), result.Vector[0].Value)
+	assert.Equal(t, []string{"partial"}, result.Warnings)
+
+	series, err := client.Series("project", "metrics", []string{`up{job="node"}`}, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []model.LabelSet{{"__name__": "up", "job": "node"}}, series)
+
+	values, err := client.LabelValues("project", "metrics", "job", nil, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node", "prometheus"}, values)
+
+	_, err = client.QueryInstant("project", "missing", "up", time.Time{})
+	require.Error(t, err)
+	assert.Equal(t, "ParameterInvalid", err.(*Error).Code)
+
+	_, err = client.QueryRange("project", "metrics", "up", start, start, 0)
+	assert.Error(t, err)
+}
+
+func TestStoreViewQueryTarget(t *testing.T) {
+	checker, err := NewStoreViewRoutingChecker([]byte(`[{"metric_names":["node_.*"],"project_stores":[{"project":"p1","metricstore":"node"}]}]`))
+	require.NoError(t, err)
+	stores := []ProjectStore{{ProjectName: "p1", MetricStore: "node"}, {ProjectName: "p2", MetricStore: "app"}}
+
+	routed, err := checker.RouteQuery(`sum(rate(node_cpu_seconds_total[1m])) / count(node_cpu_seconds_total)`, stores)
+	require.NoError(t, err)
+	assert.Equal(t, stores[:1], routed)
+
+	project, store, err := checker.QueryTarget(`node_load1`, "p0", "view", stores)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1", "node"}, []string{project, store})
+
+	project, store, err = checker.QueryTarget(`node_load1 + app_requests_total`, "p0", "view", stores)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p0", "view"}, []string{project, store})
+}
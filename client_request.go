@@ -54,6 +54,9 @@ func (c *Client) request(project, method, uri string, headers map[string]string,
 	accessKeySecret := c.AccessKeySecret
 	region := c.Region
 	authVersion := c.AuthVersion
+	interceptors := c.interceptors
+	tracerProvider := c.tracerProvider
+	httpClient := c.HTTPClient
 	c.accessKeyLock.RUnlock()
 
 	if c.credentialsProvider != nil {
@@ -104,47 +107,56 @@ func (c *Client) request(project, method, uri string, headers map[string]string,
 		urlStr = "http://"
 	}
 	urlStr += hostStr + uri
-	req, err := http.NewRequest(method, urlStr, reader)
+	ctx, tracing := startAPISpan(c.Context(), tracerProvider, project, method, uri)
+	ctx, attempt := tracing.startAttempt(ctx)
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, reader)
 	if err != nil {
+		tracing.endAttempt(attempt, nil, err)
+		tracing.end(nil, err)
 		return nil, err
 	}
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
-	if IsDebugLevelMatched(5) {
-		dump, e := httputil.DumpRequest(req, true)
-		if e != nil {
-			level.Info(Logger).Log("msg", e)
+	handler := func(req *http.Request) (*http.Response, error) {
+		if IsDebugLevelMatched(5) {
+			dump, e := httputil.DumpRequest(req, true)
+			if e != nil {
+				level.Info(Logger).Log("msg", e)
+			}
+			level.Info(Logger).Log("msg", "HTTP Request:\n%v", string(dump))
 		}
-		level.Info(Logger).Log("msg", "HTTP Request:\n%v", string(dump))
-	}
-
-	// Get ready to do request
-	httpClient := c.HTTPClient
-	if httpClient == nil {
-		httpClient = defaultHttpClient
-	}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
 
-	// Parse the sls error from body.
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		buf, err := ioutil.ReadAll(resp.Body)
+		// Get ready to do request
+		if httpClient == nil {
+			httpClient = defaultHttpClient
+		}
+		resp, err := httpClient.Do(req)
 		if err != nil {
-			return nil, readResponseError(err)
+			return nil, err
 		}
-		return nil, httpStatusNotOkError(buf, resp.Header, resp.StatusCode)
-	}
-	if IsDebugLevelMatched(5) {
-		dump, e := httputil.DumpResponse(resp, true)
-		if e != nil {
-			level.Info(Logger).Log("msg", e)
+
+		// Parse the sls error from body.
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			buf, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, readResponseError(err)
+			}
+			return nil, httpStatusNotOkError(buf, resp.Header, resp.StatusCode)
+		}
+		if IsDebugLevelMatched(5) {
+			dump, e := httputil.DumpResponse(resp, true)
+			if e != nil {
+				level.Info(Logger).Log("msg", e)
+			}
+			level.Info(Logger).Log("msg", "HTTP Response:\n%v", string(dump))
 		}
-		level.Info(Logger).Log("msg", "HTTP Response:\n%v", string(dump))
-	}
 
-	return resp, nil
+		return resp, nil
+	}
+	resp, err := chainInterceptors(interceptors, handler)(req)
+	tracing.endAttempt(attempt, resp, err)
+	tracing.end(resp, err)
+	return resp, err
 }
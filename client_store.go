@@ -15,6 +15,7 @@ import (
 )
 
 func convertLogstore(c *Client, project, logstore string) *LogStore {
+	c.initHttpClient()
 	c.accessKeyLock.RLock()
 	proj := convertLocked(c, project)
 	c.accessKeyLock.RUnlock()
@@ -321,6 +322,12 @@ func (c *Client) GetLogsToCompletedV3(project, logstore string, req *GetLogReque
 	return ls.GetLogsToCompletedV3(req)
 }
 
+// GetContextLogs query logs around the log of packID and packMeta
+func (c *Client) GetContextLogs(project, logstore string, backLines, forwardLines int32, packID, packMeta string) (*GetContextLogsResponse, error) {
+	ls := convertLogstore(c, project, logstore)
+	return ls.GetContextLogs(backLines, forwardLines, packID, packMeta)
+}
+
 // GetLogLinesV2 ...
 func (c *Client) GetLogLinesV2(project, logstore string, req *GetLogRequest) (*GetLogLinesResponse, error) {
 	ls := convertLogstore(c, project, logstore)
@@ -31,6 +31,9 @@ const (
 	// RequestIDHeader stands for the requestID in all response
 	RequestIDHeader = "x-log-requestid"
 
+	// RetryAfterHeader stands for the suggested retry interval in error response
+	RetryAfterHeader = "Retry-After"
+
 	GetLogsQueryInfo = "X-Log-Query-Info"
 	BodyRawSize      = "X-Log-Bodyrawsize"
 	HasSQLHeader     = "x-log-has-sql"
@@ -0,0 +1,162 @@
+package consumerLibrary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/go-kit/kit/log/level"
+)
+
+// CheckpointBackend is where the checkpoints of shards are loaded from and saved to.
+// The default one saves checkpoints to the consumer group on server, set LogHubConfig.CheckpointBackend
+// to save them elsewhere, and the consumer runs standalone without a consumer group then:
+// the consumer group is not created, no heartbeat is sent, and all shards of the logstore are consumed by the worker.
+type CheckpointBackend interface {
+	CheckpointStore
+	// UpdateCheckpoint saves the checkpoint of shard
+	UpdateCheckpoint(shardId int, checkpoint string) error
+}
+
+// serverCheckpointBackend saves checkpoints to the consumer group
+type serverCheckpointBackend struct {
+	consumer *ConsumerClient
+}
+
+func (b serverCheckpointBackend) GetCheckpoint(shardId int) (checkpoint string, err error) {
+	consumer := b.consumer
+	checkPonitList := []*sls.ConsumerGroupCheckPoint{}
+	for retry := 0; retry < 3; retry++ {
+		checkPonitList, err = consumer.client.GetCheckpoint(consumer.option.Project, consumer.option.Logstore, consumer.consumerGroup.ConsumerGroupName)
+		if err != nil {
+			level.Info(consumer.logger).Log("msg", "shard Get checkpoint gets errors, starts to try again", "shard", shardId, "error", err)
+			time.Sleep(1 * time.Second)
+		} else {
+			break
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, checkPoint := range checkPonitList {
+		if checkPoint.ShardID == shardId {
+			return checkPoint.CheckPoint, nil
+		}
+	}
+	return "", err
+}
+
+func (b serverCheckpointBackend) UpdateCheckpoint(shardId int, checkpoint string) error {
+	consumer := b.consumer
+	return consumer.client.UpdateCheckpoint(consumer.option.Project, consumer.option.Logstore, consumer.option.ConsumerGroupName, consumer.option.ConsumerName, shardId, checkpoint, true)
+}
+
+// MemoryCheckpointBackend keeps checkpoints in memory, they are lost once the process exits.
+// It is useful for tests and one-off readers.
+type MemoryCheckpointBackend struct {
+	mu          sync.RWMutex
+	checkpoints map[int]string
+}
+
+func NewMemoryCheckpointBackend() *MemoryCheckpointBackend {
+	return &MemoryCheckpointBackend{checkpoints: map[int]string{}}
+}
+
+func (b *MemoryCheckpointBackend) GetCheckpoint(shardId int) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.checkpoints[shardId], nil
+}
+
+func (b *MemoryCheckpointBackend) UpdateCheckpoint(shardId int, checkpoint string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.checkpoints[shardId] = checkpoint
+	return nil
+}
+
+// FileCheckpointBackend saves checkpoints of all shards to a local json file, eg. {"0": "MTU2...", "1": "MTU3..."}.
+// The file is replaced by renaming a temporary file in the same directory, so it is never half written.
+// A file should only be used by one worker at a time.
+type FileCheckpointBackend struct {
+	path        string
+	mu          sync.Mutex
+	checkpoints map[int]string
+}
+
+// NewFileCheckpointBackend loads the checkpoints saved in path, the file is created on the first update if not exists
+func NewFileCheckpointBackend(path string) (*FileCheckpointBackend, error) {
+	b := &FileCheckpointBackend{
+		path:        path,
+		checkpoints: map[int]string{},
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved := map[string]string{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	for shard, checkpoint := range saved {
+		shardId, err := strconv.Atoi(shard)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard %q in checkpoint file %s", shard, path)
+		}
+		b.checkpoints[shardId] = checkpoint
+	}
+	return b, nil
+}
+
+func (b *FileCheckpointBackend) GetCheckpoint(shardId int) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.checkpoints[shardId], nil
+}
+
+func (b *FileCheckpointBackend) UpdateCheckpoint(shardId int, checkpoint string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	saved := make(map[string]string, len(b.checkpoints)+1)
+	for shard, cp := range b.checkpoints {
+		saved[strconv.Itoa(shard)] = cp
+	}
+	saved[strconv.Itoa(shardId)] = checkpoint
+	if err := b.write(saved); err != nil {
+		return err
+	}
+	b.checkpoints[shardId] = checkpoint
+	return nil
+}
+
+func (b *FileCheckpointBackend) write(saved map[string]string) error {
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
@@ -0,0 +1,80 @@
+package consumerLibrary
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCheckpointBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	backend, err := NewFileCheckpointBackend(path)
+	require.NoError(t, err)
+	checkpoint, err := backend.GetCheckpoint(0)
+	require.NoError(t, err)
+	assert.Empty(t, checkpoint)
+
+	require.NoError(t, backend.UpdateCheckpoint(0, "MTA="))
+	require.NoError(t, backend.UpdateCheckpoint(1, "MjA="))
+	require.NoError(t, backend.UpdateCheckpoint(0, "MzA="))
+
+	backend, err = NewFileCheckpointBackend(path)
+	require.NoError(t, err)
+	checkpoint, err = backend.GetCheckpoint(0)
+	require.NoError(t, err)
+	assert.Equal(t, "MzA=", checkpoint)
+	checkpoint, err = backend.GetCheckpoint(1)
+	require.NoError(t, err)
+	assert.Equal(t, "MjA=", checkpoint)
+
+	// no temporary files are left
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err = NewFileCheckpointBackend(path)
+	assert.Error(t, err)
+}
+
+func TestStandaloneConsumer(t *testing.T) {
+	_, client, option := newTestOption(t, 2)
+	putTestLogs(t, client, "a", "b", "c")
+	backend := NewMemoryCheckpointBackend()
+	option.CheckpointBackend = backend
+
+	var mu sync.Mutex
+	consumed := map[string]bool{}
+	worker := InitConsumerWorkerWithCheckpointTracker(option, func(shard int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, logGroup := range logGroupList.LogGroups {
+			consumed[logGroup.Logs[0].Contents[0].GetValue()] = true
+		}
+		return "", tracker.SaveCheckPoint(true)
+	})
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 3
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+
+	consumerGroups, err := client.ListConsumerGroup(testProject, testLogstore)
+	require.NoError(t, err)
+	assert.Empty(t, consumerGroups)
+	for shard := 0; shard < 2; shard++ {
+		end, err := client.GetCursor(testProject, testLogstore, shard, "end")
+		require.NoError(t, err)
+		checkpoint, err := backend.GetCheckpoint(shard)
+		require.NoError(t, err)
+		assert.Equal(t, end, checkpoint)
+	}
+}
@@ -33,6 +33,7 @@ type DefaultCheckPointTracker struct {
 	savedCheckPoint   string // already saved
 	shardId           int
 	logger            log.Logger
+	monitor           *ShardMonitor // optional
 }
 
 func initConsumerCheckpointTracker(shardId int, consumerClient *ConsumerClient, consumerHeatBeat *ConsumerHeartBeat, logger log.Logger) *DefaultCheckPointTracker {
@@ -83,11 +84,15 @@ func (tracker *DefaultCheckPointTracker) GetShardId() int {
 }
 
 func (tracker *DefaultCheckPointTracker) flushCheckPoint() error {
-	if tracker.pendingCheckPoint == "" || tracker.pendingCheckPoint == tracker.savedCheckPoint {
+	if tracker.pendingCheckPoint == "" {
+		return nil
+	}
+	if tracker.pendingCheckPoint == tracker.savedCheckPoint {
+		tracker.recordCheckpoint()
 		return nil
 	}
 	for i := 0; ; i++ {
-		err := tracker.client.updateCheckPoint(tracker.shardId, tracker.pendingCheckPoint, true)
+		err := tracker.client.updateCheckPoint(tracker.shardId, tracker.pendingCheckPoint)
 		if err == nil {
 			break
 		}
@@ -116,5 +121,12 @@ func (tracker *DefaultCheckPointTracker) flushCheckPoint() error {
 	}
 
 	tracker.savedCheckPoint = tracker.pendingCheckPoint
+	tracker.recordCheckpoint()
 	return nil
 }
+
+func (tracker *DefaultCheckPointTracker) recordCheckpoint() {
+	if tracker.monitor != nil {
+		tracker.monitor.RecordCheckpoint()
+	}
+}
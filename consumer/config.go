@@ -5,6 +5,7 @@ import (
 
 	sls "github.com/aliyun/aliyun-log-go-sdk"
 	"github.com/go-kit/kit/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LogHubConfig struct {
@@ -53,6 +54,11 @@ type LogHubConfig struct {
 	//:param Region: region of sls endpoint, eg. cn-hangzhou, region must be set if AuthVersion is sls.AuthV4
 	//:param DisableRuntimeMetrics: disable runtime metrics, runtime metrics prints to local log.
 	//::param MaxIoWorkers: max io workers, default is 50. Smaller io workers will reduce memory usage, but may reduce throughput.
+	//:param TracerProvider: default nil, optional. If set, OpenTelemetry spans are created for each fetch and process of shards.
+	//:param ProcessFailurePolicy: default nil, optional. By default a batch is processed again and again until it succeeds,
+	//	  if set, the batch is handed to the dead letter handler after failing the max attempts, and the consuming moves on.
+	//:param CheckpointBackend: default nil, optional. If set, checkpoints are loaded from and saved to it instead of the consumer group,
+	//	  and the worker consumes all shards without creating a consumer group, eg. NewFileCheckpointBackend or NewMemoryCheckpointBackend.
 	Endpoint                  string
 	AccessKeyID               string
 	AccessKeySecret           string
@@ -85,6 +91,9 @@ type LogHubConfig struct {
 	Region                    string
 	DisableRuntimeMetrics     bool
 	MaxIoWorkers              int
+	TracerProvider            trace.TracerProvider
+	CheckpointBackend         CheckpointBackend
+	ProcessFailurePolicy      *ProcessFailurePolicy
 }
 
 const (
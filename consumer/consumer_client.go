@@ -1,7 +1,9 @@
 package consumerLibrary
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	sls "github.com/aliyun/aliyun-log-go-sdk"
@@ -10,10 +12,11 @@ import (
 )
 
 type ConsumerClient struct {
-	option        LogHubConfig
-	client        sls.ClientInterface
-	consumerGroup sls.ConsumerGroup
-	logger        log.Logger
+	option          LogHubConfig
+	client          sls.ClientInterface
+	consumerGroup   sls.ConsumerGroup
+	logger          log.Logger
+	checkpointStore CheckpointStore // optional, checkpoints in it take precedence over the server ones
 }
 
 func initConsumerClient(option LogHubConfig, logger log.Logger) *ConsumerClient {
@@ -53,6 +56,14 @@ func initConsumerClient(option LogHubConfig, logger log.Logger) *ConsumerClient
 	if option.Region != "" {
 		client.SetRegion(option.Region)
 	}
+	if option.TracerProvider != nil {
+		switch c := client.(type) {
+		case *sls.Client:
+			c.WithTracerProvider(option.TracerProvider)
+		case *sls.TokenAutoUpdateClient:
+			c.WithTracerProvider(option.TracerProvider)
+		}
+	}
 
 	consumerGroup := sls.ConsumerGroup{
 		ConsumerGroupName: option.ConsumerGroupName,
@@ -60,10 +71,10 @@ func initConsumerClient(option LogHubConfig, logger log.Logger) *ConsumerClient
 		InOrder:           option.InOrder,
 	}
 	consumerClient := &ConsumerClient{
-		option,
-		client,
-		consumerGroup,
-		logger,
+		option:        option,
+		client:        client,
+		consumerGroup: consumerGroup,
+		logger:        logger,
 	}
 
 	return consumerClient
@@ -103,35 +114,52 @@ func (consumer *ConsumerClient) createConsumerGroup() error {
 }
 
 func (consumer *ConsumerClient) heartBeat(heart []int) ([]int, error) {
+	if consumer.standalone() {
+		return consumer.listShardIds()
+	}
 	heldShard, err := consumer.client.HeartBeat(consumer.option.Project, consumer.option.Logstore, consumer.option.ConsumerGroupName, consumer.option.ConsumerName, heart)
 	return heldShard, err
 }
 
-func (consumer *ConsumerClient) updateCheckPoint(shardId int, checkpoint string, forceSucess bool) error {
-	return consumer.client.UpdateCheckpoint(consumer.option.Project, consumer.option.Logstore, consumer.option.ConsumerGroupName, consumer.option.ConsumerName, shardId, checkpoint, forceSucess)
+func (consumer *ConsumerClient) updateCheckPoint(shardId int, checkpoint string) error {
+	return consumer.checkpointBackend().UpdateCheckpoint(shardId, checkpoint)
 }
 
-// get a single shard checkpoint, if not，return ""
-func (consumer *ConsumerClient) getCheckPoint(shardId int) (checkpoint string, err error) {
-	checkPonitList := []*sls.ConsumerGroupCheckPoint{}
-	for retry := 0; retry < 3; retry++ {
-		checkPonitList, err = consumer.client.GetCheckpoint(consumer.option.Project, consumer.option.Logstore, consumer.consumerGroup.ConsumerGroupName)
-		if err != nil {
-			level.Info(consumer.logger).Log("msg", "shard Get checkpoint gets errors, starts to try again", "shard", shardId, "error", err)
-			time.Sleep(1 * time.Second)
-		} else {
-			break
-		}
+func (consumer *ConsumerClient) checkpointBackend() CheckpointBackend {
+	if consumer.option.CheckpointBackend != nil {
+		return consumer.option.CheckpointBackend
 	}
+	return serverCheckpointBackend{consumer: consumer}
+}
+
+// standalone returns whether the consumer runs without a consumer group, see CheckpointBackend
+func (consumer *ConsumerClient) standalone() bool {
+	return consumer.option.CheckpointBackend != nil
+}
+
+// listShardIds returns all shards of the logstore, which are held by a standalone consumer
+func (consumer *ConsumerClient) listShardIds() ([]int, error) {
+	shards, err := consumer.client.ListShards(consumer.option.Project, consumer.option.Logstore)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	shardIds := make([]int, 0, len(shards))
+	for _, shard := range shards {
+		shardIds = append(shardIds, shard.ShardID)
 	}
-	for _, checkPoint := range checkPonitList {
-		if checkPoint.ShardID == shardId {
-			return checkPoint.CheckPoint, nil
+	return shardIds, nil
+}
+
+// get a single shard checkpoint, if not，return ""
+func (consumer *ConsumerClient) getCheckPoint(shardId int) (checkpoint string, err error) {
+	if consumer.checkpointStore != nil {
+		checkpoint, err = consumer.checkpointStore.GetCheckpoint(shardId)
+		if err != nil || checkpoint != "" {
+			return checkpoint, err
 		}
+		// nothing saved in the store yet, eg. migrating from server checkpoints
 	}
-	return "", err
+	return consumer.checkpointBackend().GetCheckpoint(shardId)
 }
 
 func (consumer *ConsumerClient) getCursor(shardId int, from string) (string, error) {
@@ -139,7 +167,21 @@ func (consumer *ConsumerClient) getCursor(shardId int, from string) (string, err
 	return cursor, err
 }
 
-func (consumer *ConsumerClient) pullLogs(shardId int, cursor string) (gl *sls.LogGroupList, plm *sls.PullLogMeta, err error) {
+// isShardReadOnly returns whether the shard is read-only, eg. it has been split or merged
+func (consumer *ConsumerClient) isShardReadOnly(shardId int) (bool, error) {
+	shards, err := consumer.client.ListShards(consumer.option.Project, consumer.option.Logstore)
+	if err != nil {
+		return false, err
+	}
+	for _, shard := range shards {
+		if shard.ShardID == shardId {
+			return strings.EqualFold(shard.Status, "readonly"), nil
+		}
+	}
+	return false, fmt.Errorf("shard %d not found", shardId)
+}
+
+func (consumer *ConsumerClient) pullLogs(ctx context.Context, shardId int, cursor string) (gl *sls.LogGroupList, plm *sls.PullLogMeta, err error) {
 	plr := &sls.PullLogRequest{
 		Project:          consumer.option.Project,
 		Logstore:         consumer.option.Logstore,
@@ -150,7 +192,7 @@ func (consumer *ConsumerClient) pullLogs(shardId int, cursor string) (gl *sls.Lo
 		CompressType:     consumer.option.CompressType,
 	}
 	for retry := 0; retry < 3; retry++ {
-		gl, plm, err = consumer.client.PullLogsWithQuery(plr)
+		gl, plm, err = consumer.withContext(ctx).PullLogsWithQuery(plr)
 		if err != nil {
 			slsError, ok := err.(*sls.Error)
 			if ok {
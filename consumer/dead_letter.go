@@ -0,0 +1,211 @@
+package consumerLibrary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+	"github.com/gogo/protobuf/proto"
+)
+
+const (
+	defaultProcessMaxAttempts = 5
+	defaultProcessMaxBackoff  = 10 * time.Second
+)
+
+// ProcessFailurePolicy decides what to do with a batch of logs the processor keeps failing on.
+// Process is retried with exponential backoff, once it fails MaxAttempts times,
+// the batch is handed to DeadLetterHandler and the consuming of the shard moves on.
+type ProcessFailurePolicy struct {
+	// MaxAttempts is the times Process is called for a batch before it is dead-lettered, default 5
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled for each retry, default 50ms
+	InitialBackoff time.Duration
+	// MaxBackoff is the max wait between retries, default 10s
+	MaxBackoff time.Duration
+	// DeadLetterHandler receives the failed batches, required.
+	// If it returns an error or panics, it is retried until it succeeds or the consumer shuts down,
+	// the cursor does not move on before the batch is handled. If the consumer shuts down before,
+	// the checkpoint is not saved after the batch by the dead letter handling.
+	DeadLetterHandler DeadLetterHandler
+}
+
+func (p *ProcessFailurePolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return defaultProcessMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the wait before the next attempt, after the process failed attempt times
+func (p *ProcessFailurePolicy) backoff(attempt int) time.Duration {
+	if p == nil {
+		return processFailedSleepTime
+	}
+	backoff, maxBackoff := p.InitialBackoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = processFailedSleepTime
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultProcessMaxBackoff
+	}
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// DeadLetter is a batch of logs that the processor failed to process
+type DeadLetter struct {
+	ShardId      int
+	Cursor       string // cursor the batch is pulled from
+	NextCursor   string // cursor after the batch, where the consuming goes on
+	LogGroupList *sls.LogGroupList
+	Attempts     int
+	Err          error // error returned by the last attempt
+}
+
+type DeadLetterHandler interface {
+	HandleDeadLetter(letter *DeadLetter) error
+}
+
+type DeadLetterFunc func(letter *DeadLetter) error
+
+func (f DeadLetterFunc) HandleDeadLetter(letter *DeadLetter) error {
+	return f(letter)
+}
+
+// FileDeadLetterHandler appends dead letters to a local file, one json object per line, eg.
+// {"shard":0,"cursor":"MTU2...","nextCursor":"MTU3...","attempts":5,"error":"...","time":1700000000,"logGroupList":{...}}
+type FileDeadLetterHandler struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+type fileDeadLetter struct {
+	ShardId      int               `json:"shard"`
+	Cursor       string            `json:"cursor"`
+	NextCursor   string            `json:"nextCursor"`
+	Attempts     int               `json:"attempts"`
+	Error        string            `json:"error"`
+	Time         int64             `json:"time"`
+	LogGroupList *sls.LogGroupList `json:"logGroupList"`
+}
+
+// NewFileDeadLetterHandler opens path for appending, the file is created if not exists
+func NewFileDeadLetterHandler(path string) (*FileDeadLetterHandler, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDeadLetterHandler{file: file}, nil
+}
+
+func (h *FileDeadLetterHandler) HandleDeadLetter(letter *DeadLetter) error {
+	line := fileDeadLetter{
+		ShardId:      letter.ShardId,
+		Cursor:       letter.Cursor,
+		NextCursor:   letter.NextCursor,
+		Attempts:     letter.Attempts,
+		Time:         time.Now().Unix(),
+		LogGroupList: letter.LogGroupList,
+	}
+	if letter.Err != nil {
+		line.Error = letter.Err.Error()
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return h.file.Sync()
+}
+
+func (h *FileDeadLetterHandler) Close() error {
+	return h.file.Close()
+}
+
+// Keys of the contents added to the logs sent by ProducerDeadLetterHandler
+const (
+	DeadLetterShardKey  = "__dead_letter_shard__"
+	DeadLetterCursorKey = "__dead_letter_cursor__"
+	DeadLetterErrorKey  = "__dead_letter_error__"
+)
+
+// ProducerDeadLetterHandler sends the logs of dead letters to another logstore by a producer,
+// with the topic and source of the original log groups, and the shard, cursor and error of
+// the dead letter added to each log as contents.
+// A dead letter is handled once its logs are accepted by the producer, the producer is not started or closed by the handler.
+// The log groups of a letter are sent one by one, those accepted before a failure are not sent again when the letter
+// is retried, but they are sent again if the consumer restarts before the letter is handled, so delivery is at least once.
+type ProducerDeadLetterHandler struct {
+	producer *producer.Producer
+	project  string
+	logstore string
+
+	mu   sync.Mutex
+	sent map[string]int // number of log groups accepted of the letters failed to send, by shard and cursor
+}
+
+func NewProducerDeadLetterHandler(producer *producer.Producer, project, logstore string) *ProducerDeadLetterHandler {
+	return &ProducerDeadLetterHandler{
+		producer: producer,
+		project:  project,
+		logstore: logstore,
+		sent:     make(map[string]int),
+	}
+}
+
+func (h *ProducerDeadLetterHandler) HandleDeadLetter(letter *DeadLetter) error {
+	errMsg := ""
+	if letter.Err != nil {
+		errMsg = letter.Err.Error()
+	}
+	extra := []*sls.LogContent{
+		{Key: proto.String(DeadLetterShardKey), Value: proto.String(strconv.Itoa(letter.ShardId))},
+		{Key: proto.String(DeadLetterCursorKey), Value: proto.String(letter.Cursor)},
+		{Key: proto.String(DeadLetterErrorKey), Value: proto.String(errMsg)},
+	}
+	key := strconv.Itoa(letter.ShardId) + "/" + letter.Cursor
+	h.mu.Lock()
+	sent := h.sent[key]
+	h.mu.Unlock()
+	for i, logGroup := range letter.LogGroupList.LogGroups {
+		if i < sent || len(logGroup.Logs) == 0 {
+			continue
+		}
+		logs := make([]*sls.Log, 0, len(logGroup.Logs))
+		for _, log := range logGroup.Logs {
+			contents := make([]*sls.LogContent, 0, len(log.Contents)+len(extra))
+			contents = append(contents, log.Contents...)
+			contents = append(contents, extra...)
+			logs = append(logs, &sls.Log{
+				Time:     log.Time,
+				TimeNs:   log.TimeNs,
+				Contents: contents,
+			})
+		}
+		if err := h.producer.SendLogList(h.project, h.logstore, logGroup.GetTopic(), logGroup.GetSource(), logs); err != nil {
+			h.mu.Lock()
+			h.sent[key] = i
+			h.mu.Unlock()
+			return fmt.Errorf("send dead letter to %s/%s failed: %w", h.project, h.logstore, err)
+		}
+	}
+	h.mu.Lock()
+	delete(h.sent, key)
+	h.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,230 @@
+package consumerLibrary
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessFailurePolicyBackoff(t *testing.T) {
+	var policy *ProcessFailurePolicy
+	assert.Equal(t, processFailedSleepTime, policy.backoff(10))
+
+	policy = &ProcessFailurePolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(100))
+	assert.Equal(t, defaultProcessMaxAttempts, policy.maxAttempts())
+}
+
+func TestDeadLetter(t *testing.T) {
+	_, client, option := newTestOption(t, 1)
+	putTestLogs(t, client, "bad")
+
+	var mu sync.Mutex
+	var letters []*DeadLetter
+	var consumed []string
+	option.ProcessFailurePolicy = &ProcessFailurePolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		DeadLetterHandler: DeadLetterFunc(func(letter *DeadLetter) error {
+			mu.Lock()
+			defer mu.Unlock()
+			letters = append(letters, letter)
+			return nil
+		}),
+	}
+	worker := InitConsumerWorkerWithCheckpointTracker(option, func(shard int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, logGroup := range logGroupList.LogGroups {
+			content := logGroup.Logs[0].Contents[0].GetValue()
+			if content == "bad" {
+				return "", errors.New("malformed log")
+			}
+			consumed = append(consumed, content)
+		}
+		return "", tracker.SaveCheckPoint(true)
+	})
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(letters) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+	// the shard goes on after the bad batch
+	putTestLogs(t, client, "good")
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+
+	begin, err := client.GetCursor(testProject, testLogstore, 0, "begin")
+	require.NoError(t, err)
+	letter := letters[0]
+	assert.Equal(t, 0, letter.ShardId)
+	assert.Equal(t, begin, letter.Cursor)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.EqualError(t, letter.Err, "malformed log")
+	assert.Equal(t, "bad", letter.LogGroupList.LogGroups[0].Logs[0].Contents[0].GetValue())
+	assert.Equal(t, []string{"good"}, consumed)
+}
+
+func TestDeadLetterHandlerPanic(t *testing.T) {
+	_, client, option := newTestOption(t, 1)
+	putTestLogs(t, client, "bad")
+
+	var mu sync.Mutex
+	var calls int
+	option.ProcessFailurePolicy = &ProcessFailurePolicy{
+		MaxAttempts:    1,
+		InitialBackoff: time.Millisecond,
+		DeadLetterHandler: DeadLetterFunc(func(letter *DeadLetter) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			if calls == 1 {
+				panic("dead letter handler panics")
+			}
+			return nil
+		}),
+	}
+	worker := InitConsumerWorkerWithCheckpointTracker(option, func(shard int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+		return "", errors.New("malformed log")
+	})
+	worker.Start()
+	// the panic is recovered as an error, and the handler is retried
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls == 2
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+}
+
+func TestFileDeadLetterHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	handler, err := NewFileDeadLetterHandler(path)
+	require.NoError(t, err)
+	defer handler.Close()
+	for _, cursor := range []string{"MA==", "MQ=="} {
+		require.NoError(t, handler.HandleDeadLetter(&DeadLetter{
+			ShardId: 1,
+			Cursor:  cursor,
+			LogGroupList: &sls.LogGroupList{LogGroups: []*sls.LogGroup{{
+				Logs: []*sls.Log{{Time: proto.Uint32(1), Contents: []*sls.LogContent{{Key: proto.String("k"), Value: proto.String("v")}}}},
+			}}},
+			Attempts: 5,
+			Err:      errors.New("failed"),
+		}))
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var lines []fileDeadLetter
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line fileDeadLetter
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "MQ==", lines[1].Cursor)
+	assert.Equal(t, "failed", lines[1].Error)
+	assert.Equal(t, "v", lines[1].LogGroupList.LogGroups[0].Logs[0].Contents[0].GetValue())
+}
+
+func TestProducerDeadLetterHandler(t *testing.T) {
+	server, client, option := newTestOption(t, 1)
+	require.NoError(t, client.CreateLogStore(testProject, "dead-letter", 1, 1, false, 0))
+
+	config := producer.GetDefaultProducerConfig()
+	config.Endpoint = option.Endpoint
+	config.HTTPClient = option.HTTPClient
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	p, err := producer.NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+
+	handler := NewProducerDeadLetterHandler(p, testProject, "dead-letter")
+	require.NoError(t, handler.HandleDeadLetter(&DeadLetter{
+		ShardId: 2,
+		Cursor:  "MA==",
+		LogGroupList: &sls.LogGroupList{LogGroups: []*sls.LogGroup{{
+			Topic: proto.String("topic"),
+			Logs:  []*sls.Log{{Time: proto.Uint32(1), Contents: []*sls.LogContent{{Key: proto.String("k"), Value: proto.String("v")}}}},
+		}}},
+		Err: errors.New("failed"),
+	}))
+	p.SafeClose()
+
+	logs := server.Logs(testProject, "dead-letter")
+	require.Len(t, logs, 1)
+	contents := map[string]string{}
+	for _, content := range logs[0].Contents {
+		contents[content.GetKey()] = content.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		"k":                 "v",
+		DeadLetterShardKey:  "2",
+		DeadLetterCursorKey: "MA==",
+		DeadLetterErrorKey:  "failed",
+	}, contents)
+}
+
+func TestProducerDeadLetterHandlerRetry(t *testing.T) {
+	server, client, option := newTestOption(t, 1)
+	require.NoError(t, client.CreateLogStore(testProject, "dead-letter", 1, 1, false, 0))
+
+	config := producer.GetDefaultProducerConfig()
+	config.Endpoint = option.Endpoint
+	config.HTTPClient = option.HTTPClient
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	// the second log group is rejected until the first one is sent
+	config.TotalSizeLnBytes = 1
+	config.MaxBlockSec = 0
+	p, err := producer.NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+
+	newLogGroup := func(value string) *sls.LogGroup {
+		return &sls.LogGroup{Logs: []*sls.Log{{Time: proto.Uint32(1), Contents: []*sls.LogContent{{Key: proto.String("k"), Value: proto.String(value)}}}}}
+	}
+	letter := &DeadLetter{
+		ShardId:      0,
+		Cursor:       "MA==",
+		LogGroupList: &sls.LogGroupList{LogGroups: []*sls.LogGroup{newLogGroup("1"), newLogGroup("2")}},
+		Err:          errors.New("failed"),
+	}
+	handler := NewProducerDeadLetterHandler(p, testProject, "dead-letter")
+	require.Error(t, handler.HandleDeadLetter(letter))
+	require.Eventually(t, func() bool {
+		return handler.HandleDeadLetter(letter) == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	p.SafeClose()
+
+	var values []string
+	for _, log := range server.Logs(testProject, "dead-letter") {
+		values = append(values, log.Contents[0].GetValue())
+	}
+	assert.Equal(t, []string{"1", "2"}, values)
+	assert.Empty(t, handler.sent)
+}
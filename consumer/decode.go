@@ -0,0 +1,19 @@
+package consumerLibrary
+
+import (
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/slsencoding"
+)
+
+// DecodeProcessFunc returns a ProcessFunc which decodes the logs of each batch to T by slsencoding.UnmarshalLogGroupList
+// before calling do, T is a struct with sls tags or a map[string]string.
+// A batch that fails to decode is a process failure, it is retried and dead-lettered as configured by ProcessFailurePolicy.
+func DecodeProcessFunc[T any](do func(shardId int, records []T, tracker CheckPointTracker) (string, error)) ProcessFunc {
+	return func(shardId int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+		var records []T
+		if err := slsencoding.UnmarshalLogGroupList(logGroupList, &records); err != nil {
+			return "", err
+		}
+		return do(shardId, records, tracker)
+	}
+}
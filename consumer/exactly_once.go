@@ -0,0 +1,126 @@
+package consumerLibrary
+
+import (
+	"encoding/base64"
+	"strconv"
+	"sync"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+// CheckpointStore is where the checkpoints of shards are saved together with the output of processing,
+// eg. a table in the same database as the output, so that they can be committed atomically.
+type CheckpointStore interface {
+	// GetCheckpoint returns the cursor saved with the output of the shard, "" if nothing is saved
+	GetCheckpoint(shardId int) (string, error)
+}
+
+// ExactlyOnceProcessFunc handles the log groups of a shard. It must save the output together with nextCursor
+// to the CheckpointStore atomically, eg. in one transaction, and return nil only if they are committed.
+// Log groups whose output has already been committed are filtered out before it is called.
+type ExactlyOnceProcessFunc func(shardId int, logGroups []*sls.LogGroup, nextCursor string) error
+
+// InitConsumerWorkerExactlyOnce creates a consumer worker which processes each log group exactly once.
+// The consuming of a shard resumes from the checkpoint in store instead of the one saved in the consumer group,
+// falls back to the consumer group checkpoint and CursorPosition if nothing is saved in store.
+// If process fails after its output is committed, the committed log groups are skipped when processing again,
+// which is decided by LogGroup.GetCursor(), so it does not work with Query.
+// The checkpoints of consumer group are still saved, but only for monitoring the progress of consuming.
+func InitConsumerWorkerExactlyOnce(option LogHubConfig, store CheckpointStore, process ExactlyOnceProcessFunc) *ConsumerWorker {
+	worker := InitConsumerWorkerWithProcessor(option, &exactlyOnceProcessor{
+		store:     store,
+		process:   process,
+		committed: map[int]int64{},
+	})
+	worker.client.checkpointStore = store
+	return worker
+}
+
+type exactlyOnceProcessor struct {
+	store     CheckpointStore
+	process   ExactlyOnceProcessFunc
+	mu        sync.Mutex
+	committed map[int]int64 // shard -> offset of the committed next cursor
+}
+
+func (p *exactlyOnceProcessor) Process(shardId int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+	committed, err := p.committedOffset(shardId)
+	if err != nil {
+		return "", err
+	}
+	logGroups := make([]*sls.LogGroup, 0, len(logGroupList.LogGroups))
+	for _, logGroup := range logGroupList.LogGroups {
+		if offset, ok := decodeCursor(logGroup.GetCursor()); ok && offset < committed {
+			continue
+		}
+		logGroups = append(logGroups, logGroup)
+	}
+
+	nextCursor := tracker.GetNextCursor()
+	if len(logGroups) > 0 {
+		if err := p.process(shardId, logGroups, nextCursor); err != nil {
+			// the output may be committed or not, load it from store again
+			p.forget(shardId)
+			return "", err
+		}
+	}
+	if offset, ok := decodeCursor(nextCursor); ok && offset > committed {
+		p.mu.Lock()
+		p.committed[shardId] = offset
+		p.mu.Unlock()
+	}
+	return "", tracker.SaveCheckPoint(false)
+}
+
+func (p *exactlyOnceProcessor) Shutdown(CheckPointTracker) error {
+	return nil
+}
+
+// OnShardRevoked drops the cached checkpoint, the shard may be processed by others before assigned back
+func (p *exactlyOnceProcessor) OnShardRevoked(shardId int) {
+	p.forget(shardId)
+}
+
+// committedOffset returns the offset of the committed checkpoint of shard, -1 if unknown
+func (p *exactlyOnceProcessor) committedOffset(shardId int) (int64, error) {
+	p.mu.Lock()
+	offset, ok := p.committed[shardId]
+	p.mu.Unlock()
+	if ok {
+		return offset, nil
+	}
+	checkpoint, err := p.store.GetCheckpoint(shardId)
+	if err != nil {
+		return 0, err
+	}
+	offset, ok = decodeCursor(checkpoint)
+	if !ok {
+		offset = -1
+	}
+	p.mu.Lock()
+	p.committed[shardId] = offset
+	p.mu.Unlock()
+	return offset, nil
+}
+
+func (p *exactlyOnceProcessor) forget(shardId int) {
+	p.mu.Lock()
+	delete(p.committed, shardId)
+	p.mu.Unlock()
+}
+
+// decodeCursor returns the offset of a cursor in shard, false if the cursor is not comparable
+func decodeCursor(cursor string) (int64, bool) {
+	if cursor == "" {
+		return 0, false
+	}
+	b, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return offset, true
+}
@@ -0,0 +1,89 @@
+package consumerLibrary
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutput commits the output and checkpoints together, like a transaction of a database
+type memoryOutput struct {
+	mu          sync.Mutex
+	contents    []string
+	checkpoints map[int]string
+}
+
+func (o *memoryOutput) GetCheckpoint(shardId int) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.checkpoints[shardId], nil
+}
+
+func (o *memoryOutput) commit(shardId int, logGroups []*sls.LogGroup, nextCursor string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, logGroup := range logGroups {
+		o.contents = append(o.contents, logGroup.Logs[0].Contents[0].GetValue())
+	}
+	o.checkpoints[shardId] = nextCursor
+}
+
+func (o *memoryOutput) consumed() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.contents...)
+}
+
+func TestExactlyOnce(t *testing.T) {
+	_, client, option := newTestOption(t, 1)
+	putTestLogs(t, client, "a", "b")
+
+	output := &memoryOutput{checkpoints: map[int]string{}}
+	failed := false
+	worker := InitConsumerWorkerExactlyOnce(option, output, func(shardId int, logGroups []*sls.LogGroup, nextCursor string) error {
+		output.commit(shardId, logGroups, nextCursor)
+		if !failed {
+			// committed, but the result is lost
+			failed = true
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		return len(output.consumed()) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+	// the failed batch is retried without committing it twice
+	time.Sleep(processFailedSleepTime + 500*time.Millisecond)
+	worker.StopAndWait()
+	assert.Equal(t, []string{"a", "b"}, output.consumed())
+
+	// resume from the store even if the consumer group has no checkpoint
+	putTestLogs(t, client, "c")
+	option.ConsumerGroupName = "another-group"
+	worker = InitConsumerWorkerExactlyOnce(option, output, func(shardId int, logGroups []*sls.LogGroup, nextCursor string) error {
+		output.commit(shardId, logGroups, nextCursor)
+		return nil
+	})
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		return len(output.consumed()) == 3
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+	assert.Equal(t, []string{"a", "b", "c"}, output.consumed())
+}
+
+func TestDecodeCursor(t *testing.T) {
+	offset, ok := decodeCursor("MTIz")
+	assert.True(t, ok)
+	assert.Equal(t, int64(123), offset)
+	_, ok = decodeCursor("")
+	require.False(t, ok)
+	_, ok = decodeCursor("not a cursor")
+	require.False(t, ok)
+}
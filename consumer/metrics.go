@@ -0,0 +1,158 @@
+package consumerLibrary
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "sls"
+	metricsSubsystem = "consumer"
+)
+
+// latencyBuckets are the buckets of latency histograms in seconds, from 1ms to about 32s
+var latencyBuckets = prometheus.ExponentialBuckets(0.001, 2, 16)
+
+// consumerMetrics holds the cumulative prometheus metrics of all shards consumed by a worker
+type consumerMetrics struct {
+	fetchDuration     *prometheus.HistogramVec // shard
+	fetchFailures     *prometheus.CounterVec   // shard
+	fetchRawBytes     *prometheus.CounterVec   // shard
+	fetchLogGroups    *prometheus.CounterVec   // shard
+	processDuration   *prometheus.HistogramVec // shard
+	processFailures   *prometheus.CounterVec   // shard
+	deadLetters       *prometheus.CounterVec   // shard
+	lagDesc           *prometheus.Desc
+	checkpointAgeDesc *prometheus.Desc
+	vecs              []prometheus.Collector
+
+	shards sync.Map // map[int]*ShardMonitor
+}
+
+func newConsumerMetrics(option LogHubConfig) *consumerMetrics {
+	constLabels := prometheus.Labels{
+		"project":        option.Project,
+		"logstore":       option.Logstore,
+		"consumer_group": option.ConsumerGroupName,
+		"consumer":       option.ConsumerName,
+	}
+	shardLabels := []string{"shard"}
+	m := &consumerMetrics{
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "fetch_duration_seconds",
+			Help:        "Latency of pulling logs from a shard.",
+			Buckets:     latencyBuckets,
+			ConstLabels: constLabels,
+		}, shardLabels),
+		fetchFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "fetch_failures_total",
+			Help:        "Total number of failed pulls of a shard.",
+			ConstLabels: constLabels,
+		}, shardLabels),
+		fetchRawBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "fetch_raw_bytes_total",
+			Help:        "Total raw size of the logs pulled from a shard.",
+			ConstLabels: constLabels,
+		}, shardLabels),
+		fetchLogGroups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "fetch_log_groups_total",
+			Help:        "Total number of log groups pulled from a shard.",
+			ConstLabels: constLabels,
+		}, shardLabels),
+		processDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "process_duration_seconds",
+			Help:        "Latency of the user processor for logs of a shard.",
+			Buckets:     latencyBuckets,
+			ConstLabels: constLabels,
+		}, shardLabels),
+		processFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "process_failures_total",
+			Help:        "Total number of errors or panics returned by the user processor.",
+			ConstLabels: constLabels,
+		}, shardLabels),
+		deadLetters: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Subsystem:   metricsSubsystem,
+			Name:        "dead_letters_total",
+			Help:        "Total number of batches handed to the dead letter handler after process failed.",
+			ConstLabels: constLabels,
+		}, shardLabels),
+		lagDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "lag_seconds"),
+			"Time since the latest log fetched from a shard, 0 if the shard has been consumed to the end.",
+			shardLabels, constLabels),
+		checkpointAgeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "checkpoint_age_seconds"),
+			"Time since the checkpoint of a shard was last saved or found up to date.",
+			shardLabels, constLabels),
+	}
+	m.vecs = []prometheus.Collector{
+		m.fetchDuration,
+		m.fetchFailures,
+		m.fetchRawBytes,
+		m.fetchLogGroups,
+		m.processDuration,
+		m.processFailures,
+		m.deadLetters,
+	}
+	return m
+}
+
+// addShard starts exposing metrics of the shard
+func (m *consumerMetrics) addShard(monitor *ShardMonitor) {
+	m.shards.Store(monitor.shard, monitor)
+}
+
+// removeShard stops exposing metrics of the shard, eg. after it is assigned to other consumers
+func (m *consumerMetrics) removeShard(shard int) {
+	m.shards.Delete(shard)
+	label := strconv.Itoa(shard)
+	for _, vec := range []interface{ DeleteLabelValues(...string) bool }{
+		m.fetchDuration, m.fetchFailures, m.fetchRawBytes, m.fetchLogGroups,
+		m.processDuration, m.processFailures, m.deadLetters,
+	} {
+		vec.DeleteLabelValues(label)
+	}
+}
+
+func (m *consumerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.vecs {
+		c.Describe(ch)
+	}
+	ch <- m.lagDesc
+	ch <- m.checkpointAgeDesc
+}
+
+func (m *consumerMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.vecs {
+		c.Collect(ch)
+	}
+	now := time.Now()
+	m.shards.Range(func(key, value interface{}) bool {
+		monitor := value.(*ShardMonitor)
+		ch <- prometheus.MustNewConstMetric(m.lagDesc, prometheus.GaugeValue, monitor.lag(now).Seconds(), monitor.shardLabel)
+		ch <- prometheus.MustNewConstMetric(m.checkpointAgeDesc, prometheus.GaugeValue, monitor.checkpointAge(now).Seconds(), monitor.shardLabel)
+		return true
+	})
+}
+
+// Collector returns a prometheus.Collector which exposes the runtime metrics of the shards consumed by the worker,
+// eg. fetch and process latency, raw bytes pulled, lag and checkpoint age, labeled by shard.
+func (consumerWorker *ConsumerWorker) Collector() prometheus.Collector {
+	return consumerWorker.metrics
+}
@@ -0,0 +1,65 @@
+package consumerLibrary
+
+import (
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerMetrics(t *testing.T) {
+	metrics := newConsumerMetrics(LogHubConfig{
+		Project:           "test-project",
+		Logstore:          "test-logstore",
+		ConsumerGroupName: "test-group",
+		ConsumerName:      "test-consumer",
+	})
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(metrics))
+
+	monitor := newShardMonitor(1, time.Minute)
+	monitor.prom = metrics
+	metrics.addShard(monitor)
+
+	start := time.Now().Add(-10 * time.Millisecond)
+	monitor.RecordFetchRequest(&sls.PullLogMeta{RawSize: 100, Count: 2}, nil, start)
+	monitor.RecordFetchRequest(nil, sls.NewClientError(nil), start)
+	monitor.RecordProcess(nil, start)
+	logTime := uint32(time.Now().Add(-time.Minute).Unix())
+	monitor.RecordFetchedLogs(&sls.LogGroupList{
+		LogGroups: []*sls.LogGroup{{Logs: []*sls.Log{{Time: proto.Uint32(logTime)}}}},
+	}, false)
+
+	assert.Equal(t, 100.0, testutil.ToFloat64(metrics.fetchRawBytes.WithLabelValues("1")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.fetchLogGroups.WithLabelValues("1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.fetchFailures.WithLabelValues("1")))
+	assert.GreaterOrEqual(t, monitor.lag(time.Now()), time.Minute)
+
+	monitor.RecordFetchedLogs(nil, true)
+	assert.Equal(t, time.Duration(0), monitor.lag(time.Now()))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	names := map[string]bool{}
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	for _, name := range []string{
+		"sls_consumer_fetch_duration_seconds",
+		"sls_consumer_fetch_raw_bytes_total",
+		"sls_consumer_process_duration_seconds",
+		"sls_consumer_lag_seconds",
+		"sls_consumer_checkpoint_age_seconds",
+	} {
+		assert.True(t, names[name], name)
+	}
+
+	metrics.removeShard(1)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics, "sls_consumer_lag_seconds"))
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics, "sls_consumer_fetch_raw_bytes_total"))
+}
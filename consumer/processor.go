@@ -17,3 +17,25 @@ func (processor ProcessFunc) Shutdown(checkpointTracker CheckPointTracker) error
 	// Do nothing
 	return nil
 }
+
+// ShardAssignedListener is an optional interface of Processor,
+// OnShardAssigned is called when a shard is assigned to the consumer, before the first Process of the shard.
+// initialCursor is the cursor the consuming starts from, either the saved checkpoint or the one of CursorPosition.
+type ShardAssignedListener interface {
+	OnShardAssigned(shardId int, initialCursor string)
+}
+
+// ShardRevokedListener is an optional interface of Processor,
+// OnShardRevoked is called when the consumer stops consuming a shard, because the shard is
+// reassigned to another consumer or the worker is stopping.
+// It is called after Shutdown and the final checkpoint flush, so it is the place to flush per-shard state.
+type ShardRevokedListener interface {
+	OnShardRevoked(shardId int)
+}
+
+// ShardEndListener is an optional interface of Processor,
+// OnShardEnd is called once when a read-only shard, eg. the parent of a split, is consumed to the end
+// and will not have any new data.
+type ShardEndListener interface {
+	OnShardEnd(shardId int)
+}
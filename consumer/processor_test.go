@@ -0,0 +1,161 @@
+package consumerLibrary
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/slstest"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testProject  = "test-project"
+	testLogstore = "test-logstore"
+)
+
+// newTestOption starts a fake server with a logstore of shardCount shards,
+// and returns the option of a consumer consuming it from the beginning
+func newTestOption(t *testing.T, shardCount int) (*slstest.Server, sls.ClientInterface, LogHubConfig) {
+	server := slstest.NewServer()
+	t.Cleanup(server.Close)
+	client := server.NewClient()
+	_, err := client.CreateProject(testProject, "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore(testProject, testLogstore, 1, shardCount, false, 0))
+	option := LogHubConfig{
+		Endpoint:                  server.Endpoint,
+		HTTPClient:                server.HTTPClient(),
+		AccessKeyID:               "id",
+		AccessKeySecret:           "secret",
+		Project:                   testProject,
+		Logstore:                  testLogstore,
+		ConsumerGroupName:         "test-group",
+		ConsumerName:              "test-consumer",
+		CursorPosition:            BEGIN_CURSOR,
+		HeartbeatIntervalInSecond: 1,
+		AllowLogLevel:             "error",
+	}
+	return server, client, option
+}
+
+func putTestLogs(t *testing.T, client sls.ClientInterface, contents ...string) {
+	for _, content := range contents {
+		logGroup := &sls.LogGroup{Logs: []*sls.Log{{
+			Time:     proto.Uint32(uint32(time.Now().Unix())),
+			Contents: []*sls.LogContent{{Key: proto.String("content"), Value: proto.String(content)}},
+		}}}
+		require.NoError(t, client.PutLogs(testProject, testLogstore, logGroup))
+	}
+}
+
+type shardListenerProcessor struct {
+	ProcessFunc
+	mu       sync.Mutex
+	assigned map[int]string
+	revoked  map[int]bool
+	ended    map[int]bool
+}
+
+func (p *shardListenerProcessor) OnShardAssigned(shardId int, initialCursor string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.assigned[shardId] = initialCursor
+}
+
+func (p *shardListenerProcessor) OnShardRevoked(shardId int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revoked[shardId] = true
+}
+
+func (p *shardListenerProcessor) OnShardEnd(shardId int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ended[shardId] = true
+}
+
+func TestShardListener(t *testing.T) {
+	server, client, option := newTestOption(t, 2)
+	putTestLogs(t, client, "a", "b")
+	require.True(t, server.SetShardReadOnly(testProject, testLogstore, 1))
+
+	processor := &shardListenerProcessor{
+		ProcessFunc: func(shard int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+			return "", tracker.SaveCheckPoint(false)
+		},
+		assigned: map[int]string{},
+		revoked:  map[int]bool{},
+		ended:    map[int]bool{},
+	}
+	worker := InitConsumerWorkerWithProcessor(option, processor)
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		processor.mu.Lock()
+		defer processor.mu.Unlock()
+		return len(processor.assigned) == 2 && processor.ended[1]
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+	begin, err := client.GetCursor(testProject, testLogstore, 0, "begin")
+	require.NoError(t, err)
+	assert.Equal(t, begin, processor.assigned[0])
+	assert.False(t, processor.ended[0])
+	assert.Equal(t, map[int]bool{0: true, 1: true}, processor.revoked)
+}
+
+func TestShardListenerIsOptional(t *testing.T) {
+	_, client, option := newTestOption(t, 1)
+	putTestLogs(t, client, "a")
+
+	var mu sync.Mutex
+	var consumed []string
+	worker := InitConsumerWorkerWithCheckpointTracker(option, func(shard int, logGroupList *sls.LogGroupList, tracker CheckPointTracker) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, logGroup := range logGroupList.LogGroups {
+			consumed = append(consumed, logGroup.Logs[0].Contents[0].GetValue())
+		}
+		return "", nil
+	})
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+}
+
+type testContent struct {
+	Content string `sls:"content"`
+}
+
+func TestDecodeProcessFunc(t *testing.T) {
+	_, client, option := newTestOption(t, 1)
+	putTestLogs(t, client, "a", "b")
+
+	var mu sync.Mutex
+	var consumed []string
+	worker := InitConsumerWorkerWithProcessor(option, DecodeProcessFunc(func(shardId int, records []testContent, tracker CheckPointTracker) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, record := range records {
+			consumed = append(consumed, record.Content)
+		}
+		return "", nil
+	}))
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+	assert.Equal(t, []string{"a", "b"}, consumed)
+}
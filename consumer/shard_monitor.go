@@ -1,6 +1,7 @@
 package consumerLibrary
 
 import (
+	"strconv"
 	"time"
 
 	"go.uber.org/atomic"
@@ -25,6 +26,12 @@ type ShardMonitor struct {
 	reportInterval time.Duration
 	lastReportTime time.Time
 	metrics        atomic.Value // *MonitorMetrics
+
+	prom           *consumerMetrics // optional
+	shardLabel     string
+	latestLogTime  atomic.Int64 // unix seconds of the latest log fetched
+	caughtUp       atomic.Bool
+	checkpointTime atomic.Int64 // unix nanoseconds of last time the checkpoint is saved or up to date
 }
 
 func newShardMonitor(shard int, reportInterval time.Duration) *ShardMonitor {
@@ -32,8 +39,10 @@ func newShardMonitor(shard int, reportInterval time.Duration) *ShardMonitor {
 		shard:          shard,
 		reportInterval: reportInterval,
 		lastReportTime: time.Now(),
+		shardLabel:     strconv.Itoa(shard),
 	}
 	monitor.metrics.Store(&MonitorMetrics{})
+	monitor.checkpointTime.Store(time.Now().UnixNano())
 	return monitor
 }
 
@@ -45,6 +54,51 @@ func (m *ShardMonitor) RecordFetchRequest(plm *sls.PullLogMeta, err error, start
 		metrics.logRawSize.Add(int64(plm.RawSize))
 	}
 	metrics.fetchLogHistogram.AddSample(float64(time.Since(start).Microseconds()))
+
+	if m.prom == nil {
+		return
+	}
+	if err != nil {
+		m.prom.fetchFailures.WithLabelValues(m.shardLabel).Inc()
+	} else {
+		m.prom.fetchRawBytes.WithLabelValues(m.shardLabel).Add(float64(plm.RawSize))
+		m.prom.fetchLogGroups.WithLabelValues(m.shardLabel).Add(float64(plm.Count))
+	}
+	m.prom.fetchDuration.WithLabelValues(m.shardLabel).Observe(time.Since(start).Seconds())
+}
+
+// RecordFetchedLogs records the progress of consuming, caughtUp is true if the end of shard is reached
+func (m *ShardMonitor) RecordFetchedLogs(logGroupList *sls.LogGroupList, caughtUp bool) {
+	m.caughtUp.Store(caughtUp)
+	if caughtUp || logGroupList == nil || len(logGroupList.LogGroups) == 0 {
+		return
+	}
+	// logs are appended in order, so the last log is approximately the latest one
+	logs := logGroupList.LogGroups[len(logGroupList.LogGroups)-1].Logs
+	if len(logs) > 0 {
+		m.latestLogTime.Store(int64(logs[len(logs)-1].GetTime()))
+	}
+}
+
+// RecordCheckpoint records that the checkpoint is saved or already up to date
+func (m *ShardMonitor) RecordCheckpoint() {
+	m.checkpointTime.Store(time.Now().UnixNano())
+}
+
+func (m *ShardMonitor) lag(now time.Time) time.Duration {
+	latest := m.latestLogTime.Load()
+	if m.caughtUp.Load() || latest == 0 {
+		return 0
+	}
+	lag := now.Sub(time.Unix(latest, 0))
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+func (m *ShardMonitor) checkpointAge(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, m.checkpointTime.Load()))
 }
 
 func (m *ShardMonitor) RecordProcess(err error, start time.Time) {
@@ -53,6 +107,20 @@ func (m *ShardMonitor) RecordProcess(err error, start time.Time) {
 		metrics.processFailedCount.Inc()
 	}
 	metrics.processHistogram.AddSample(float64(time.Since(start).Microseconds()))
+
+	if m.prom == nil {
+		return
+	}
+	if err != nil {
+		m.prom.processFailures.WithLabelValues(m.shardLabel).Inc()
+	}
+	m.prom.processDuration.WithLabelValues(m.shardLabel).Observe(time.Since(start).Seconds())
+}
+
+func (m *ShardMonitor) RecordDeadLetter() {
+	if m.prom != nil {
+		m.prom.deadLetters.WithLabelValues(m.shardLabel).Inc()
+	}
 }
 
 func (m *ShardMonitor) getAndResetMetrics() *MonitorMetrics {
@@ -1,6 +1,7 @@
 package consumerLibrary
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"sync"
@@ -20,6 +21,7 @@ const (
 	fetchFailedSleepTime           = 100 * time.Millisecond // todo: use backoff interval, [1, 2, 4, 8, ...]
 	shutdownFailedSleepTime        = 100 * time.Millisecond
 	flushCheckPointFailedSleepTime = 100 * time.Millisecond
+	shardStatusCheckInterval       = 30 * time.Second
 )
 
 type ShardConsumerWorker struct {
@@ -35,9 +37,13 @@ type ShardConsumerWorker struct {
 	stopped                *atomic.Bool
 	startOnceFlag          sync.Once
 	ioThrottler            ioThrottler
+
+	assigned             bool // OnShardAssigned is called
+	shardEnded           bool // OnShardEnd is called
+	lastShardStatusCheck time.Time
 }
 
-func newShardConsumerWorker(shardId int, consumerClient *ConsumerClient, consumerHeartBeat *ConsumerHeartBeat, processor Processor, logger log.Logger, ioThrottler ioThrottler) *ShardConsumerWorker {
+func newShardConsumerWorker(shardId int, consumerClient *ConsumerClient, consumerHeartBeat *ConsumerHeartBeat, processor Processor, logger log.Logger, ioThrottler ioThrottler, metrics *consumerMetrics) *ShardConsumerWorker {
 	shardConsumeWorker := &ShardConsumerWorker{
 		processor:                 processor,
 		consumerCheckPointTracker: initConsumerCheckpointTracker(shardId, consumerClient, consumerHeartBeat, logger),
@@ -50,6 +56,11 @@ func newShardConsumerWorker(shardId int, consumerClient *ConsumerClient, consume
 		monitor:                   newShardMonitor(shardId, time.Minute),
 		ioThrottler:               ioThrottler,
 	}
+	if metrics != nil {
+		shardConsumeWorker.monitor.prom = metrics
+		shardConsumeWorker.consumerCheckPointTracker.monitor = shardConsumeWorker.monitor
+		metrics.addShard(shardConsumeWorker.monitor)
+	}
 	return shardConsumeWorker
 }
 
@@ -68,6 +79,9 @@ func (c *ShardConsumerWorker) runLoop() {
 
 	cursor := c.getInitCursor()
 	level.Info(c.logger).Log("msg", "runLoop got init cursor", "cursor", cursor)
+	if !c.shutDownFlag.Load() {
+		c.onShardAssigned(cursor)
+	}
 
 	for !c.shutDownFlag.Load() {
 		lastFetchTime := time.Now()
@@ -101,8 +115,14 @@ func (c *ShardConsumerWorker) fetchLogs(cursor string) (shouldCallProcess bool,
 	defer c.ioThrottler.Release()
 
 	start := time.Now()
-	logGroupList, plm, err := c.client.pullLogs(c.shardId, cursor)
+	ctx, span := c.startSpan("consumer fetch", attrCursor.String(cursor))
+	logGroupList, plm, err := c.client.pullLogs(ctx, c.shardId, cursor)
 	c.monitor.RecordFetchRequest(plm, err, start)
+	if plm != nil {
+		endSpan(span, err, attrLogGroupCount.Int(plm.Count), attrRawSize.Int(plm.RawSize))
+	} else {
+		endSpan(span, err)
+	}
 
 	if err != nil {
 		time.Sleep(fetchFailedSleepTime)
@@ -111,8 +131,10 @@ func (c *ShardConsumerWorker) fetchLogs(cursor string) (shouldCallProcess bool,
 
 	c.consumerCheckPointTracker.setCurrentCursor(cursor)
 	c.consumerCheckPointTracker.setNextCursor(plm.NextCursor)
+	c.monitor.RecordFetchedLogs(logGroupList, cursor == plm.NextCursor)
 
 	if cursor == plm.NextCursor { // already reach end of shard
+		c.checkShardEnd()
 		c.saveCheckPointIfNeeded()
 		time.Sleep(noProgressSleepTime)
 		return false, nil, nil
@@ -121,14 +143,17 @@ func (c *ShardConsumerWorker) fetchLogs(cursor string) (shouldCallProcess bool,
 }
 
 func (c *ShardConsumerWorker) callProcess(logGroupList *sls.LogGroupList, plm *sls.PullLogMeta) (nextCursor string) {
-	for {
+	policy := c.client.option.ProcessFailurePolicy
+	for attempt := 1; ; attempt++ {
 		start := time.Now()
+		_, span := c.startSpan("consumer process", attrLogGroupCount.Int(len(logGroupList.LogGroups)))
 		rollBackCheckpoint, err := c.processInternal(logGroupList)
 		c.monitor.RecordProcess(err, start)
+		endSpan(span, err)
 
 		c.saveCheckPointIfNeeded()
 		if err != nil {
-			level.Error(c.logger).Log("msg", "process func returns an error", "err", err)
+			level.Error(c.logger).Log("msg", "process func returns an error", "err", err, "attempt", attempt)
 		}
 		if rollBackCheckpoint != "" {
 			level.Warn(c.logger).Log("msg", "Rollback checkpoint by user",
@@ -143,10 +168,66 @@ func (c *ShardConsumerWorker) callProcess(logGroupList *sls.LogGroupList, plm *s
 			level.Warn(c.logger).Log("msg", "shutting down and last process failed, just quit")
 			return plm.NextCursor
 		}
-		time.Sleep(processFailedSleepTime)
+		if policy != nil && attempt >= policy.maxAttempts() {
+			cursor := c.consumerCheckPointTracker.GetCurrentCursor()
+			handled := c.deadLetter(policy, &DeadLetter{
+				ShardId:      c.shardId,
+				Cursor:       cursor,
+				NextCursor:   plm.NextCursor,
+				LogGroupList: logGroupList,
+				Attempts:     attempt,
+				Err:          err,
+			})
+			if !handled {
+				return cursor
+			}
+			return plm.NextCursor
+		}
+		time.Sleep(policy.backoff(attempt))
 	}
 }
 
+// deadLetter hands the batch to the dead letter handler, and saves the checkpoint after it as the batch is done.
+// It returns false if the consumer shuts down before the batch is handled.
+func (c *ShardConsumerWorker) deadLetter(policy *ProcessFailurePolicy, letter *DeadLetter) (handled bool) {
+	for attempt := 1; ; attempt++ {
+		err := c.handleDeadLetter(policy.DeadLetterHandler, letter)
+		if err == nil {
+			break
+		}
+		level.Error(c.logger).Log("msg", "failed to handle dead letter", "shard", c.shardId, "cursor", letter.Cursor, "err", err, "attempt", attempt)
+		if c.shutDownFlag.Load() {
+			return false
+		}
+		time.Sleep(policy.backoff(attempt))
+	}
+	level.Warn(c.logger).Log("msg", "batch is dead-lettered after process failed",
+		"shard", c.shardId,
+		"cursor", letter.Cursor,
+		"attempts", letter.Attempts,
+		"err", letter.Err,
+	)
+	c.monitor.RecordDeadLetter()
+	if err := c.consumerCheckPointTracker.SaveCheckPoint(false); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to save checkpoint after dead letter", "shard", c.shardId, "err", err)
+	}
+	return true
+}
+
+func (c *ShardConsumerWorker) handleDeadLetter(handler DeadLetterHandler, letter *DeadLetter) (err error) {
+	defer func() {
+		// recover must be called by the deferred function itself
+		if r := recover(); r != nil {
+			c.logPanic("panic in your dead letter handler", r)
+			err = fmt.Errorf("panic when handle dead letter: %v", r)
+		}
+	}()
+	if handler == nil {
+		return errors.New("DeadLetterHandler of ProcessFailurePolicy is not set")
+	}
+	return handler.HandleDeadLetter(letter)
+}
+
 func (c *ShardConsumerWorker) processInternal(logGroup *sls.LogGroupList) (rollBackCheckpoint string, err error) {
 	defer func() {
 		if r := c.recoverIfPanic("panic in your process function"); r != nil {
@@ -179,10 +260,52 @@ func (c *ShardConsumerWorker) doShutDown() {
 		level.Error(c.logger).Log("msg", "failed to flush checkpoint when shutting down", "err", err)
 		time.Sleep(flushCheckPointFailedSleepTime)
 	}
+	c.onShardRevoked()
 	level.Info(c.logger).Log("msg", "shutting down completed, bye")
 	c.stopped.Store(true)
 }
 
+func (c *ShardConsumerWorker) onShardAssigned(initialCursor string) {
+	c.assigned = true
+	listener, ok := c.processor.(ShardAssignedListener)
+	if !ok {
+		return
+	}
+	defer c.recoverIfPanic("panic in your OnShardAssigned function")
+	listener.OnShardAssigned(c.shardId, initialCursor)
+}
+
+func (c *ShardConsumerWorker) onShardRevoked() {
+	listener, ok := c.processor.(ShardRevokedListener)
+	if !ok || !c.assigned {
+		return
+	}
+	defer c.recoverIfPanic("panic in your OnShardRevoked function")
+	listener.OnShardRevoked(c.shardId)
+}
+
+// checkShardEnd calls OnShardEnd once the shard is read-only and consumed to the end,
+// the status of shard is checked at most once every shardStatusCheckInterval
+func (c *ShardConsumerWorker) checkShardEnd() {
+	listener, ok := c.processor.(ShardEndListener)
+	if !ok || c.shardEnded || time.Since(c.lastShardStatusCheck) < shardStatusCheckInterval {
+		return
+	}
+	c.lastShardStatusCheck = time.Now()
+	readOnly, err := c.client.isShardReadOnly(c.shardId)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to get shard status", "err", err)
+		return
+	}
+	if !readOnly {
+		return
+	}
+	level.Info(c.logger).Log("msg", "read-only shard is consumed to the end")
+	c.shardEnded = true
+	defer c.recoverIfPanic("panic in your OnShardEnd function")
+	listener.OnShardEnd(c.shardId)
+}
+
 // todo: refine sleep time, make it more reasonable
 func (c *ShardConsumerWorker) sleepUtilNextFetch(lastFetchSuccessTime time.Time, plm *sls.PullLogMeta) {
 	sinceLastFetch := time.Since(lastFetchSuccessTime)
@@ -234,16 +357,21 @@ func (c *ShardConsumerWorker) isStopped() bool {
 
 func (c *ShardConsumerWorker) recoverIfPanic(reason string) any {
 	if r := recover(); r != nil {
-		stackBuf := make([]byte, 1<<16)
-		n := runtime.Stack(stackBuf, false)
-		level.Error(c.logger).Log("msg", "get panic in shard consumer worker",
-			"reason", reason,
-			"error", r, "stack", stackBuf[:n])
+		c.logPanic(reason, r)
 		return r
 	}
 	return nil
 }
 
+// logPanic logs the value recovered from a panic with the stack
+func (c *ShardConsumerWorker) logPanic(reason string, r any) {
+	stackBuf := make([]byte, 1<<16)
+	n := runtime.Stack(stackBuf, false)
+	level.Error(c.logger).Log("msg", "get panic in shard consumer worker",
+		"reason", reason,
+		"error", r, "stack", stackBuf[:n])
+}
+
 func (c *ShardConsumerWorker) shouldReportMetrics() bool {
 	return !c.client.option.DisableRuntimeMetrics && c.monitor.shouldReport()
 }
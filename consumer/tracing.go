@@ -0,0 +1,60 @@
+package consumerLibrary
+
+import (
+	"context"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	attrConsumerGroup = attribute.Key("sls.consumer_group")
+	attrConsumer      = attribute.Key("sls.consumer")
+	attrShard         = attribute.Key("sls.shard")
+	attrCursor        = attribute.Key("sls.cursor")
+	attrLogGroupCount = attribute.Key("sls.log_group_count")
+	attrRawSize       = attribute.Key("sls.raw_size")
+)
+
+// startSpan starts a span of the shard consumer, returns a nil span if tracing is disabled
+func (c *ShardConsumerWorker) startSpan(name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tp := c.client.option.TracerProvider
+	if tp == nil {
+		return context.Background(), nil
+	}
+	attrs = append(attrs,
+		sls.AttrProject.String(c.client.option.Project),
+		sls.AttrLogstore.String(c.client.option.Logstore),
+		attrConsumerGroup.String(c.client.option.ConsumerGroupName),
+		attrConsumer.String(c.client.option.ConsumerName),
+		attrShard.Int(c.shardId),
+	)
+	return tp.Tracer(sls.TracerName).Start(context.Background(), name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// withContext returns the sls client whose calls are bound to ctx if tracing is enabled
+func (consumer *ConsumerClient) withContext(ctx context.Context) sls.ClientInterface {
+	if consumer.option.TracerProvider == nil {
+		return consumer.client
+	}
+	if client, ok := consumer.client.(sls.ClientWithContext); ok {
+		return client.WithContext(ctx)
+	}
+	return consumer.client
+}
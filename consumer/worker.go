@@ -22,6 +22,7 @@ type ConsumerWorker struct {
 	waitGroup          sync.WaitGroup
 	Logger             log.Logger
 	ioThrottler        ioThrottler
+	metrics            *consumerMetrics
 }
 
 // depreciated: this old logic is to automatically save to memory, and then commit at a fixed time
@@ -73,6 +74,10 @@ func InitConsumerWorkerWithProcessor(option LogHubConfig, processor Processor) *
 		processor:   processor,
 		Logger:      logger,
 		ioThrottler: newSimpleIoThrottler(maxIoWorker),
+		metrics:     newConsumerMetrics(option),
+	}
+	if consumerClient.standalone() {
+		return consumerWorker
 	}
 	if err := consumerClient.createConsumerGroup(); err != nil {
 		level.Error(consumerWorker.Logger).Log(
@@ -134,6 +139,7 @@ func (consumerWorker *ConsumerWorker) shutDownAndWait() {
 					consumer.shutdown()
 				} else {
 					consumerWorker.shardConsumer.Delete(key)
+					consumerWorker.metrics.removeShard(key.(int))
 				}
 				return true
 			},
@@ -155,7 +161,8 @@ func (consumerWorker *ConsumerWorker) getShardConsumer(shardId int) *ShardConsum
 		consumerWorker.consumerHeatBeat,
 		consumerWorker.processor,
 		consumerWorker.Logger,
-		consumerWorker.ioThrottler)
+		consumerWorker.ioThrottler,
+		consumerWorker.metrics)
 	consumerWorker.shardConsumer.Store(shardId, consumerIns)
 	return consumerIns
 
@@ -179,6 +186,7 @@ func (consumerWorker *ConsumerWorker) cleanShardConsumer(owned_shards []int) {
 				if isDeleteShard {
 					level.Info(consumerWorker.Logger).Log("msg", "Remove an assigned consumer shard", "shardId", shard)
 					consumerWorker.shardConsumer.Delete(shard)
+					consumerWorker.metrics.removeShard(shard)
 				} else {
 					level.Info(consumerWorker.Logger).Log("msg", "Remove an assigned consumer shard failed", "shardId", shard)
 				}
@@ -0,0 +1,171 @@
+package sls
+
+import (
+	"fmt"
+)
+
+const (
+	// PackIDKey and PackMetaKey are the keys of the pack of a log returned by GetLogs, to query its context by
+	PackIDKey   = "__tag__:__pack_id__"
+	PackMetaKey = "__pack_meta__"
+
+	maxContextLines = 100
+)
+
+// ContextBrowser browses the logs around a log returned by GetLogsV3, in the order they are written to the shard, eg.
+//
+//	browser, err := sls.NewContextBrowser(client, project, logstore, resp.Logs[0])
+//	if err != nil {
+//		return err
+//	}
+//	if err := browser.Around(10, 10); err != nil {
+//		return err
+//	}
+//	browser.Back(20) // 20 more logs before the first log of view
+//	for _, log := range browser.Logs() {
+//		fmt.Println(log)
+//	}
+//
+// The logs are fetched by GetContextLogs from the pack of the log, and the packs of the first and the last log of view
+// when paging back and forward. The log is required to have __tag__:__pack_id__ and __pack_meta__, or __pack_id__,
+// which are returned by GetLogsV3.
+type ContextBrowser struct {
+	client   ClientInterface
+	project  string
+	logstore string
+	origin   map[string]string
+
+	logs      []map[string]string
+	originPos int
+	atFirst   bool // the first log of view is the first of shard
+	atLast    bool // the last log of view is the last of shard, when it is fetched
+}
+
+// NewContextBrowser creates a browser of the context of log, the view has log only until it is paged
+func NewContextBrowser(client ClientInterface, project, logstore string, log map[string]string) (*ContextBrowser, error) {
+	if _, _, err := packOf(log); err != nil {
+		return nil, err
+	}
+	return &ContextBrowser{
+		client:   client,
+		project:  project,
+		logstore: logstore,
+		origin:   log,
+		logs:     []map[string]string{log},
+	}, nil
+}
+
+// packOf returns the pack id and pack meta of log
+func packOf(log map[string]string) (packID, packMeta string, err error) {
+	packID = log[PackIDKey]
+	if packID == "" {
+		packID = log["__pack_id__"]
+	}
+	packMeta = log[PackMetaKey]
+	if packID == "" || packMeta == "" {
+		return "", "", NewClientError(fmt.Errorf("log has no %s and %s of context", PackIDKey, PackMetaKey))
+	}
+	return packID, packMeta, nil
+}
+
+// Around resets the view to backLines logs before the log, the log, and forwardLines logs after it.
+// Lines are at most 100.
+func (b *ContextBrowser) Around(backLines, forwardLines int32) error {
+	backLines, forwardLines = contextLines(backLines), contextLines(forwardLines)
+	back, forward, err := b.fetch(b.origin, backLines, forwardLines)
+	if err != nil {
+		return err
+	}
+	logs := make([]map[string]string, 0, len(back)+1+len(forward))
+	logs = append(append(append(logs, back...), b.origin), forward...)
+	b.logs = logs
+	b.originPos = len(back)
+	b.atFirst = int32(len(back)) < backLines
+	b.atLast = int32(len(forward)) < forwardLines
+	return nil
+}
+
+// Back fetches at most lines (up to 100) more logs before the first log of view, and returns them.
+// No logs are returned once the first log of shard is reached, which is reported by HasMoreBack.
+func (b *ContextBrowser) Back(lines int32) ([]map[string]string, error) {
+	lines = contextLines(lines)
+	if b.atFirst || lines == 0 {
+		return nil, nil
+	}
+	back, _, err := b.fetch(b.logs[0], lines, 0)
+	if err != nil {
+		return nil, err
+	}
+	b.logs = append(append([]map[string]string{}, back...), b.logs...)
+	b.originPos += len(back)
+	b.atFirst = int32(len(back)) < lines
+	return back, nil
+}
+
+// Forward fetches at most lines (up to 100) more logs after the last log of view, and returns them.
+// It returns no logs if there are no more logs written after the last log yet, which is reported by HasMoreForward,
+// and it can be called again later for the logs written since.
+func (b *ContextBrowser) Forward(lines int32) ([]map[string]string, error) {
+	lines = contextLines(lines)
+	if lines == 0 {
+		return nil, nil
+	}
+	_, forward, err := b.fetch(b.logs[len(b.logs)-1], 0, lines)
+	if err != nil {
+		return nil, err
+	}
+	b.logs = append(b.logs, forward...)
+	b.atLast = int32(len(forward)) < lines
+	return forward, nil
+}
+
+// Logs returns the logs of view in order
+func (b *ContextBrowser) Logs() []map[string]string {
+	return b.logs
+}
+
+// Origin returns the index of the log in Logs
+func (b *ContextBrowser) Origin() int {
+	return b.originPos
+}
+
+// HasMoreBack returns false once Back reaches the first log of shard
+func (b *ContextBrowser) HasMoreBack() bool {
+	return !b.atFirst
+}
+
+// HasMoreForward returns false if the last Forward reaches the last log of shard
+func (b *ContextBrowser) HasMoreForward() bool {
+	return !b.atLast
+}
+
+// fetch returns the logs before and after anchor, the anchor itself is not included
+func (b *ContextBrowser) fetch(anchor map[string]string, backLines, forwardLines int32) (back, forward []map[string]string, err error) {
+	packID, packMeta, err := packOf(anchor)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := b.client.GetContextLogs(b.project, b.logstore, backLines, forwardLines, packID, packMeta)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !resp.IsComplete() {
+		return nil, nil, NewClientError(fmt.Errorf("context logs of pack %s are incomplete", packID))
+	}
+	backCount, forwardCount := int(resp.BackLines), int(resp.ForwardLines)
+	if backCount < 0 || forwardCount < 0 || backCount+forwardCount > len(resp.Logs) {
+		return nil, nil, NewClientError(fmt.Errorf("invalid context logs, back_lines %d and forward_lines %d of %d logs",
+			resp.BackLines, resp.ForwardLines, len(resp.Logs)))
+	}
+	return resp.Logs[:backCount], resp.Logs[len(resp.Logs)-forwardCount:], nil
+}
+
+func contextLines(lines int32) int32 {
+	if lines < 0 {
+		return 0
+	}
+	if lines > maxContextLines {
+		return maxContextLines
+	}
+	return lines
+}
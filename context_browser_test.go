@@ -0,0 +1,94 @@
+package sls
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContextLogs serves GetContextLogs of n logs in a shard, the pack id of log i is "pack-i"
+func fakeContextLogs(n int) http.HandlerFunc {
+	newLog := func(i int) map[string]string {
+		return map[string]string{"seq": strconv.Itoa(i), PackIDKey: "pack-" + strconv.Itoa(i), PackMetaKey: "meta"}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("type") != "context_log" || query.Get("pack_meta") != "meta" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errorCode":"ParameterInvalid","errorMessage":"invalid pack"}`))
+			return
+		}
+		pos, _ := strconv.Atoi(strings.TrimPrefix(query.Get("pack_id"), "pack-"))
+		back, _ := strconv.Atoi(query.Get("back_lines"))
+		forward, _ := strconv.Atoi(query.Get("forward_lines"))
+		from, to := pos-back, pos+forward+1
+		if from < 0 {
+			from = 0
+		}
+		if to > n {
+			to = n
+		}
+		resp := GetContextLogsResponse{Progress: "Complete", BackLines: int64(pos - from), ForwardLines: int64(to - pos - 1)}
+		for i := from; i < to; i++ {
+			resp.Logs = append(resp.Logs, newLog(i))
+		}
+		resp.TotalLines = int64(len(resp.Logs))
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func TestContextBrowser(t *testing.T) {
+	client := newHandlerTestClient(t, fakeContextLogs(50))
+	seqs := func(logs []map[string]string) []int {
+		var seqs []int
+		for _, log := range logs {
+			seq, _ := strconv.Atoi(log["seq"])
+			seqs = append(seqs, seq)
+		}
+		return seqs
+	}
+	origin := map[string]string{"seq": "10", "__pack_id__": "pack-10", PackMetaKey: "meta"}
+
+	browser, err := NewContextBrowser(client, "project", "logstore", origin)
+	require.NoError(t, err)
+	require.NoError(t, browser.Around(3, 2))
+	assert.Equal(t, []int{7, 8, 9, 10, 11, 12}, seqs(browser.Logs()))
+	assert.Equal(t, 3, browser.Origin())
+	assert.True(t, browser.HasMoreBack())
+
+	back, err := browser.Back(5)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4, 5, 6}, seqs(back))
+	back, err = browser.Back(5)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, seqs(back))
+	assert.False(t, browser.HasMoreBack())
+	back, err = browser.Back(5)
+	require.NoError(t, err)
+	assert.Empty(t, back)
+	assert.Equal(t, 10, browser.Origin())
+	assert.Equal(t, origin, browser.Logs()[browser.Origin()])
+
+	forward, err := browser.Forward(30)
+	require.NoError(t, err)
+	assert.Len(t, forward, 30)
+	assert.True(t, browser.HasMoreForward())
+	forward, err = browser.Forward(30)
+	require.NoError(t, err)
+	assert.Equal(t, []int{43, 44, 45, 46, 47, 48, 49}, seqs(forward))
+	assert.False(t, browser.HasMoreForward())
+	for i, seq := range seqs(browser.Logs()) {
+		assert.Equal(t, i, seq)
+	}
+
+	_, err = NewContextBrowser(client, "project", "logstore", map[string]string{"seq": "1"})
+	assert.Error(t, err)
+	browser, err = NewContextBrowser(client, "project", "logstore", map[string]string{PackIDKey: "pack-1", PackMetaKey: "invalid"})
+	require.NoError(t, err)
+	assert.Error(t, browser.Around(1, 1))
+}
@@ -31,6 +31,7 @@ func httpStatusNotOkError(body []byte, header http.Header, httpCode int) error {
 	}
 	slsErr.HTTPCode = int32(httpCode)
 	slsErr.RequestID = header.Get(RequestIDHeader)
+	slsErr.retryAfter = parseRetryAfter(header.Get(RetryAfterHeader))
 	return slsErr
 }
 
@@ -0,0 +1,425 @@
+// Package export dumps the logs of a search query in a time range to a local file.
+//
+// The time range is split into slices by sls.SplitTimeRange, slices are exported concurrently to
+// temporary files in a state directory, and joined in time order into the output once all of them
+// are done. The progress is saved in the state directory, so an interrupted export is resumed by
+// running it again with the same Config, and only the slices not done yet are exported.
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+const (
+	defaultConcurrency     = 4
+	defaultMaxRowsPerSlice = 10000
+)
+
+// Format is the file format of output
+type Format string
+
+const (
+	// JSONL writes a json object of each log per line
+	JSONL Format = "jsonl"
+	// CSV writes a header of columns, and a row of each log
+	CSV Format = "csv"
+	// Parquet writes a parquet file of optional UTF8 columns, the missing fields of logs are null
+	Parquet Format = "parquet"
+)
+
+// Config is what to export and where to
+type Config struct {
+	Project  string
+	Logstore string
+	Topic    string
+	// Query is a search query, queries with SQL are not supported
+	Query string
+	// From and To are the time range [From, To) in unix seconds
+	From int64
+	To   int64
+	// Output is the path of the file written
+	Output string
+	// Format of output, by the extension of Output if it is empty, ".csv" or ".parquet", JSONL otherwise
+	Format Format
+	// Columns of CSV and Parquet, the sorted keys of all logs if it is empty, ignored by JSONL
+	Columns []string
+	// StateDir keeps the progress and the exported slices, default Output + ".state". It is removed once the export is done.
+	StateDir string
+	// Concurrency is the number of slices exported at the same time, default 4
+	Concurrency int
+	// MaxRowsPerSlice is the max number of logs in a slice, default 10000
+	MaxRowsPerSlice int64
+}
+
+// Exporter exports the logs as its Config
+type Exporter struct {
+	client sls.ClientInterface
+	config Config
+
+	mu    sync.Mutex
+	state *state
+}
+
+// NewExporter creates an Exporter reading logs by client
+func NewExporter(client sls.ClientInterface, config Config) *Exporter {
+	return &Exporter{client: client, config: config}
+}
+
+// Run exports the logs, it returns nil once Output is written. On error or cancel of ctx, the progress is kept in StateDir,
+// and the next Run of the same Config resumes from it. Run returns an error if StateDir has the progress of a different export.
+func (e *Exporter) Run(ctx context.Context) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(e.stateDir(), 0755); err != nil {
+		return err
+	}
+	if err := e.loadState(ctx); err != nil {
+		return err
+	}
+	if err := e.exportSlices(ctx); err != nil {
+		return err
+	}
+	if err := e.writeOutput(); err != nil {
+		return err
+	}
+	return os.RemoveAll(e.stateDir())
+}
+
+func (e *Exporter) validate() error {
+	c := e.config
+	if c.Project == "" || c.Logstore == "" {
+		return errors.New("export: project and logstore are required")
+	}
+	if c.Output == "" {
+		return errors.New("export: output is required")
+	}
+	if c.From >= c.To {
+		return fmt.Errorf("export: invalid time range [%d, %d)", c.From, c.To)
+	}
+	switch e.format() {
+	case JSONL, CSV, Parquet:
+		return nil
+	default:
+		return fmt.Errorf("export: unknown format %q", c.Format)
+	}
+}
+
+func (e *Exporter) format() Format {
+	if e.config.Format != "" {
+		return e.config.Format
+	}
+	switch strings.ToLower(filepath.Ext(e.config.Output)) {
+	case ".csv":
+		return CSV
+	case ".parquet":
+		return Parquet
+	default:
+		return JSONL
+	}
+}
+
+func (e *Exporter) stateDir() string {
+	if e.config.StateDir != "" {
+		return e.config.StateDir
+	}
+	return e.config.Output + ".state"
+}
+
+func (e *Exporter) concurrency() int {
+	if e.config.Concurrency <= 0 {
+		return defaultConcurrency
+	}
+	return e.config.Concurrency
+}
+
+func (e *Exporter) maxRowsPerSlice() int64 {
+	if e.config.MaxRowsPerSlice <= 0 {
+		return defaultMaxRowsPerSlice
+	}
+	return e.config.MaxRowsPerSlice
+}
+
+// clientWithContext returns the client whose calls are canceled with ctx, if the client supports context
+func (e *Exporter) clientWithContext(ctx context.Context) sls.ClientInterface {
+	if client, ok := e.client.(sls.ClientWithContext); ok {
+		return client.WithContext(ctx)
+	}
+	return e.client
+}
+
+func (e *Exporter) request(from, to int64) *sls.GetLogRequest {
+	return &sls.GetLogRequest{Topic: e.config.Topic, Query: e.config.Query, From: from, To: to}
+}
+
+func (e *Exporter) slicePath(i int) string {
+	return filepath.Join(e.stateDir(), fmt.Sprintf("slice-%06d.jsonl", i))
+}
+
+// loadState reads the progress in StateDir, or splits the time range into slices for a new export
+func (e *Exporter) loadState(ctx context.Context) error {
+	path := filepath.Join(e.stateDir(), stateFile)
+	s, err := readState(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	id := e.identity()
+	if s != nil {
+		if s.Identity != id {
+			return fmt.Errorf("export: %s has the progress of another export, remove it to start over", e.stateDir())
+		}
+		e.state = s
+		return nil
+	}
+	slices, err := sls.SplitTimeRange(e.clientWithContext(ctx), e.config.Project, e.config.Logstore, e.request(e.config.From, e.config.To), e.maxRowsPerSlice())
+	if err != nil {
+		return err
+	}
+	s = &state{Identity: id}
+	for _, slice := range slices {
+		s.Slices = append(s.Slices, sliceState{TimeSlice: slice})
+	}
+	e.state = s
+	return writeState(path, s)
+}
+
+func (e *Exporter) identity() identity {
+	return identity{
+		Project:  e.config.Project,
+		Logstore: e.config.Logstore,
+		Topic:    e.config.Topic,
+		Query:    e.config.Query,
+		From:     e.config.From,
+		To:       e.config.To,
+	}
+}
+
+// exportSlices exports the slices not done yet by a pool of workers, it stops at the first error
+func (e *Exporter) exportSlices(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pending := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for w := 0; w < e.concurrency(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pending {
+				if err := e.exportSlice(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	var todo []int
+	for i, slice := range e.state.Slices {
+		if !slice.Done {
+			todo = append(todo, i)
+		}
+	}
+feed:
+	for _, i := range todo {
+		select {
+		case pending <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(pending)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// exportSlice writes the logs of slice i to its file, and saves it as done
+func (e *Exporter) exportSlice(ctx context.Context, i int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	slice := e.state.Slices[i].TimeSlice
+	path := e.slicePath(i)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	it := sls.NewLogIteratorOfSlices(e.clientWithContext(ctx), e.config.Project, e.config.Logstore, e.request(slice.From, slice.To), []sls.TimeSlice{slice})
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := encoder.Encode(it.Log()); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state.Slices[i].Done = true
+	return writeState(filepath.Join(e.stateDir(), stateFile), e.state)
+}
+
+// writeOutput joins the slices in time order into Output
+func (e *Exporter) writeOutput() error {
+	tmp := e.config.Output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	switch e.format() {
+	case JSONL:
+		err = e.writeJSONL(w)
+	case CSV:
+		err = e.writeCSV(w)
+	case Parquet:
+		err = e.writeParquet(w)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, e.config.Output)
+}
+
+func (e *Exporter) writeJSONL(w io.Writer) error {
+	for i := range e.state.Slices {
+		f, err := os.Open(e.slicePath(i))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) writeCSV(w io.Writer) error {
+	columns, err := e.columns()
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	err = e.eachLog(func(log map[string]string) error {
+		for i, column := range columns {
+			record[i] = log[column]
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (e *Exporter) writeParquet(w io.Writer) error {
+	columns, err := e.columns()
+	if err != nil {
+		return err
+	}
+	pw := newParquetWriter(w, columns)
+	if err := e.eachLog(pw.write); err != nil {
+		return err
+	}
+	return pw.close()
+}
+
+// columns returns Config.Columns, or the sorted keys of all logs
+func (e *Exporter) columns() ([]string, error) {
+	if len(e.config.Columns) > 0 {
+		return e.config.Columns, nil
+	}
+	keys := map[string]struct{}{}
+	err := e.eachLog(func(log map[string]string) error {
+		for key := range log {
+			keys[key] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(keys))
+	for key := range keys {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+	return columns, nil
+}
+
+// eachLog calls fn with the logs of all slices in order
+func (e *Exporter) eachLog(fn func(log map[string]string) error) error {
+	for i := range e.state.Slices {
+		if err := e.eachSliceLog(i, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) eachSliceLog(i int, fn func(log map[string]string) error) error {
+	f, err := os.Open(e.slicePath(i))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var log map[string]string
+		if err := decoder.Decode(&log); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("export: invalid slice file %s: %w", f.Name(), err)
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+}
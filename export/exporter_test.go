@@ -0,0 +1,326 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	parquetreader "github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// fakeSearchClient serves GetHistograms and GetLogsV3 of logs at the given seconds, the query is ignored
+type fakeSearchClient struct {
+	sls.ClientInterface
+	mu       sync.Mutex
+	times    []int64 // time of each log, sorted
+	failFrom int64   // GetLogs of the range from failFrom fails, if it is not zero
+	getLogs  int
+
+	incomplete bool // histograms are incomplete
+	histograms int
+	contexts   []context.Context // contexts the calls are bound to by WithContext
+}
+
+// WithContext records ctx, the calls are still made by c
+func (c *fakeSearchClient) WithContext(ctx context.Context) sls.ClientInterface {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contexts = append(c.contexts, ctx)
+	return c
+}
+
+func (c *fakeSearchClient) between(from, to int64) []int64 {
+	var times []int64
+	for _, t := range c.times {
+		if t >= from && t < to {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+func (c *fakeSearchClient) GetHistogramsToCompletedV2(project, logstore string, req *sls.GetHistogramRequest) (*sls.GetHistogramsResponse, error) {
+	c.mu.Lock()
+	c.histograms++
+	c.mu.Unlock()
+	resp := &sls.GetHistogramsResponse{Progress: "Complete"}
+	if c.incomplete {
+		resp.Progress = "Incomplete"
+	}
+	for start := req.From; start < req.To; start += int64(req.Interval) {
+		end := start + int64(req.Interval)
+		if end > req.To {
+			end = req.To
+		}
+		resp.Histograms = append(resp.Histograms, sls.SingleHistogram{From: start, To: end, Count: int64(len(c.between(start, end))), Progress: "Complete"})
+	}
+	return resp, nil
+}
+
+func (c *fakeSearchClient) GetLogsToCompletedV3(project, logstore string, req *sls.GetLogRequest) (*sls.GetLogsV3Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failFrom != 0 && req.From <= c.failFrom && c.failFrom < req.To {
+		return nil, errors.New("network error")
+	}
+	c.getLogs++
+	times := c.between(req.From, req.To)
+	resp := &sls.GetLogsV3Response{Meta: sls.GetLogsV3ResponseMeta{Progress: "Complete"}, Logs: []map[string]string{}}
+	for i := req.Offset; i < int64(len(times)) && i < req.Offset+req.Lines; i++ {
+		log := map[string]string{"__time__": strconv.FormatInt(times[i], 10), "msg": "hello, \"world\""}
+		if times[i]%2 == 0 {
+			log["level"] = "INFO"
+		}
+		resp.Logs = append(resp.Logs, log)
+	}
+	resp.Meta.Count = int64(len(resp.Logs))
+	return resp, nil
+}
+
+func newFakeSearchClient() *fakeSearchClient {
+	client := &fakeSearchClient{}
+	for i := int64(0); i < 200; i++ {
+		client.times = append(client.times, 1000+i/2)
+	}
+	return client
+}
+
+func TestExportJSONL(t *testing.T) {
+	client := newFakeSearchClient()
+	dir := t.TempDir()
+	config := Config{
+		Project:         "project",
+		Logstore:        "logstore",
+		Query:           "*",
+		From:            900,
+		To:              1200,
+		Output:          filepath.Join(dir, "logs.jsonl"),
+		Concurrency:     3,
+		MaxRowsPerSlice: 20,
+	}
+
+	// the first run fails at a slice in the middle
+	client.failFrom = 1050
+	err := NewExporter(client, config).Run(context.Background())
+	require.Error(t, err)
+	_, err = os.Stat(config.Output)
+	assert.True(t, os.IsNotExist(err))
+	s, err := readState(filepath.Join(config.Output+".state", stateFile))
+	require.NoError(t, err)
+	assert.Greater(t, len(s.Slices), 5)
+
+	// the next run resumes, and exports the slices not done only
+	done := 0
+	for _, slice := range s.Slices {
+		if slice.Done {
+			done++
+		}
+	}
+	client.failFrom = 0
+	client.getLogs, client.histograms = 0, 0
+	require.NoError(t, NewExporter(client, config).Run(context.Background()))
+	assert.LessOrEqual(t, client.getLogs, len(s.Slices)-done)
+	assert.Zero(t, client.histograms, "slices are not split again")
+
+	buf, err := os.ReadFile(config.Output)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	require.Len(t, lines, len(client.times))
+	for i, line := range lines {
+		var log map[string]string
+		require.NoError(t, json.Unmarshal([]byte(line), &log))
+		assert.Equal(t, strconv.FormatInt(client.times[i], 10), log["__time__"])
+	}
+	_, err = os.Stat(config.Output + ".state")
+	assert.True(t, os.IsNotExist(err), "state is removed once done")
+}
+
+func TestExportIncompleteHistograms(t *testing.T) {
+	client := newFakeSearchClient()
+	client.incomplete = true
+	config := Config{Project: "project", Logstore: "logstore", Query: "*", From: 900, To: 1200, Output: filepath.Join(t.TempDir(), "logs.jsonl")}
+	require.Error(t, NewExporter(client, config).Run(context.Background()))
+	_, err := os.Stat(config.Output)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExportContext(t *testing.T) {
+	type ctxKey struct{}
+	client := newFakeSearchClient()
+	config := Config{Project: "project", Logstore: "logstore", Query: "*", From: 900, To: 1200, Output: filepath.Join(t.TempDir(), "logs.jsonl"), MaxRowsPerSlice: 50}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "export")
+	require.NoError(t, NewExporter(client, config).Run(ctx))
+	// the split and each slice are bound to ctx
+	require.Greater(t, len(client.contexts), 2)
+	for _, c := range client.contexts {
+		assert.Equal(t, "export", c.Value(ctxKey{}))
+	}
+}
+
+func TestExportStateMismatch(t *testing.T) {
+	client := newFakeSearchClient()
+	client.failFrom = 1000
+	config := Config{Project: "project", Logstore: "logstore", Query: "*", From: 900, To: 1200, Output: filepath.Join(t.TempDir(), "logs.jsonl")}
+	require.Error(t, NewExporter(client, config).Run(context.Background()))
+
+	config.Query = "level: ERROR"
+	err := NewExporter(client, config).Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "another export")
+}
+
+func TestExportCSV(t *testing.T) {
+	client := newFakeSearchClient()
+	config := Config{Project: "project", Logstore: "logstore", From: 1000, To: 1010, Output: filepath.Join(t.TempDir(), "logs.csv")}
+	require.NoError(t, NewExporter(client, config).Run(context.Background()))
+
+	f, err := os.Open(config.Output)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 21)
+	assert.Equal(t, []string{"__time__", "level", "msg"}, records[0])
+	assert.Equal(t, []string{"1000", "INFO", `hello, "world"`}, records[1])
+	assert.Equal(t, []string{"1001", "", `hello, "world"`}, records[3])
+}
+
+// parquetBuffer is a source.ParquetFile of a parquet file in memory, for the reader of parquet-go
+type parquetBuffer struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (b *parquetBuffer) Open(string) (source.ParquetFile, error) {
+	return &parquetBuffer{Reader: bytes.NewReader(b.data), data: b.data}, nil
+}
+
+func (b *parquetBuffer) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("read only")
+}
+
+func (b *parquetBuffer) Write([]byte) (int, error) {
+	return 0, errors.New("read only")
+}
+
+func (b *parquetBuffer) Close() error {
+	return nil
+}
+
+// readParquet reads the parquet file by parquet-go, and returns the names of columns and the rows, nil for nulls
+func readParquet(t *testing.T, data []byte) ([]string, [][]*string) {
+	file := &parquetBuffer{Reader: bytes.NewReader(data), data: data}
+	reader, err := parquetreader.NewParquetColumnReader(file, 1)
+	require.NoError(t, err)
+	defer reader.ReadStop()
+	numRows := reader.GetNumRows()
+	var columns []string
+	for i := 1; i < len(reader.Footer.Schema); i++ {
+		columns = append(columns, reader.SchemaHandler.GetExName(i))
+	}
+	rows := make([][]*string, numRows)
+	for i := range columns {
+		values, _, definitionLevels, err := reader.ReadColumnByIndex(int64(i), numRows)
+		require.NoError(t, err)
+		require.Len(t, values, int(numRows))
+		for row, value := range values {
+			var cell *string
+			if definitionLevels[row] > 0 {
+				v := value.(string)
+				cell = &v
+			}
+			rows[row] = append(rows[row], cell)
+		}
+	}
+	return columns, rows
+}
+
+func TestExportParquet(t *testing.T) {
+	client := newFakeSearchClient()
+	config := Config{
+		Project:  "project",
+		Logstore: "logstore",
+		From:     1000,
+		To:       1100,
+		Output:   filepath.Join(t.TempDir(), "logs.parquet"),
+		Columns:  []string{"__time__", "level"},
+	}
+	require.NoError(t, NewExporter(client, config).Run(context.Background()))
+
+	data, err := os.ReadFile(config.Output)
+	require.NoError(t, err)
+	columns, rows := readParquet(t, data)
+	assert.Equal(t, []string{"__time__", "level"}, columns)
+	require.Len(t, rows, 200)
+	info := "INFO"
+	for i, row := range rows {
+		seconds := strconv.Itoa(1000 + i/2)
+		var level *string
+		if (i/2)%2 == 0 {
+			level = &info
+		}
+		assert.Equal(t, []*string{&seconds, level}, row, "row %d", i)
+	}
+}
+
+func TestParquetWriterRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	w := newParquetWriter(&buf, []string{"index", "odd", "__tag__:__path__"})
+	n := parquetRowGroupSize*2 + 3
+	for i := 0; i < n; i++ {
+		log := map[string]string{"index": strconv.Itoa(i), "__tag__:__path__": ""}
+		if i%2 == 1 {
+			log["odd"] = "是"
+		}
+		require.NoError(t, w.write(log))
+	}
+	require.NoError(t, w.close())
+
+	columns, rows := readParquet(t, buf.Bytes())
+	assert.Equal(t, []string{"index", "odd", "__tag__:__path__"}, columns)
+	require.Len(t, rows, n)
+	for i, row := range rows {
+		require.Len(t, row, 3)
+		require.NotNil(t, row[0])
+		assert.Equal(t, strconv.Itoa(i), *row[0])
+		if i%2 == 1 {
+			require.NotNil(t, row[1], "row %d", i)
+			assert.Equal(t, "是", *row[1])
+		} else {
+			assert.Nil(t, row[1], "row %d", i)
+		}
+		require.NotNil(t, row[2])
+		assert.Equal(t, "", *row[2])
+	}
+
+	// an empty file has the schema only
+	buf.Reset()
+	require.NoError(t, newParquetWriter(&buf, []string{"a"}).close())
+	columns, rows = readParquet(t, buf.Bytes())
+	assert.Equal(t, []string{"a"}, columns)
+	assert.Empty(t, rows)
+}
+
+func TestParquetDataPage(t *testing.T) {
+	a, b := "a", "bc"
+	page := parquetDataPage([]*string{&a, nil, &b})
+	expected := []byte{
+		2, 0, 0, 0, // length of definition levels
+		3, 0x05, // a bit packed group of 1, 0, 1
+		1, 0, 0, 0, 'a',
+		2, 0, 0, 0, 'b', 'c',
+	}
+	assert.Equal(t, expected, page)
+}
@@ -0,0 +1,309 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// parquetRowGroupSize is the number of rows buffered in memory for a row group
+const parquetRowGroupSize = 10000
+
+// parquet constants of parquet.thrift
+const (
+	parquetTypeByteArray      = 6
+	parquetRepetitionOptional = 1
+	parquetConvertedUTF8      = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetCodecUncompressed  = 0
+	parquetPageData           = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetWriter writes a parquet file of optional UTF8 columns, each column chunk is a plain encoded,
+// uncompressed data page, so that the file is read by any parquet reader without extra dependencies.
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []string
+	values    [][]*string // values of the buffered rows by column, nil for the missing fields
+	rows      int
+	totalRows int64
+	rowGroups []parquetRowGroup
+	err       error
+}
+
+type parquetColumnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+	path      string
+}
+
+type parquetRowGroup struct {
+	chunks  []parquetColumnChunk
+	size    int64
+	numRows int64
+}
+
+func newParquetWriter(w io.Writer, columns []string) *parquetWriter {
+	return &parquetWriter{
+		w:       w,
+		columns: columns,
+		values:  make([][]*string, len(columns)),
+	}
+}
+
+func (p *parquetWriter) write(log map[string]string) error {
+	if p.err == nil && p.offset == 0 {
+		p.emit(parquetMagic)
+	}
+	for i, column := range p.columns {
+		var value *string
+		if v, ok := log[column]; ok {
+			value = &v
+		}
+		p.values[i] = append(p.values[i], value)
+	}
+	p.rows++
+	if p.rows >= parquetRowGroupSize {
+		p.flushRowGroup()
+	}
+	return p.err
+}
+
+func (p *parquetWriter) close() error {
+	if p.err == nil && p.offset == 0 {
+		p.emit(parquetMagic)
+	}
+	p.flushRowGroup()
+	footer := p.footer()
+	p.emit(footer)
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	p.emit(size[:])
+	p.emit(parquetMagic)
+	return p.err
+}
+
+func (p *parquetWriter) emit(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	p.err = err
+}
+
+func (p *parquetWriter) flushRowGroup() {
+	if p.rows == 0 || p.err != nil {
+		return
+	}
+	group := parquetRowGroup{numRows: int64(p.rows)}
+	for i, column := range p.columns {
+		page := parquetDataPage(p.values[i])
+		header := parquetPageHeader(p.rows, len(page))
+		chunk := parquetColumnChunk{
+			offset:    p.offset,
+			size:      int64(len(header) + len(page)),
+			numValues: int64(p.rows),
+			path:      column,
+		}
+		p.emit(header)
+		p.emit(page)
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+		p.values[i] = p.values[i][:0]
+	}
+	p.rowGroups = append(p.rowGroups, group)
+	p.totalRows += int64(p.rows)
+	p.rows = 0
+}
+
+// parquetDataPage encodes the definition levels, bit packed with the 4 bytes length, and the plain values
+func parquetDataPage(values []*string) []byte {
+	var levels bytes.Buffer
+	groups := (len(values) + 7) / 8
+	writeUvarint(&levels, uint64(groups)<<1|1)
+	for g := 0; g < groups; g++ {
+		var b byte
+		for j := 0; j < 8 && g*8+j < len(values); j++ {
+			if values[g*8+j] != nil {
+				b |= 1 << j
+			}
+		}
+		levels.WriteByte(b)
+	}
+	var page bytes.Buffer
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(levels.Len()))
+	page.Write(size[:])
+	page.Write(levels.Bytes())
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		binary.LittleEndian.PutUint32(size[:], uint32(len(*value)))
+		page.Write(size[:])
+		page.WriteString(*value)
+	}
+	return page.Bytes()
+}
+
+func parquetPageHeader(numValues, size int) []byte {
+	t := newThriftWriter()
+	t.i32(1, parquetPageData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.structBegin(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, parquetEncodingPlain)
+	t.i32(3, parquetEncodingRLE)
+	t.i32(4, parquetEncodingRLE)
+	t.structEnd()
+	t.structEnd()
+	return t.bytes()
+}
+
+// footer encodes the FileMetaData
+func (p *parquetWriter) footer() []byte {
+	t := newThriftWriter()
+	t.i32(1, 1)
+	t.listBegin(2, thriftStruct, len(p.columns)+1)
+	t.elemBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(p.columns)))
+	t.structEnd()
+	for _, column := range p.columns {
+		t.elemBegin()
+		t.i32(1, parquetTypeByteArray)
+		t.i32(3, parquetRepetitionOptional)
+		t.binary(4, column)
+		t.i32(6, parquetConvertedUTF8)
+		t.structEnd()
+	}
+	t.i64(3, p.totalRows)
+	t.listBegin(4, thriftStruct, len(p.rowGroups))
+	for _, group := range p.rowGroups {
+		t.elemBegin()
+		t.listBegin(1, thriftStruct, len(group.chunks))
+		for _, chunk := range group.chunks {
+			t.elemBegin()
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, parquetTypeByteArray)
+			t.listBegin(2, thriftI32, 2)
+			t.elemI32(parquetEncodingPlain)
+			t.elemI32(parquetEncodingRLE)
+			t.listBegin(3, thriftBinary, 1)
+			t.elemBinary(chunk.path)
+			t.i32(4, parquetCodecUncompressed)
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+	t.binary(6, "aliyun-log-go-sdk")
+	t.structEnd()
+	return t.bytes()
+}
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs by the thrift compact protocol, the top level struct is begun by newThriftWriter
+type thriftWriter struct {
+	buf       bytes.Buffer
+	lastField []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{lastField: []int16{0}}
+}
+
+func (t *thriftWriter) bytes() []byte {
+	return t.buf.Bytes()
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.lastField[len(t.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		writeUvarint(&t.buf, zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	writeUvarint(&t.buf, zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	writeUvarint(&t.buf, zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.elemBinary(v)
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+func (t *thriftWriter) listBegin(id int16, elemType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		writeUvarint(&t.buf, uint64(size))
+	}
+}
+
+// elemBegin begins a struct element of list
+func (t *thriftWriter) elemBegin() {
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *thriftWriter) elemI32(v int32) {
+	writeUvarint(&t.buf, zigzag(int64(v)))
+}
+
+func (t *thriftWriter) elemBinary(v string) {
+	writeUvarint(&t.buf, uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
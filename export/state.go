@@ -0,0 +1,55 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+const stateFile = "state.json"
+
+// identity is what is exported, a state is resumed only by the export of the same identity
+type identity struct {
+	Project  string `json:"project"`
+	Logstore string `json:"logstore"`
+	Topic    string `json:"topic"`
+	Query    string `json:"query"`
+	From     int64  `json:"from"`
+	To       int64  `json:"to"`
+}
+
+type sliceState struct {
+	sls.TimeSlice
+	Done bool `json:"done"`
+}
+
+// state is the progress of an export, saved as state.json in StateDir
+type state struct {
+	Identity identity     `json:"identity"`
+	Slices   []sliceState `json:"slices"`
+}
+
+func readState(path string) (*state, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &state{}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// writeState replaces the state file by rename, so it is never half written
+func writeState(path string, s *state) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,20 @@
+package sls
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newHandlerTestClient returns a client whose requests of any project are served by handler
+func newHandlerTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &Client{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		AccessKeyID:     "id",
+		AccessKeySecret: "secret",
+		HTTPClient:      newTestServerHTTPClient(server),
+	}
+}
@@ -0,0 +1,39 @@
+package sls
+
+import "net/http"
+
+// RequestHandler sends a http request to SLS and returns the response.
+// If the server returns a non-200 status code, the response is nil and
+// the error is an *Error or *BadResponseError.
+type RequestHandler func(req *http.Request) (*http.Response, error)
+
+// RequestInterceptor observes and mutates every http request sent to SLS,
+// and the response or error returned, retried requests are intercepted one by one.
+// An interceptor continues the chain by calling next, or returns directly
+// without sending the request, eg. for fault injection.
+//
+// The request is already signed when it is intercepted, modifying signed headers
+// or body makes the server reject the request.
+type RequestInterceptor interface {
+	Intercept(req *http.Request, next RequestHandler) (*http.Response, error)
+}
+
+// RequestInterceptorFunc is an adapter to allow the use of ordinary functions as RequestInterceptor.
+type RequestInterceptorFunc func(req *http.Request, next RequestHandler) (*http.Response, error)
+
+// Intercept calls f(req, next).
+func (f RequestInterceptorFunc) Intercept(req *http.Request, next RequestHandler) (*http.Response, error) {
+	return f(req, next)
+}
+
+// chainInterceptors wraps handler with interceptors,
+// the first interceptor is the outermost one.
+func chainInterceptors(interceptors []RequestInterceptor, handler RequestHandler) RequestHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(req *http.Request) (*http.Response, error) {
+			return interceptor.Intercept(req, next)
+		}
+	}
+	return handler
+}
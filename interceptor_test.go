@@ -0,0 +1,72 @@
+package sls
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestInterceptorChain(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Test-Trace")
+		w.Header().Set(RequestIDHeader, "test-request-id")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errorCode":"LogStoreNotExist","errorMessage":"logstore not exist"}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	var observed error
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	client.WithRequestInterceptors(
+		RequestInterceptorFunc(func(req *http.Request, next RequestHandler) (*http.Response, error) {
+			order = append(order, "first")
+			req.Header.Set("X-Test-Trace", "trace-id")
+			resp, err := next(req)
+			observed = err
+			return resp, err
+		}),
+		RequestInterceptorFunc(func(req *http.Request, next RequestHandler) (*http.Response, error) {
+			order = append(order, "second")
+			return next(req)
+		}),
+	)
+
+	// request with retry
+	_, err := client.GetLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, "trace-id", gotHeader)
+	assert.Equal(t, []string{"first", "second"}, order)
+	slsErr, ok := observed.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, "LogStoreNotExist", slsErr.Code)
+	assert.Equal(t, "test-request-id", slsErr.RequestID)
+
+	// request without retry
+	order, gotHeader = nil, ""
+	_, err = client.ListConsumerGroup("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, "trace-id", gotHeader)
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestRequestInterceptorFaultInjection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent")
+	}))
+	defer server.Close()
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	client.WithRequestInterceptors(RequestInterceptorFunc(func(req *http.Request, next RequestHandler) (*http.Response, error) {
+		return nil, &Error{HTTPCode: 400, Code: "InjectedError", Message: "injected"}
+	}))
+	err := client.DeleteLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InjectedError")
+}
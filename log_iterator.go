@@ -0,0 +1,239 @@
+package sls
+
+import (
+	"fmt"
+)
+
+const (
+	defaultIteratorMaxRowsPerSlice = 10000
+	maxGetLogsLines                = 100
+	iteratorHistogramBuckets       = 60
+)
+
+// LogIterator pages through the logs of a search query in a time range of any size, eg.
+//
+//	it := sls.NewLogIterator(client, project, logstore, &sls.GetLogRequest{From: from, To: to, Query: "level: ERROR"})
+//	for it.Next() {
+//		fmt.Println(it.Log())
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// The time range is split into slices by GetHistogramsV2, so that each slice has at most MaxRowsPerSlice logs
+// to page through by offset. Slices are read in time order, in reverse order if GetLogRequest.Reverse is set.
+// Each page is read by GetLogsToCompletedV3, so incomplete results are retried as the client's RetryPolicy,
+// and Err returns an error if the logs or the histograms are still incomplete. Queries with SQL are not supported.
+type LogIterator struct {
+	client   ClientInterface
+	project  string
+	logstore string
+	req      GetLogRequest
+	// MaxRowsPerSlice is the max number of logs read from a time slice by offset, default 10000.
+	// A slice of one second is not split further even if it has more logs.
+	MaxRowsPerSlice int64
+
+	started   bool
+	slices    []TimeSlice
+	offset    int64
+	sliceDone bool
+	page      []map[string]string
+	pos       int
+	log       map[string]string
+	err       error
+}
+
+// TimeSlice is a part of a time range, [From, To) in seconds, with the count of logs in it
+type TimeSlice struct {
+	From  int64 `json:"from"`
+	To    int64 `json:"to"`
+	Count int64 `json:"count"`
+}
+
+// NewLogIterator creates an iterator of the logs matching req, Offset of req is ignored,
+// Lines is the size of each page, 100 if it is not in (0, 100].
+func NewLogIterator(client ClientInterface, project, logstore string, req *GetLogRequest) *LogIterator {
+	return &LogIterator{
+		client:          client,
+		project:         project,
+		logstore:        logstore,
+		req:             *req,
+		MaxRowsPerSlice: defaultIteratorMaxRowsPerSlice,
+	}
+}
+
+// NewLogIteratorOfSlices creates an iterator of the logs matching req in slices, eg. returned by SplitTimeRange.
+// The slices are read in the given order and not split again, the time range and Reverse of req only
+// decide the order of logs in each slice.
+func NewLogIteratorOfSlices(client ClientInterface, project, logstore string, req *GetLogRequest, slices []TimeSlice) *LogIterator {
+	it := NewLogIterator(client, project, logstore, req)
+	it.started = true
+	it.slices = slices
+	return it
+}
+
+// Next moves to the next log, false if there are no more logs or an error occurs
+func (it *LogIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		if it.err = it.split(); it.err != nil {
+			return false
+		}
+	}
+	for it.pos >= len(it.page) {
+		if !it.fetch() {
+			it.log = nil
+			return false
+		}
+	}
+	it.log = it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Log returns the current log
+func (it *LogIterator) Log() map[string]string {
+	return it.log
+}
+
+// Err returns the error that stops the iteration, nil if all logs are read
+func (it *LogIterator) Err() error {
+	return it.err
+}
+
+func (it *LogIterator) lines() int64 {
+	if it.req.Lines <= 0 || it.req.Lines > maxGetLogsLines {
+		return maxGetLogsLines
+	}
+	return it.req.Lines
+}
+
+func (it *LogIterator) maxRowsPerSlice() int64 {
+	if it.MaxRowsPerSlice <= 0 {
+		return defaultIteratorMaxRowsPerSlice
+	}
+	return it.MaxRowsPerSlice
+}
+
+// fetch reads the next page, false if there are no more pages or an error occurs
+func (it *LogIterator) fetch() bool {
+	for len(it.slices) > 0 {
+		if it.sliceDone {
+			it.slices = it.slices[1:]
+			it.offset, it.sliceDone = 0, false
+			continue
+		}
+		slice := it.slices[0]
+		req := it.req
+		req.From, req.To, req.FromNsPart, req.ToNsPart = slice.From, slice.To, 0, 0
+		req.Offset = it.offset
+		req.Lines = it.lines()
+		resp, err := it.client.GetLogsToCompletedV3(it.project, it.logstore, &req)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if resp.Meta.HasSQL {
+			it.err = NewClientError(fmt.Errorf("LogIterator does not support query with SQL: %s", it.req.Query))
+			return false
+		}
+		if !resp.IsComplete() {
+			it.err = NewClientError(fmt.Errorf("logs in [%d, %d) are incomplete after retries", slice.From, slice.To))
+			return false
+		}
+		it.page, it.pos = resp.Logs, 0
+		it.offset += int64(len(resp.Logs))
+		if int64(len(resp.Logs)) < req.Lines {
+			it.sliceDone = true
+		}
+		if len(resp.Logs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// split divides the time range into slices in the order to read
+func (it *LogIterator) split() error {
+	slices, err := SplitTimeRange(it.client, it.project, it.logstore, &it.req, it.maxRowsPerSlice())
+	if err != nil {
+		return err
+	}
+	if it.req.Reverse {
+		for i, j := 0, len(slices)-1; i < j; i, j = i+1, j-1 {
+			slices[i], slices[j] = slices[j], slices[i]
+		}
+	}
+	it.slices = slices
+	return nil
+}
+
+// SplitTimeRange divides the time range of req into slices of at most maxRows logs matching req by GetHistogramsV2,
+// in time order, and the parts without logs are skipped. Adjacent histogram buckets are merged into a slice,
+// and a bucket with more logs is split by the histograms of itself, until it is one second.
+// It returns an error if the histograms are incomplete after the retries of the client's RetryPolicy.
+func SplitTimeRange(client ClientInterface, project, logstore string, req *GetLogRequest, maxRows int64) ([]TimeSlice, error) {
+	return splitTimeRange(client, project, logstore, req, maxRows, req.From, req.To)
+}
+
+func splitTimeRange(client ClientInterface, project, logstore string, req *GetLogRequest, maxRows, from, to int64) ([]TimeSlice, error) {
+	interval := (to - from) / iteratorHistogramBuckets
+	if interval < 1 {
+		interval = 1
+	}
+	resp, err := client.GetHistogramsToCompletedV2(project, logstore, &GetHistogramRequest{
+		Topic:    req.Topic,
+		From:     from,
+		To:       to,
+		Query:    req.Query,
+		Interval: int32(interval),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsComplete() {
+		return nil, NewClientError(fmt.Errorf("histograms in [%d, %d) are incomplete after retries", from, to))
+	}
+	var slices []TimeSlice
+	var current *TimeSlice
+	flush := func() {
+		if current != nil {
+			slices = append(slices, *current)
+			current = nil
+		}
+	}
+	for _, histogram := range resp.Histograms {
+		if histogram.Count <= 0 {
+			continue
+		}
+		bucket := TimeSlice{From: histogram.From, To: histogram.To, Count: histogram.Count}
+		if bucket.From < from {
+			bucket.From = from
+		}
+		if bucket.To > to {
+			bucket.To = to
+		}
+		// split the bucket only if it is smaller than the range, or it never ends
+		if bucket.Count > maxRows && bucket.To-bucket.From > 1 && (bucket.From > from || bucket.To < to) {
+			flush()
+			sub, err := splitTimeRange(client, project, logstore, req, maxRows, bucket.From, bucket.To)
+			if err != nil {
+				return nil, err
+			}
+			slices = append(slices, sub...)
+			continue
+		}
+		if current != nil && current.To == bucket.From && current.Count+bucket.Count <= maxRows {
+			current.To = bucket.To
+			current.Count += bucket.Count
+			continue
+		}
+		flush()
+		current = &bucket
+	}
+	flush()
+	return slices, nil
+}
@@ -0,0 +1,126 @@
+package sls
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogSearch serves GetHistograms and GetLogsV3 of logs at the given seconds, the query is ignored
+type fakeLogSearch struct {
+	mu         sync.Mutex
+	times      []int64 // time of each log, sorted
+	incomplete int     // the first incomplete responses
+	requests   []GetLogRequest
+}
+
+func (f *fakeLogSearch) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	progress := "Complete"
+	if f.incomplete > 0 {
+		f.incomplete--
+		progress = "Incomplete"
+	}
+	if r.Method == http.MethodGet && r.URL.Query().Get("type") == "histogram" {
+		query := r.URL.Query()
+		from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+		to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+		interval, _ := strconv.ParseInt(query.Get("interval"), 10, 64)
+		histograms := []SingleHistogram{}
+		for start := from; start < to; start += interval {
+			end := start + interval
+			if end > to {
+				end = to
+			}
+			histograms = append(histograms, SingleHistogram{From: start, To: end, Count: int64(len(f.between(start, end))), Progress: progress})
+		}
+		w.Header().Set(ProgressHeader, progress)
+		w.Header().Set(GetLogsCountHeader, strconv.Itoa(len(f.between(from, to))))
+		json.NewEncoder(w).Encode(histograms)
+		return
+	}
+	var req GetLogRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	f.requests = append(f.requests, req)
+	times := f.between(req.From, req.To)
+	if req.Reverse {
+		sort.Slice(times, func(i, j int) bool { return times[i] > times[j] })
+	}
+	logs := []map[string]string{}
+	for i := req.Offset; i < int64(len(times)) && i < req.Offset+req.Lines; i++ {
+		logs = append(logs, map[string]string{"__time__": strconv.FormatInt(times[i], 10)})
+	}
+	json.NewEncoder(w).Encode(GetLogsV3Response{Meta: GetLogsV3ResponseMeta{Progress: progress, Count: int64(len(logs))}, Logs: logs})
+}
+
+func (f *fakeLogSearch) between(from, to int64) []int64 {
+	var times []int64
+	for _, t := range f.times {
+		if t >= from && t < to {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+func TestLogIterator(t *testing.T) {
+	search := &fakeLogSearch{}
+	for i := int64(0); i < 120; i++ {
+		search.times = append(search.times, 1000+i/2) // 2 logs per second
+	}
+	for i := int64(0); i < 30; i++ {
+		search.times = append(search.times, 1100) // a hot second
+	}
+	sort.Slice(search.times, func(i, j int) bool { return search.times[i] < search.times[j] })
+	client := newHandlerTestClient(t, search.serveHTTP)
+
+	it := NewLogIterator(client, "project", "logstore", &GetLogRequest{From: 900, To: 1200, Query: "*", Lines: 7})
+	it.MaxRowsPerSlice = 20
+	var times []int64
+	for it.Next() {
+		ts, err := strconv.ParseInt(it.Log()["__time__"], 10, 64)
+		require.NoError(t, err)
+		times = append(times, ts)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, search.times, times)
+	for _, req := range search.requests {
+		assert.LessOrEqual(t, req.Offset, int64(30), "a slice of one second is not split")
+		assert.Equal(t, int64(7), req.Lines)
+	}
+
+	search.requests = nil
+	it = NewLogIterator(client, "project", "logstore", &GetLogRequest{From: 900, To: 1200, Query: "*", Reverse: true})
+	times = times[:0]
+	for it.Next() {
+		ts, _ := strconv.ParseInt(it.Log()["__time__"], 10, 64)
+		times = append(times, ts)
+	}
+	require.NoError(t, it.Err())
+	require.Len(t, times, len(search.times))
+	assert.True(t, sort.SliceIsSorted(times, func(i, j int) bool { return times[i] > times[j] }))
+
+	// incomplete responses are retried
+	search.incomplete = 2
+	it = NewLogIterator(client, "project", "logstore", &GetLogRequest{From: 1000, To: 1001, Query: "*"})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, 2, count)
+
+	// no logs are skipped by histograms incomplete after retries
+	client.SetRetryPolicy(&RetryPolicy{CompletedRetryCount: 1})
+	search.incomplete = 1
+	it = NewLogIterator(client, "project", "logstore", &GetLogRequest{From: 1000, To: 1001, Query: "*"})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/log/level"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -65,6 +66,14 @@ type LogProject struct {
 	// be ignored
 	commonHeaders map[string]string
 	innerHeaders  map[string]string
+
+	// ctx is the context all requests of the project are bound to,
+	// nil means context.Background()
+	ctx          context.Context
+	interceptors []RequestInterceptor
+	retryPolicy  *RetryPolicy
+	// tracerProvider enables OpenTelemetry tracing if not nil
+	tracerProvider trace.TracerProvider
 }
 
 // NewLogProject creates a new SLS project.
@@ -125,11 +134,45 @@ func (p *LogProject) WithRetryTimeout(timeout time.Duration) *LogProject {
 	return p
 }
 
+// WithRetryPolicy with custom retry policy, nil means the default retry behavior
+// controlled by package level RetryOnServerErrorEnabled.
+func (p *LogProject) WithRetryPolicy(policy *RetryPolicy) *LogProject {
+	p.retryPolicy = policy
+	return p
+}
+
+// WithRequestInterceptors appends interceptors to the project's interceptor chain and returns the same project.
+// Interceptors are called in the order they are added.
+func (p *LogProject) WithRequestInterceptors(interceptors ...RequestInterceptor) *LogProject {
+	chain := make([]RequestInterceptor, 0, len(p.interceptors)+len(interceptors))
+	chain = append(chain, p.interceptors...)
+	p.interceptors = append(chain, interceptors...)
+	return p
+}
+
+// WithContext returns a shallow copy of the project whose requests are bound to ctx.
+// Cancellation or deadline of ctx aborts in-flight requests and pending retries.
+func (p *LogProject) WithContext(ctx context.Context) *LogProject {
+	if ctx == nil {
+		panic("nil context")
+	}
+	p2 := *p
+	p2.ctx = ctx
+	return &p2
+}
+
+// Context returns the context of the project, default is context.Background()
+func (p *LogProject) Context() context.Context {
+	if p.ctx != nil {
+		return p.ctx
+	}
+	return context.Background()
+}
+
 // RawRequest send raw http request to LogService and return the raw http response
 // @note you should call http.Response.Body.Close() to close body stream
 func (p *LogProject) RawRequest(method, uri string, headers map[string]string, body []byte) (*http.Response, error) {
-	ctx := context.Background()
-	return realRequest(ctx, p, method, uri, headers, body)
+	return realRequest(p.Context(), p, method, uri, headers, body)
 }
 
 // ListLogStore returns all logstore names of project p.
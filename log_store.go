@@ -821,16 +821,21 @@ func (s *LogStore) GetLogsByNano(topic string, fromInNS int64, toInNs int64, que
 
 func (s *LogStore) getToCompleted(f func() (bool, error)) {
 	interval := 100 * time.Millisecond
-	retryCount := MaxCompletedRetryCount
+	retryCount := s.project.retryPolicy.completedRetryCount()
 	isCompleted := false
-	timeoutTime := time.Now().Add(MaxCompletedRetryLatency)
+	timeoutTime := time.Now().Add(s.project.retryPolicy.completedRetryLatency())
+	ctx := s.project.Context()
 	for retryCount > 0 && timeoutTime.After(time.Now()) {
 		var err error
 		isCompleted, err = f()
 		if err != nil || isCompleted {
 			return
 		}
-		time.Sleep(interval)
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
 		retryCount--
 		if interval < 10*time.Second {
 			interval = interval * 2
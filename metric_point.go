@@ -0,0 +1,220 @@
+package sls
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// Keys of the contents of a metric log, which are the SubStoreKeys of a MetricStore
+const (
+	MetricNameKey     = "__name__"
+	MetricLabelsKey   = "__labels__"
+	MetricTimeNanoKey = "__time_nano__"
+	MetricValueKey    = "__value__"
+)
+
+const (
+	metricLabelSeparator      = "|"
+	metricLabelValueSeparator = "#$#"
+)
+
+// MetricPoint is a sample of a time series in MetricStore
+type MetricPoint struct {
+	Name      string
+	Labels    map[string]string // without __name__
+	Timestamp time.Time
+	Value     float64
+}
+
+// ToLog converts the point to a metric log
+func (p *MetricPoint) ToLog() *Log {
+	return &Log{
+		Time: proto.Uint32(uint32(p.Timestamp.Unix())),
+		Contents: []*LogContent{
+			{Key: proto.String(MetricNameKey), Value: proto.String(p.Name)},
+			{Key: proto.String(MetricLabelsKey), Value: proto.String(EncodeMetricLabels(p.Labels))},
+			{Key: proto.String(MetricTimeNanoKey), Value: proto.String(strconv.FormatInt(p.Timestamp.UnixNano(), 10))},
+			{Key: proto.String(MetricValueKey), Value: proto.String(strconv.FormatFloat(p.Value, 'g', -1, 64))},
+		},
+	}
+}
+
+// EncodeMetricLabels encodes labels sorted by name as k1#$#v1|k2#$#v2, __name__ and empty values are skipped
+func EncodeMetricLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name, value := range labels {
+		if name == MetricNameKey || value == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(metricLabelSeparator)
+		}
+		b.WriteString(name)
+		b.WriteString(metricLabelValueSeparator)
+		b.WriteString(labels[name])
+	}
+	return b.String()
+}
+
+// DecodeMetricLabels decodes labels encoded by EncodeMetricLabels,
+// a "|" in label value is kept if the part after it is not a label
+func DecodeMetricLabels(encoded string) (map[string]string, error) {
+	labels := map[string]string{}
+	if encoded == "" {
+		return labels, nil
+	}
+	var last string
+	for _, part := range strings.Split(encoded, metricLabelSeparator) {
+		name, value, ok := strings.Cut(part, metricLabelValueSeparator)
+		if !ok {
+			if last == "" {
+				return nil, fmt.Errorf("invalid metric labels %q", encoded)
+			}
+			labels[last] += metricLabelSeparator + part
+			continue
+		}
+		labels[name] = value
+		last = name
+	}
+	return labels, nil
+}
+
+// MetricPointFromLog converts a metric log to point
+func MetricPointFromLog(log *Log) (*MetricPoint, error) {
+	point := &MetricPoint{}
+	var hasTime, hasValue bool
+	for _, content := range log.Contents {
+		switch content.GetKey() {
+		case MetricNameKey:
+			point.Name = content.GetValue()
+		case MetricLabelsKey:
+			labels, err := DecodeMetricLabels(content.GetValue())
+			if err != nil {
+				return nil, err
+			}
+			point.Labels = labels
+		case MetricTimeNanoKey:
+			nano, err := strconv.ParseInt(content.GetValue(), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", MetricTimeNanoKey, content.GetValue())
+			}
+			point.Timestamp = time.Unix(0, nano)
+			hasTime = true
+		case MetricValueKey:
+			value, err := strconv.ParseFloat(content.GetValue(), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", MetricValueKey, content.GetValue())
+			}
+			point.Value = value
+			hasValue = true
+		}
+	}
+	if point.Name == "" || !hasValue {
+		return nil, fmt.Errorf("not a metric log, %s or %s is missing", MetricNameKey, MetricValueKey)
+	}
+	if !hasTime {
+		point.Timestamp = time.Unix(int64(log.GetTime()), int64(log.GetTimeNs()))
+	}
+	if point.Labels == nil {
+		point.Labels = map[string]string{}
+	}
+	return point, nil
+}
+
+// DecodeMetricPoints converts the logs pulled from a MetricStore to points
+func DecodeMetricPoints(logGroupList *LogGroupList) ([]*MetricPoint, error) {
+	var points []*MetricPoint
+	for _, logGroup := range logGroupList.GetLogGroups() {
+		for _, log := range logGroup.GetLogs() {
+			point, err := MetricPointFromLog(log)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+const defaultMetricWriterBatchSize = 4096
+
+// MetricWriter batches points and writes them to a MetricStore by PutLogsWithMetricStoreURL.
+// Points are written once BatchSize points are added, or Flush is called. It is safe for concurrent use.
+type MetricWriter struct {
+	client      ClientInterface
+	project     string
+	metricStore string
+	// BatchSize is the max number of points written in a request, default 4096
+	BatchSize int
+
+	mu     sync.Mutex
+	points []*Log
+}
+
+func NewMetricWriter(client ClientInterface, project, metricStore string) *MetricWriter {
+	return &MetricWriter{
+		client:      client,
+		project:     project,
+		metricStore: metricStore,
+		BatchSize:   defaultMetricWriterBatchSize,
+	}
+}
+
+// Write adds points to the batch, the batch is written if it is full.
+// If the write fails, the points are kept and written by the next Write or Flush.
+func (w *MetricWriter) Write(points ...*MetricPoint) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, point := range points {
+		w.points = append(w.points, point.ToLog())
+	}
+	for len(w.points) >= w.batchSize() {
+		if err := w.writeLocked(w.batchSize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes all the points added
+func (w *MetricWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(w.points) > 0 {
+		n := len(w.points)
+		if n > w.batchSize() {
+			n = w.batchSize()
+		}
+		if err := w.writeLocked(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *MetricWriter) batchSize() int {
+	if w.BatchSize <= 0 {
+		return defaultMetricWriterBatchSize
+	}
+	return w.BatchSize
+}
+
+func (w *MetricWriter) writeLocked(n int) error {
+	logGroup := &LogGroup{Logs: w.points[:n]}
+	if err := w.client.PutLogsWithMetricStoreURL(w.project, w.metricStore, logGroup); err != nil {
+		return err
+	}
+	w.points = append(w.points[:0], w.points[n:]...)
+	return nil
+}
@@ -0,0 +1,85 @@
+package sls
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricLabels(t *testing.T) {
+	encoded := EncodeMetricLabels(map[string]string{"job": "node", "__name__": "up", "instance": "host:9100", "empty": ""})
+	assert.Equal(t, "instance#$#host:9100|job#$#node", encoded)
+	labels, err := DecodeMetricLabels(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"job": "node", "instance": "host:9100"}, labels)
+
+	labels, err = DecodeMetricLabels("path#$#/a|b|method#$#GET")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"path": "/a|b", "method": "GET"}, labels)
+
+	_, err = DecodeMetricLabels("invalid")
+	assert.Error(t, err)
+}
+
+func TestMetricPoint(t *testing.T) {
+	point := &MetricPoint{
+		Name:      "http_requests_total",
+		Labels:    map[string]string{"code": "200"},
+		Timestamp: time.Unix(1700000000, 123456789),
+		Value:     42.5,
+	}
+	log := point.ToLog()
+	assert.Equal(t, uint32(1700000000), log.GetTime())
+	decoded, err := MetricPointFromLog(log)
+	require.NoError(t, err)
+	assert.Equal(t, point.Name, decoded.Name)
+	assert.Equal(t, point.Labels, decoded.Labels)
+	assert.True(t, point.Timestamp.Equal(decoded.Timestamp))
+	assert.Equal(t, point.Value, decoded.Value)
+
+	points, err := DecodeMetricPoints(&LogGroupList{LogGroups: []*LogGroup{{Logs: []*Log{log, log}}}})
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	_, err = MetricPointFromLog(&Log{})
+	assert.Error(t, err)
+}
+
+type fakeMetricClient struct {
+	ClientInterface
+	err       error
+	logGroups []*LogGroup
+}
+
+func (c *fakeMetricClient) PutLogsWithMetricStoreURL(project, logstore string, lg *LogGroup) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.logGroups = append(c.logGroups, &LogGroup{Logs: append([]*Log(nil), lg.Logs...)})
+	return nil
+}
+
+func TestMetricWriter(t *testing.T) {
+	client := &fakeMetricClient{}
+	writer := NewMetricWriter(client, "project", "metrics")
+	writer.BatchSize = 2
+	newPoint := func(value float64) *MetricPoint {
+		return &MetricPoint{Name: "up", Timestamp: time.Now(), Value: value}
+	}
+	require.NoError(t, writer.Write(newPoint(1), newPoint(2), newPoint(3)))
+	require.Len(t, client.logGroups, 1)
+	assert.Len(t, client.logGroups[0].Logs, 2)
+
+	client.err = errors.New("network error")
+	assert.Error(t, writer.Flush())
+	client.err = nil
+	require.NoError(t, writer.Flush())
+	require.Len(t, client.logGroups, 2)
+	points, err := DecodeMetricPoints(&LogGroupList{LogGroups: client.logGroups})
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, 3.0, points[2].Value)
+}
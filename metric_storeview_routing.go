@@ -458,3 +458,43 @@ func (s *StoreViewRoutingChecker) CheckPromQlQuery(query string, sourceProjects
 
 	return dstProjects, nil
 }
+
+// RouteQuery returns the metric stores read by a PromQL query, picked from sourceProjects by the routing configs,
+// in the order of sourceProjects without duplicates
+func (s *StoreViewRoutingChecker) RouteQuery(query string, sourceProjects []ProjectStore) ([]ProjectStore, error) {
+	results, err := s.CheckPromQlQuery(query, sourceProjects)
+	if err != nil {
+		return nil, err
+	}
+	picked := make(map[ProjectStore]struct{})
+	for _, result := range results {
+		for _, projectStore := range result.ProjectStores {
+			picked[projectStore] = struct{}{}
+		}
+	}
+	stores := make([]ProjectStore, 0, len(picked))
+	for _, projectStore := range sourceProjects {
+		if _, ok := picked[projectStore]; ok {
+			stores = append(stores, projectStore)
+			delete(picked, projectStore)
+		}
+	}
+	return stores, nil
+}
+
+// QueryTarget returns the project and store a PromQL query of storeView should be sent to, eg. by Client.QueryRange.
+// It is the only metric store the query reads if the routing picks one, so that the query skips the other stores,
+// otherwise the storeView itself. storeViewStores are the stores of storeView, a store without project is in project.
+func (s *StoreViewRoutingChecker) QueryTarget(query, project, storeView string, storeViewStores []ProjectStore) (string, string, error) {
+	stores, err := s.RouteQuery(query, storeViewStores)
+	if err != nil {
+		return "", "", err
+	}
+	if len(stores) != 1 {
+		return project, storeView, nil
+	}
+	if stores[0].ProjectName == "" {
+		return project, stores[0].MetricStore, nil
+	}
+	return stores[0].ProjectName, stores[0].MetricStore, nil
+}
@@ -41,12 +41,16 @@ func initIoWorker(client sls.ClientInterface, retryQueue *RetryQueue, logger log
 }
 
 func (ioWorker *IoWorker) sendToServer(producerBatch *ProducerBatch) {
+	if ioWorker.delayIfLimited(producerBatch) {
+		return
+	}
 	level.Debug(ioWorker.logger).Log("msg", "ioworker send data to server")
 	sendBegin := time.Now()
+	client, span := ioWorker.startSendSpan(producerBatch)
 	var err error
 	if producerBatch.isUseMetricStoreUrl() {
 		// not use compress type now
-		err = ioWorker.client.PutLogsWithMetricStoreURL(producerBatch.getProject(), producerBatch.getLogstore(), producerBatch.logGroup)
+		err = client.PutLogsWithMetricStoreURL(producerBatch.getProject(), producerBatch.getLogstore(), producerBatch.logGroup)
 	} else {
 		req := &sls.PostLogStoreLogsRequest{
 			LogGroup:     producerBatch.logGroup,
@@ -54,22 +58,27 @@ func (ioWorker *IoWorker) sendToServer(producerBatch *ProducerBatch) {
 			CompressType: ioWorker.producer.producerConfig.CompressType,
 			Processor:    ioWorker.producer.producerConfig.Processor,
 		}
-		err = ioWorker.client.PostLogStoreLogsV2(producerBatch.getProject(), producerBatch.getLogstore(), req)
+		err = client.PostLogStoreLogsV2(producerBatch.getProject(), producerBatch.getLogstore(), req)
 	}
 	sendEnd := time.Now()
 
 	// send ok
 	if err == nil {
 		level.Debug(ioWorker.logger).Log("msg", "sendToServer success")
+		ioWorker.producer.limiter.observe(producerBatch, nil, sendEnd)
+		endSendSpan(span, nil, false)
 		defer ioWorker.producer.monitor.recordSuccess(sendBegin, sendEnd)
 		producerBatch.OnSuccess(sendBegin)
+		ioWorker.producer.wal.ack(producerBatch.walSegments)
 		// After successful delivery, producer removes the batch size sent out
-		atomic.AddInt64(&ioWorker.producer.producerLogGroupSize, -producerBatch.totalDataSize)
+		ioWorker.producer.releaseMemory(producerBatch.totalDataSize)
 		return
 	}
 
 	slsError := parseSlsError(err)
+	ioWorker.producer.limiter.observe(producerBatch, slsError, sendEnd)
 	canRetry := ioWorker.canRetry(producerBatch, slsError)
+	endSendSpan(span, slsError, canRetry)
 	level.Error(ioWorker.logger).Log("msg", "sendToServer failed",
 		"retryTimes", producerBatch.attemptCount,
 		"requestId", slsError.RequestID,
@@ -80,7 +89,13 @@ func (ioWorker *IoWorker) sendToServer(producerBatch *ProducerBatch) {
 	if !canRetry {
 		defer ioWorker.producer.monitor.recordFailure(sendBegin, sendEnd)
 		producerBatch.OnFail(slsError, sendBegin)
-		atomic.AddInt64(&ioWorker.producer.producerLogGroupSize, -producerBatch.totalDataSize)
+		if _, ok := ioWorker.noRetryStatusCodeMap[int(slsError.HTTPCode)]; ok {
+			// rejected by server, it makes no sense to send the logs again after restart
+			ioWorker.producer.wal.ack(producerBatch.walSegments)
+		} else if ioWorker.producer.producerConfig.WALAckOnFailure && !ioWorker.retryQueueShutDownFlag.Load() {
+			ioWorker.producer.wal.ack(producerBatch.walSegments)
+		}
+		ioWorker.producer.releaseMemory(producerBatch.totalDataSize)
 		return
 	}
 
@@ -92,6 +107,23 @@ func (ioWorker *IoWorker) sendToServer(producerBatch *ProducerBatch) {
 	ioWorker.retryQueue.sendToRetryQueue(producerBatch, ioWorker.logger)
 }
 
+// delayIfLimited puts the batch back to the retry queue if it is over the rate limit of its logstore.
+// The batch is sent right away if the producer is closing, because the retry queue is not consumed any more,
+// and waiting would block Close.
+func (ioWorker *IoWorker) delayIfLimited(producerBatch *ProducerBatch) bool {
+	if ioWorker.retryQueueShutDownFlag.Load() {
+		return false
+	}
+	delay := ioWorker.producer.limiter.acquire(producerBatch, time.Now())
+	if delay <= 0 {
+		return false
+	}
+	level.Debug(ioWorker.logger).Log("msg", "batch is rate limited, send it later", "delay", delay)
+	producerBatch.nextRetryMs = time.Now().Add(delay).UnixMilli()
+	ioWorker.retryQueue.sendToRetryQueue(producerBatch, ioWorker.logger)
+	return true
+}
+
 func parseSlsError(err error) *sls.Error {
 	if slsError, ok := err.(*sls.Error); ok {
 		return slsError
@@ -2,6 +2,8 @@ package producer
 
 import (
 	"errors"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -38,32 +40,62 @@ func initLogAccumulator(config *ProducerConfig, ioWorker *IoWorker, logger log.L
 }
 
 func (logAccumulator *LogAccumulator) addLogToProducerBatch(project, logstore, shardHash, logTopic, logSource string,
-	logData interface{}, callback CallBack) error {
+	tags []*sls.LogTag, logData interface{}, callback CallBack) error {
 	if logAccumulator.shutDownFlag.Load() {
 		level.Warn(logAccumulator.logger).Log("msg", "Producer has started and shut down and cannot write to new logs")
 		return errors.New("Producer has started and shut down and cannot write to new logs")
 	}
 	if log, ok := logData.(*sls.Log); ok {
-		logAccumulator.addLog(project, logstore, shardHash, logTopic, logSource, log, callback)
+		segment, err := logAccumulator.appendWAL(project, logstore, shardHash, logTopic, logSource, tags, []*sls.Log{log})
+		if err != nil {
+			return err
+		}
+		logAccumulator.addLog(project, logstore, shardHash, logTopic, logSource, tags, log, callback, segment)
 		return nil
 	}
 	if logList, ok := logData.([]*sls.Log); ok {
-		logAccumulator.addLogList(project, logstore, shardHash, logTopic, logSource, logList, callback)
+		segment, err := logAccumulator.appendWAL(project, logstore, shardHash, logTopic, logSource, tags, logList)
+		if err != nil {
+			return err
+		}
+		logAccumulator.addLogList(project, logstore, shardHash, logTopic, logSource, tags, logList, callback, segment)
 		return nil
 	}
 	level.Error(logAccumulator.logger).Log("msg", "Invalid logType")
 	return errors.New("invalid logType")
 }
 
+// appendWAL persists the logs before they are added to batches, returns nil segment if wal is disabled
+func (logAccumulator *LogAccumulator) appendWAL(project, logstore, shardHash, logTopic, logSource string, tags []*sls.LogTag, logList []*sls.Log) (*walSegment, error) {
+	wal := logAccumulator.producer.wal
+	if wal == nil {
+		return nil, nil
+	}
+	segment, err := wal.append(&walRecord{
+		project:   project,
+		logstore:  logstore,
+		shardHash: shardHash,
+		topic:     logTopic,
+		source:    logSource,
+		tags:      tags,
+		logs:      logList,
+	})
+	if err != nil {
+		level.Error(logAccumulator.logger).Log("msg", "failed to append logs to wal", "error", err)
+		return nil, err
+	}
+	return segment, nil
+}
+
 func (logAccumulator *LogAccumulator) addLog(project, logstore, shardHash, logTopic, logSource string,
-	log *sls.Log, callback CallBack) {
-	key := logAccumulator.getKeyString(project, logstore, logTopic, shardHash, logSource)
+	tags []*sls.LogTag, log *sls.Log, callback CallBack, segment *walSegment) {
+	key := logAccumulator.getKeyString(project, logstore, logTopic, shardHash, logSource, tags)
 	logSize := int64(GetLogSizeCalculate(log))
 	atomic.AddInt64(&logAccumulator.producer.producerLogGroupSize, logSize)
 
 	logAccumulator.lock.Lock()
-	producerBatch := logAccumulator.getOrCreateProducerBatch(key, project, logstore, logTopic, logSource, shardHash)
-	producerBatch.addLog(log, logSize, callback)
+	producerBatch := logAccumulator.getOrCreateProducerBatch(key, project, logstore, logTopic, logSource, shardHash, tags)
+	producerBatch.addLog(log, logSize, callback, segment)
 
 	if !producerBatch.meetSendCondition(logAccumulator.producerConfig) {
 		logAccumulator.lock.Unlock()
@@ -77,14 +109,14 @@ func (logAccumulator *LogAccumulator) addLog(project, logstore, shardHash, logTo
 }
 
 func (logAccumulator *LogAccumulator) addLogList(project, logstore, shardHash, logTopic, logSource string,
-	logList []*sls.Log, callback CallBack) {
-	key := logAccumulator.getKeyString(project, logstore, logTopic, shardHash, logSource)
+	tags []*sls.LogTag, logList []*sls.Log, callback CallBack, segment *walSegment) {
+	key := logAccumulator.getKeyString(project, logstore, logTopic, shardHash, logSource, tags)
 	logListSize := int64(GetLogListSize(logList))
 	atomic.AddInt64(&logAccumulator.producer.producerLogGroupSize, logListSize)
 
 	logAccumulator.lock.Lock()
-	producerBatch := logAccumulator.getOrCreateProducerBatch(key, project, logstore, logTopic, logSource, shardHash)
-	producerBatch.addLogList(logList, logListSize, callback)
+	producerBatch := logAccumulator.getOrCreateProducerBatch(key, project, logstore, logTopic, logSource, shardHash, tags)
+	producerBatch.addLogList(logList, logListSize, callback, segment)
 
 	if !producerBatch.meetSendCondition(logAccumulator.producerConfig) {
 		logAccumulator.lock.Unlock()
@@ -97,18 +129,18 @@ func (logAccumulator *LogAccumulator) addLogList(project, logstore, shardHash, l
 	logAccumulator.threadPool.addTask(producerBatch)
 }
 
-func (logAccumulator *LogAccumulator) getOrCreateProducerBatch(key, project, logstore, logTopic, logSource, shardHash string) *ProducerBatch {
+func (logAccumulator *LogAccumulator) getOrCreateProducerBatch(key, project, logstore, logTopic, logSource, shardHash string, tags []*sls.LogTag) *ProducerBatch {
 	if producerBatch, ok := logAccumulator.logGroupData[key]; ok && producerBatch != nil {
 		return producerBatch
 	}
 
 	logAccumulator.producer.monitor.incCreateBatch()
-	batch := newProducerBatch(logAccumulator.packIdGenrator, project, logstore, logTopic, logSource, shardHash, logAccumulator.producerConfig)
+	batch := newProducerBatch(logAccumulator.packIdGenrator, project, logstore, logTopic, logSource, shardHash, tags, logAccumulator.producerConfig)
 	logAccumulator.logGroupData[key] = batch
 	return batch
 }
 
-func (logAccumulator *LogAccumulator) getKeyString(project, logstore, logTopic, shardHash, logSource string) string {
+func (logAccumulator *LogAccumulator) getKeyString(project, logstore, logTopic, shardHash, logSource string, tags []*sls.LogTag) string {
 	var key strings.Builder
 	key.Grow(len(project) + len(logstore) + len(logTopic) + len(shardHash) + len(logSource) + len(Delimiter)*4)
 	key.WriteString(project)
@@ -120,5 +152,26 @@ func (logAccumulator *LogAccumulator) getKeyString(project, logstore, logTopic,
 	key.WriteString(shardHash)
 	key.WriteString(Delimiter)
 	key.WriteString(logSource)
+	if len(tags) > 0 {
+		writeTagsKey(&key, tags)
+	}
 	return key.String()
 }
+
+// writeTagsKey writes the tags in the order of keys, each with the length of key and value,
+// so that the logs of different tags never share a batch even if the tags contain Delimiter
+func writeTagsKey(key *strings.Builder, tags []*sls.LogTag) {
+	sorted := append(make([]*sls.LogTag, 0, len(tags)), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetKey() < sorted[j].GetKey()
+	})
+	for _, tag := range sorted {
+		key.WriteString(Delimiter)
+		key.WriteString(strconv.Itoa(len(tag.GetKey())))
+		key.WriteByte(':')
+		key.WriteString(tag.GetKey())
+		key.WriteString(strconv.Itoa(len(tag.GetValue())))
+		key.WriteByte(':')
+		key.WriteString(tag.GetValue())
+	}
+}
@@ -0,0 +1,121 @@
+package producer
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "sls"
+	metricsSubsystem = "producer"
+)
+
+// latencyBuckets are the buckets of latency histograms in seconds, from 1ms to about 32s
+var latencyBuckets = prometheus.ExponentialBuckets(0.001, 2, 16)
+
+// producerMetrics holds the cumulative prometheus metrics of a producer,
+// unlike ProducerMetrics, they are never reset.
+type producerMetrics struct {
+	batchesCreated     prometheus.Counter
+	retries            prometheus.Counter
+	waitMemoryFailures prometheus.Counter
+	sendDuration       *prometheus.HistogramVec // result
+	callbackDuration   *prometheus.HistogramVec // result
+	waitMemoryDuration prometheus.Histogram
+	memoryInUse        prometheus.GaugeFunc
+	memoryLimit        prometheus.GaugeFunc
+	collectors         []prometheus.Collector
+}
+
+func newProducerMetrics(producer *Producer) *producerMetrics {
+	m := &producerMetrics{
+		batchesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "batches_created_total",
+			Help:      "Total number of batches created by the producer.",
+		}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "retries_total",
+			Help:      "Total number of failed sends that are put into the retry queue.",
+		}),
+		waitMemoryFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "wait_memory_failures_total",
+			Help:      "Total number of sends rejected because no memory is available before MaxBlockSec.",
+		}),
+		sendDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "send_duration_seconds",
+			Help:      "Latency of sending a batch to the server, by result: success, failure or retry.",
+			Buckets:   latencyBuckets,
+		}, []string{"result"}),
+		callbackDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "callback_duration_seconds",
+			Help:      "Latency of the user callbacks of a batch, by result: success or failure.",
+			Buckets:   latencyBuckets,
+		}, []string{"result"}),
+		waitMemoryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "wait_memory_duration_seconds",
+			Help:      "Time spent by senders waiting for available memory.",
+			Buckets:   latencyBuckets,
+		}),
+		memoryInUse: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "memory_in_use_bytes",
+			Help:      "Size of the logs cached by the producer and not yet sent.",
+		}, func() float64 {
+			return float64(atomic.LoadInt64(&producer.producerLogGroupSize))
+		}),
+		memoryLimit: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "memory_limit_bytes",
+			Help:      "Max size of the logs the producer can cache, TotalSizeLnBytes of the config.",
+		}, func() float64 {
+			return float64(producer.producerConfig.TotalSizeLnBytes)
+		}),
+	}
+	m.collectors = []prometheus.Collector{
+		m.batchesCreated,
+		m.retries,
+		m.waitMemoryFailures,
+		m.sendDuration,
+		m.callbackDuration,
+		m.waitMemoryDuration,
+		m.memoryInUse,
+		m.memoryLimit,
+	}
+	return m
+}
+
+func (m *producerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors {
+		c.Describe(ch)
+	}
+}
+
+func (m *producerMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors {
+		c.Collect(ch)
+	}
+}
+
+// Collector returns a prometheus.Collector which exposes the runtime metrics of the producer,
+// eg. memory in use, batches created, retries and the latency histograms of sending.
+// To register multiple producers in the same registry, distinguish them with labels, eg.
+//
+//	prometheus.WrapRegistererWith(prometheus.Labels{"producer": "a"}, registry).MustRegister(producer.Collector())
+func (producer *Producer) Collector() prometheus.Collector {
+	return producer.monitor.prom
+}
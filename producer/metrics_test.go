@@ -0,0 +1,39 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducerCollector(t *testing.T) {
+	producer := &Producer{producerConfig: GetDefaultProducerConfig(), producerLogGroupSize: 1024}
+	producer.monitor = newProducerMonitor(producer)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(producer.Collector()))
+
+	now := time.Now()
+	producer.monitor.incCreateBatch()
+	producer.monitor.recordRetry(time.Millisecond)
+	producer.monitor.recordSuccess(now.Add(-time.Millisecond), now)
+	producer.monitor.incWaitMemoryFail()
+
+	prom := producer.monitor.prom
+	assert.Equal(t, 1.0, testutil.ToFloat64(prom.batchesCreated))
+	assert.Equal(t, 1.0, testutil.ToFloat64(prom.retries))
+	assert.Equal(t, 1.0, testutil.ToFloat64(prom.waitMemoryFailures))
+	assert.Equal(t, 1024.0, testutil.ToFloat64(prom.memoryInUse))
+	assert.Equal(t, 2, testutil.CollectAndCount(prom.sendDuration))
+
+	// metrics are cumulative, the periodic report does not reset them
+	producer.monitor.getAndResetMetrics()
+	assert.Equal(t, 1.0, testutil.ToFloat64(prom.batchesCreated))
+
+	count, err := testutil.GatherAndCount(registry)
+	require.NoError(t, err)
+	assert.Equal(t, 9, count)
+}
@@ -23,10 +23,11 @@ type ProducerMetrics struct {
 
 type ProducerMonitor struct {
 	metrics atomic.Value // *ProducerMetrics
+	prom    *producerMetrics
 }
 
-func newProducerMonitor() *ProducerMonitor {
-	m := &ProducerMonitor{}
+func newProducerMonitor(producer *Producer) *ProducerMonitor {
+	m := &ProducerMonitor{prom: newProducerMetrics(producer)}
 	m.metrics.Store(&ProducerMetrics{})
 	return m
 }
@@ -35,33 +36,42 @@ func (m *ProducerMonitor) recordSuccess(sendBegin time.Time, sendEnd time.Time)
 	metrics := m.metrics.Load().(*ProducerMetrics)
 	metrics.sendBatch.AddSample(float64(sendEnd.Sub(sendBegin).Microseconds()))
 	metrics.onSuccess.AddSample(float64(time.Since(sendEnd).Microseconds()))
+	m.prom.sendDuration.WithLabelValues("success").Observe(sendEnd.Sub(sendBegin).Seconds())
+	m.prom.callbackDuration.WithLabelValues("success").Observe(time.Since(sendEnd).Seconds())
 }
 
 func (m *ProducerMonitor) recordFailure(sendBegin time.Time, sendEnd time.Time) {
 	metrics := m.metrics.Load().(*ProducerMetrics)
 	metrics.sendBatch.AddSample(float64(sendEnd.Sub(sendBegin).Microseconds()))
 	metrics.onFail.AddSample(float64(time.Since(sendEnd).Microseconds()))
+	m.prom.sendDuration.WithLabelValues("failure").Observe(sendEnd.Sub(sendBegin).Seconds())
+	m.prom.callbackDuration.WithLabelValues("failure").Observe(time.Since(sendEnd).Seconds())
 }
 
 func (m *ProducerMonitor) recordRetry(sendCost time.Duration) {
 	metrics := m.metrics.Load().(*ProducerMetrics)
 	metrics.sendBatch.AddSample(float64(sendCost.Microseconds()))
 	metrics.retryCount.Add(1)
+	m.prom.sendDuration.WithLabelValues("retry").Observe(sendCost.Seconds())
+	m.prom.retries.Inc()
 }
 
 func (m *ProducerMonitor) recordWaitMemory(start time.Time) {
 	metrics := m.metrics.Load().(*ProducerMetrics)
 	metrics.waitMemory.AddSample(float64(time.Since(start).Microseconds()))
+	m.prom.waitMemoryDuration.Observe(time.Since(start).Seconds())
 }
 
 func (m *ProducerMonitor) incWaitMemoryFail() {
 	metrics := m.metrics.Load().(*ProducerMetrics)
 	metrics.waitMemoryFailCount.Add(1)
+	m.prom.waitMemoryFailures.Inc()
 }
 
 func (m *ProducerMonitor) incCreateBatch() {
 	metrics := m.metrics.Load().(*ProducerMetrics)
 	metrics.createBatch.Add(1)
+	m.prom.batchesCreated.Inc()
 }
 
 func (m *ProducerMonitor) getAndResetMetrics() *ProducerMetrics {
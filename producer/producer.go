@@ -1,12 +1,14 @@
 package producer
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/slsencoding"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 )
@@ -16,6 +18,9 @@ const (
 	IllegalStateException = "IllegalStateException"
 )
 
+// ErrTimeout is returned by Send* if no memory is available before MaxBlockSec, and by Close if it times out
+var ErrTimeout = errors.New(TimeoutExecption)
+
 type Producer struct {
 	producerConfig        *ProducerConfig
 	logAccumulator        *LogAccumulator
@@ -28,6 +33,9 @@ type Producer struct {
 	logger                log.Logger
 	producerLogGroupSize  int64
 	monitor               *ProducerMonitor
+	memoryReleased        notifier
+	wal                   *wal         // optional
+	limiter               *rateLimiter // optional
 }
 
 func NewProducer(producerConfig *ProducerConfig) (*Producer, error) {
@@ -38,7 +46,11 @@ func NewProducer(producerConfig *ProducerConfig) (*Producer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return createProducerInternal(client, finalProducerConfig, logger), nil
+	producer := createProducerInternal(client, finalProducerConfig, logger)
+	if err := producer.openWAL(); err != nil {
+		return nil, err
+	}
+	return producer, nil
 }
 
 // Deprecated: use NewProducer instead.
@@ -47,7 +59,11 @@ func InitProducer(producerConfig *ProducerConfig) *Producer {
 	finalProducerConfig := validateProducerConfig(producerConfig, logger)
 
 	client, _ := createClient(finalProducerConfig, true, logger)
-	return createProducerInternal(client, finalProducerConfig, logger)
+	producer := createProducerInternal(client, finalProducerConfig, logger)
+	if err := producer.openWAL(); err != nil {
+		level.Error(logger).Log("msg", "failed to open wal, logs will only be buffered in memory", "error", err)
+	}
+	return producer
 }
 
 func createProducerInternal(client sls.ClientInterface, finalProducerConfig *ProducerConfig, logger log.Logger) *Producer {
@@ -76,10 +92,32 @@ func createProducerInternal(client sls.ClientInterface, finalProducerConfig *Pro
 	producer.ioWorkerWaitGroup = &sync.WaitGroup{}
 	producer.ioThreadPoolWaitGroup = &sync.WaitGroup{}
 	producer.logger = logger
-	producer.monitor = newProducerMonitor()
+	producer.monitor = newProducerMonitor(producer)
+	if finalProducerConfig.EnableQuotaBackoff || !finalProducerConfig.RateLimit.unlimited() || len(finalProducerConfig.RateLimits) > 0 {
+		producer.limiter = newRateLimiter(finalProducerConfig, logger)
+	}
 	return producer
 }
 
+// openWAL opens the write-ahead log if WALDir is set, and adds the logs left by the last producer to batches
+func (producer *Producer) openWAL() error {
+	if producer.producerConfig.WALDir == "" {
+		return nil
+	}
+	w, err := openWAL(producer.producerConfig, producer.logger)
+	if err != nil {
+		return err
+	}
+	err = w.replay(func(record *walRecord, segment *walSegment) {
+		producer.logAccumulator.addLogList(record.project, record.logstore, record.shardHash, record.topic, record.source, record.tags, record.logs, nil, segment)
+	})
+	if err != nil {
+		return err
+	}
+	producer.wal = w
+	return nil
+}
+
 func configureClient(client sls.ClientInterface, producerConfig *ProducerConfig) {
 	if producerConfig.Region != "" {
 		client.SetRegion(producerConfig.Region)
@@ -93,6 +131,14 @@ func configureClient(client sls.ClientInterface, producerConfig *ProducerConfig)
 	if producerConfig.UserAgent != "" {
 		client.SetUserAgent(producerConfig.UserAgent)
 	}
+	if producerConfig.TracerProvider != nil {
+		switch c := client.(type) {
+		case *sls.Client:
+			c.WithTracerProvider(producerConfig.TracerProvider)
+		case *sls.TokenAutoUpdateClient:
+			c.WithTracerProvider(producerConfig.TracerProvider)
+		}
+	}
 }
 
 func createClient(producerConfig *ProducerConfig, allowStsFallback bool, logger log.Logger) (sls.ClientInterface, error) {
@@ -157,7 +203,7 @@ func (producer *Producer) HashSendLogWithCallBack(project, logstore, shardHash,
 			return err
 		}
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, log, callback)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, nil, log, callback)
 }
 
 func (producer *Producer) HashSendLogListWithCallBack(project, logstore, shardHash, topic, source string, logList []*sls.Log, callback CallBack) (err error) {
@@ -172,7 +218,7 @@ func (producer *Producer) HashSendLogListWithCallBack(project, logstore, shardHa
 			return err
 		}
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, logList, callback)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, nil, logList, callback)
 }
 
 func (producer *Producer) SendLog(project, logstore, topic, source string, log *sls.Log) error {
@@ -180,7 +226,17 @@ func (producer *Producer) SendLog(project, logstore, topic, source string, log *
 	if err != nil {
 		return err
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, log, nil)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, nil, log, nil)
+}
+
+// SendValue encodes v to a log by slsencoding.Marshal and sends it,
+// v is a struct with sls tags, a map with string keys or a json object in []byte
+func (producer *Producer) SendValue(project, logstore, topic, source string, v interface{}) error {
+	log, err := slsencoding.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return producer.SendLog(project, logstore, topic, source, log)
 }
 
 func (producer *Producer) SendLogList(project, logstore, topic, source string, logList []*sls.Log) (err error) {
@@ -189,7 +245,7 @@ func (producer *Producer) SendLogList(project, logstore, topic, source string, l
 		return err
 	}
 
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, logList, nil)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, nil, logList, nil)
 
 }
 
@@ -204,7 +260,7 @@ func (producer *Producer) HashSendLog(project, logstore, shardHash, topic, sourc
 			return err
 		}
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, log, nil)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, nil, log, nil)
 }
 
 func (producer *Producer) HashSendLogList(project, logstore, shardHash, topic, source string, logList []*sls.Log) (err error) {
@@ -218,7 +274,7 @@ func (producer *Producer) HashSendLogList(project, logstore, shardHash, topic, s
 			return err
 		}
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, logList, nil)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, shardHash, topic, source, nil, logList, nil)
 
 }
 
@@ -227,7 +283,7 @@ func (producer *Producer) SendLogWithCallBack(project, logstore, topic, source s
 	if err != nil {
 		return err
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, log, callback)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, nil, log, callback)
 }
 
 func (producer *Producer) SendLogListWithCallBack(project, logstore, topic, source string, logList []*sls.Log, callback CallBack) (err error) {
@@ -235,51 +291,86 @@ func (producer *Producer) SendLogListWithCallBack(project, logstore, topic, sour
 	if err != nil {
 		return err
 	}
-	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, logList, callback)
+	return producer.logAccumulator.addLogToProducerBatch(project, logstore, "", topic, source, nil, logList, callback)
 
 }
 
-// todo: refactor this
 func (producer *Producer) waitTime() error {
-	if atomic.LoadInt64(&producer.producerLogGroupSize) <= producer.producerConfig.TotalSizeLnBytes {
+	return producer.waitMemory(context.Background())
+}
+
+// waitMemory blocks until the cached logs are below TotalSizeLnBytes, MaxBlockSec is exceeded or ctx is done
+func (producer *Producer) waitMemory(ctx context.Context) error {
+	if !producer.memoryExceeded() {
 		return nil
 	}
 
 	// no wait
 	if producer.producerConfig.MaxBlockSec == 0 {
-		if atomic.LoadInt64(&producer.producerLogGroupSize) > producer.producerConfig.TotalSizeLnBytes {
-			level.Error(producer.logger).Log("msg", "Over producer set maximum blocking time")
-			return errors.New(TimeoutExecption)
-		}
-		return nil
+		level.Error(producer.logger).Log("msg", "Over producer set maximum blocking time")
+		return ErrTimeout
 	}
 
 	defer producer.monitor.recordWaitMemory(time.Now())
 
-	// infinite wait
-	if producer.producerConfig.MaxBlockSec < 0 {
-		for atomic.LoadInt64(&producer.producerLogGroupSize) > producer.producerConfig.TotalSizeLnBytes {
-			time.Sleep(waitTimeUnit)
-		}
-		return nil
+	var timeout <-chan time.Time
+	if producer.producerConfig.MaxBlockSec > 0 {
+		timer := time.NewTimer(time.Duration(producer.producerConfig.MaxBlockSec) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
 	}
-
-	// todo: refine this, limited wait
-	for i := 0; i < producer.producerConfig.MaxBlockSec*waitUnitPerSec; i++ {
-		if atomic.LoadInt64(&producer.producerLogGroupSize) > producer.producerConfig.TotalSizeLnBytes {
-			time.Sleep(waitTimeUnit)
-		} else {
+	for {
+		// get the channel before checking, so that a release after the check is not missed
+		released := producer.memoryReleased.wait()
+		if !producer.memoryExceeded() {
 			return nil
 		}
+		select {
+		case <-released:
+		case <-timeout:
+			producer.monitor.incWaitMemoryFail()
+			level.Error(producer.logger).Log("msg", "Over producer set maximum blocking time")
+			return ErrTimeout
+		case <-ctx.Done():
+			producer.monitor.incWaitMemoryFail()
+			return ctx.Err()
+		}
 	}
+}
 
-	producer.monitor.incWaitMemoryFail()
-	level.Error(producer.logger).Log("msg", "Over producer set maximum blocking time")
-	return errors.New(TimeoutExecption)
+func (producer *Producer) memoryExceeded() bool {
+	return atomic.LoadInt64(&producer.producerLogGroupSize) > producer.producerConfig.TotalSizeLnBytes
 }
 
-const waitTimeUnit = time.Millisecond * 10
-const waitUnitPerSec = int(time.Second / waitTimeUnit)
+// releaseMemory is called once a batch is done, and wakes up the senders waiting for memory
+func (producer *Producer) releaseMemory(size int64) {
+	atomic.AddInt64(&producer.producerLogGroupSize, -size)
+	producer.memoryReleased.notify()
+}
+
+// notifier wakes up all waiters by closing the channel returned by wait, the zero value is ready to use
+type notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func (n *notifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+func (n *notifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
 
 func (producer *Producer) Start() {
 	producer.moverWaitGroup.Add(1)
@@ -301,11 +392,13 @@ func (producer *Producer) Close(timeoutMs int64) error {
 	for !producer.threadPool.Stopped() {
 		if time.Since(startCloseTime) > time.Duration(timeoutMs)*time.Millisecond {
 			level.Warn(producer.logger).Log("msg", "The producer timeout closes, and some of the cached data may not be sent properly")
-			return errors.New(TimeoutExecption)
+			producer.closeWAL()
+			return ErrTimeout
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
 	level.Info(producer.logger).Log("msg", "All groutines of producer have been shutdown")
+	producer.closeWAL()
 	return nil
 }
 
@@ -317,9 +410,16 @@ func (producer *Producer) SafeClose() {
 	producer.ioThreadPoolWaitGroup.Wait()
 	level.Info(producer.logger).Log("msg", "IoThreadPool close finish")
 	producer.ioWorkerWaitGroup.Wait()
+	producer.closeWAL()
 	level.Info(producer.logger).Log("msg", "Producer close finish")
 }
 
+func (producer *Producer) closeWAL() {
+	if err := producer.wal.close(); err != nil {
+		level.Warn(producer.logger).Log("msg", "failed to close wal", "error", err)
+	}
+}
+
 func (producer *Producer) sendCloseProdcerSignal() {
 	level.Info(producer.logger).Log("msg", "producer start closing")
 	producer.closeStstokenChannel()
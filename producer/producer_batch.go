@@ -26,28 +26,33 @@ type ProducerBatch struct {
 	totalDataSize int64
 	logGroup      *sls.LogGroup
 	callBackList  []CallBack
+	walSegments   []*walSegment // a segment for each record of wal in the batch
 
 	// transient fields, but rw by at most one thread
 	attemptCount int
 	nextRetryMs  int64
 	result       *Result
+	rateReserved bool // the rate of the next send is reserved in rateLimiter
 }
 
-func newProducerBatch(packIdGenerator *PackIdGenerator, project, logstore, logTopic, logSource, shardHash string, config *ProducerConfig) *ProducerBatch {
+func newProducerBatch(packIdGenerator *PackIdGenerator, project, logstore, logTopic, logSource, shardHash string, tags []*sls.LogTag, config *ProducerConfig) *ProducerBatch {
 	logGroup := &sls.LogGroup{
 		Topic:  proto.String(logTopic),
 		Source: proto.String(logSource),
 		Logs:   make([]*sls.Log, 0, config.MaxBatchCount+4),
 	}
 
+	if config.GeneratePackId || len(tags) > 0 {
+		logGroup.LogTags = append(make([]*sls.LogTag, 0, len(config.LogTags)+len(tags)+1), config.LogTags...)
+		logGroup.LogTags = append(logGroup.LogTags, tags...)
+	} else {
+		logGroup.LogTags = config.LogTags
+	}
 	if config.GeneratePackId {
-		logGroup.LogTags = append(make([]*sls.LogTag, 0, len(config.LogTags)+1), config.LogTags...)
 		logGroup.LogTags = append(logGroup.LogTags, &sls.LogTag{
 			Key:   &PACK_ID_KEY,
 			Value: proto.String(packIdGenerator.GeneratePackId(project, logstore)),
 		})
-	} else {
-		logGroup.LogTags = config.LogTags
 	}
 
 	producerBatch := &ProducerBatch{
@@ -89,20 +94,26 @@ func (producerBatch *ProducerBatch) meetSendCondition(producerConfig *ProducerCo
 	return producerBatch.totalDataSize >= producerConfig.MaxBatchSize || len(producerBatch.logGroup.Logs) >= producerConfig.MaxBatchCount
 }
 
-func (producerBatch *ProducerBatch) addLog(log *sls.Log, size int64, callback CallBack) {
+func (producerBatch *ProducerBatch) addLog(log *sls.Log, size int64, callback CallBack, segment *walSegment) {
 	producerBatch.logGroup.Logs = append(producerBatch.logGroup.Logs, log)
 	producerBatch.totalDataSize += size
 	if callback != nil {
 		producerBatch.callBackList = append(producerBatch.callBackList, callback)
 	}
+	if segment != nil {
+		producerBatch.walSegments = append(producerBatch.walSegments, segment)
+	}
 }
 
-func (producerBatch *ProducerBatch) addLogList(logList []*sls.Log, size int64, callback CallBack) {
+func (producerBatch *ProducerBatch) addLogList(logList []*sls.Log, size int64, callback CallBack, segment *walSegment) {
 	producerBatch.logGroup.Logs = append(producerBatch.logGroup.Logs, logList...)
 	producerBatch.totalDataSize += size
 	if callback != nil {
 		producerBatch.callBackList = append(producerBatch.callBackList, callback)
 	}
+	if segment != nil {
+		producerBatch.walSegments = append(producerBatch.walSegments, segment)
+	}
 }
 
 func (producerBatch *ProducerBatch) OnSuccess(begin time.Time) {
@@ -6,6 +6,7 @@ import (
 
 	sls "github.com/aliyun/aliyun-log-go-sdk"
 	"github.com/go-kit/kit/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const Delimiter = "|"
@@ -76,6 +77,38 @@ type ProducerConfig struct {
 	AuthVersion      sls.AuthVersionType
 	CompressType     int    // only work for logstore now
 	Processor        string // ingest processor
+
+	// Optional, defaults to nil.
+	// TracerProvider enables OpenTelemetry tracing, a span is created for each batch sent to server.
+	TracerProvider trace.TracerProvider
+
+	// Optional, defaults to unlimited.
+	// RateLimit limits the logs sent to each project/logstore, RateLimits overrides it for specific logstores,
+	// keyed by RateLimitKey(project, logstore). Batches over the rate wait in the retry queue without taking io workers.
+	RateLimit  RateLimit
+	RateLimits map[string]RateLimit
+	// Optional, defaults to false, which only retries the failed batch itself.
+	// If set, once the server returns a quota exceeded error, eg. WriteQuotaExceed, sending to the project/logstore is paused
+	// for all batches, starting from BaseRetryBackoffMs and doubled for each consecutive error up to MaxRetryBackoffMs.
+	EnableQuotaBackoff bool
+
+	// Optional, defaults to "", which disables the write-ahead log.
+	// If set, logs accepted by Send* are appended to segment files in WALDir before they are sent,
+	// and the logs not sent yet, eg. the producer crashed or Close timed out, are sent again by the next producer on the same dir.
+	// Logs may be sent more than once, and a WALDir should only be used by one producer at a time.
+	// Batches that fail, eg. run out of retries or fail during Close, are sent again after restart too,
+	// and their segment files are kept on disk until then, unless WALAckOnFailure is set.
+	WALDir string
+	// Optional, defaults to 64MB. A new segment file is created once the current one exceeds it,
+	// and segment files are removed once all logs in them are sent.
+	WALSegmentSizeBytes int64
+	// Optional, defaults to false, which survives crashes of the process but not of the host.
+	// Set to true to fsync the segment file after each send call, which is much slower.
+	WALSync bool
+	// Optional, defaults to false.
+	// Set to true to drop the logs of a batch from the write-ahead log once it fails after all retries,
+	// as batches rejected by NoRetryStatusCodeList are. Batches failed during Close are still sent again after restart.
+	WALAckOnFailure bool
 }
 
 func GetDefaultProducerConfig() *ProducerConfig {
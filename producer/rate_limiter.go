@@ -0,0 +1,205 @@
+package producer
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// RateLimit limits the logs sent to a project/logstore, zero fields mean unlimited
+type RateLimit struct {
+	BytesPerSec    int64   // raw size of the logs in batches
+	RequestsPerSec float64 // batches sent, including retries
+}
+
+func (limit RateLimit) unlimited() bool {
+	return limit.BytesPerSec <= 0 && limit.RequestsPerSec <= 0
+}
+
+// tokenBucket allows reserving more tokens than available, the caller waits for the returned delay,
+// so that a batch larger than the burst is still sent
+type tokenBucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// reserve takes n tokens and returns how long to wait before they are available
+func (b *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// destinationLimiter is shared by all batches of a project/logstore
+type destinationLimiter struct {
+	bytes        *tokenBucket // nil if unlimited
+	requests     *tokenBucket // nil if unlimited
+	quotaErrors  int          // consecutive quota errors
+	pausedUntil  time.Time
+	lastActivity time.Time
+}
+
+// rateLimiter coordinates the batches sent to the same project/logstore,
+// it rate limits the batches as configured, and pauses sending to a destination
+// with exponential backoff once its write quota is exceeded.
+type rateLimiter struct {
+	defaultLimit    RateLimit
+	limits          map[string]RateLimit
+	quotaBackoff    bool
+	baseBackoff     time.Duration
+	maxBackoff      time.Duration
+	logger          log.Logger
+	mu              sync.Mutex
+	destinations    map[string]*destinationLimiter
+	lastCleanupTime time.Time
+}
+
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+func newRateLimiter(config *ProducerConfig, logger log.Logger) *rateLimiter {
+	return &rateLimiter{
+		defaultLimit: config.RateLimit,
+		limits:       config.RateLimits,
+		quotaBackoff: config.EnableQuotaBackoff,
+		baseBackoff:  time.Duration(config.BaseRetryBackoffMs) * time.Millisecond,
+		maxBackoff:   time.Duration(config.MaxRetryBackoffMs) * time.Millisecond,
+		logger:       logger,
+		destinations: map[string]*destinationLimiter{},
+	}
+}
+
+// RateLimitKey returns the key of ProducerConfig.RateLimits for a logstore
+func RateLimitKey(project, logstore string) string {
+	return project + "/" + logstore
+}
+
+func (limiter *rateLimiter) getDestinationLocked(key string, now time.Time) *destinationLimiter {
+	dest, ok := limiter.destinations[key]
+	if !ok {
+		limit, ok := limiter.limits[key]
+		if !ok {
+			limit = limiter.defaultLimit
+		}
+		dest = &destinationLimiter{}
+		if limit.BytesPerSec > 0 {
+			dest.bytes = newTokenBucket(float64(limit.BytesPerSec), now)
+		}
+		if limit.RequestsPerSec > 0 {
+			dest.requests = newTokenBucket(limit.RequestsPerSec, now)
+		}
+		limiter.destinations[key] = dest
+	}
+	dest.lastActivity = now
+	limiter.cleanupLocked(now)
+	return dest
+}
+
+// cleanupLocked forgets idle destinations, so that the map does not grow forever
+func (limiter *rateLimiter) cleanupLocked(now time.Time) {
+	if now.Sub(limiter.lastCleanupTime) < rateLimiterIdleTimeout {
+		return
+	}
+	limiter.lastCleanupTime = now
+	for key, dest := range limiter.destinations {
+		if now.Sub(dest.lastActivity) > rateLimiterIdleTimeout && now.After(dest.pausedUntil) {
+			delete(limiter.destinations, key)
+		}
+	}
+}
+
+// acquire returns how long the batch should wait before it is sent, 0 if it can be sent now.
+// The rate of a batch is only reserved once for each send, so a delayed batch does not pay twice.
+func (limiter *rateLimiter) acquire(batch *ProducerBatch, now time.Time) time.Duration {
+	if limiter == nil {
+		return 0
+	}
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	dest := limiter.getDestinationLocked(RateLimitKey(batch.getProject(), batch.getLogstore()), now)
+	if now.Before(dest.pausedUntil) {
+		return dest.pausedUntil.Sub(now)
+	}
+	if batch.rateReserved {
+		return 0
+	}
+	batch.rateReserved = true
+	var delay time.Duration
+	if dest.bytes != nil {
+		delay = dest.bytes.reserve(float64(batch.totalDataSize), now)
+	}
+	if dest.requests != nil {
+		if d := dest.requests.reserve(1, now); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// observe updates the backoff of the destination by the result of a send
+func (limiter *rateLimiter) observe(batch *ProducerBatch, err *sls.Error, now time.Time) {
+	if limiter == nil {
+		return
+	}
+	batch.rateReserved = false
+	if !limiter.quotaBackoff {
+		return
+	}
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	dest := limiter.getDestinationLocked(RateLimitKey(batch.getProject(), batch.getLogstore()), now)
+	if !isQuotaExceeded(err) {
+		if err == nil {
+			dest.quotaErrors = 0
+		}
+		return
+	}
+	dest.quotaErrors++
+	backoff := limiter.baseBackoff
+	for i := 1; i < dest.quotaErrors && backoff < limiter.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > limiter.maxBackoff {
+		backoff = limiter.maxBackoff
+	}
+	if pausedUntil := now.Add(backoff); pausedUntil.After(dest.pausedUntil) {
+		dest.pausedUntil = pausedUntil
+	}
+	level.Warn(limiter.logger).Log("msg", "write quota exceeded, pause sending",
+		"project", batch.getProject(),
+		"logstore", batch.getLogstore(),
+		"errorCode", err.Code,
+		"pause", backoff,
+	)
+}
+
+// isQuotaExceeded returns whether the server rejects the request because of the write quota of project or shard,
+// eg. WriteQuotaExceed, ProjectQuotaExceed, ShardWriteQuotaExceed
+func isQuotaExceeded(err *sls.Error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.HasSuffix(err.Code, "QuotaExceed") || err.HTTPCode == 429
+}
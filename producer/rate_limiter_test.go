@@ -0,0 +1,105 @@
+package producer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/slstest"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newTokenBucket(100, now)
+	assert.Equal(t, time.Duration(0), bucket.reserve(100, now))
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve(50, now))
+	// refilled, but still in debt
+	assert.Equal(t, 250*time.Millisecond, bucket.reserve(0, now.Add(250*time.Millisecond)))
+	assert.Equal(t, time.Duration(0), bucket.reserve(100, now.Add(2*time.Second)))
+}
+
+func TestRateLimiter(t *testing.T) {
+	config := GetDefaultProducerConfig()
+	config.RateLimit = RateLimit{RequestsPerSec: 1}
+	config.RateLimits = map[string]RateLimit{RateLimitKey("project", "fast"): {BytesPerSec: 1000}}
+	limiter := newRateLimiter(config, log.NewNopLogger())
+	now := time.Now()
+
+	newBatch := func(logstore string, size int64) *ProducerBatch {
+		batch := newProducerBatch(newPackIdGenerator(), "project", logstore, "", "", "", nil, config)
+		batch.totalDataSize = size
+		return batch
+	}
+	slow := newBatch("slow", 1)
+	assert.Equal(t, time.Duration(0), limiter.acquire(slow, now))
+	delayed := newBatch("slow", 1)
+	assert.Equal(t, time.Second, limiter.acquire(delayed, now))
+	// a delayed batch is not charged again
+	assert.Equal(t, time.Duration(0), limiter.acquire(delayed, now.Add(time.Second)))
+
+	assert.Equal(t, time.Duration(0), limiter.acquire(newBatch("fast", 1000), now))
+	assert.Equal(t, 500*time.Millisecond, limiter.acquire(newBatch("fast", 500), now))
+}
+
+func TestRateLimiterQuotaBackoff(t *testing.T) {
+	config := GetDefaultProducerConfig()
+	config.BaseRetryBackoffMs = 100
+	config.MaxRetryBackoffMs = 300
+	config.EnableQuotaBackoff = true
+	limiter := newRateLimiter(config, log.NewNopLogger())
+	now := time.Now()
+	batch := newProducerBatch(newPackIdGenerator(), "project", "logstore", "", "", "", nil, config)
+	other := newProducerBatch(newPackIdGenerator(), "project", "other", "", "", "", nil, config)
+	quotaErr := &sls.Error{HTTPCode: 403, Code: "WriteQuotaExceed"}
+
+	limiter.observe(batch, quotaErr, now)
+	assert.Equal(t, 100*time.Millisecond, limiter.acquire(batch, now))
+	assert.Equal(t, time.Duration(0), limiter.acquire(other, now))
+	limiter.observe(batch, quotaErr, now)
+	assert.Equal(t, 200*time.Millisecond, limiter.acquire(batch, now))
+	limiter.observe(batch, quotaErr, now)
+	limiter.observe(batch, quotaErr, now)
+	assert.Equal(t, 300*time.Millisecond, limiter.acquire(batch, now))
+
+	// other errors do not pause, and success resets the backoff
+	later := now.Add(time.Second)
+	limiter.observe(batch, &sls.Error{HTTPCode: 500, Code: "InternalServerError"}, later)
+	assert.Equal(t, time.Duration(0), limiter.acquire(batch, later))
+	limiter.observe(batch, nil, later)
+	limiter.observe(batch, quotaErr, later)
+	assert.Equal(t, 100*time.Millisecond, limiter.acquire(batch, later))
+}
+
+func TestProducerRateLimit(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "logstore", 1, 1, false, 0))
+
+	config := GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	config.MaxBatchCount = 1
+	config.RateLimit = RateLimit{RequestsPerSec: 10}
+	p, err := NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		require.NoError(t, p.SendLog("project", "logstore", "", "", GenerateLog(uint32(time.Now().Unix()), map[string]string{"index": fmt.Sprint(i)})))
+	}
+	assert.Eventually(t, func() bool {
+		return len(server.Logs("project", "logstore")) == 15
+	}, 10*time.Second, 10*time.Millisecond)
+	// 10 batches in the burst, then 10 per second
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	p.SafeClose()
+}
@@ -0,0 +1,80 @@
+package producer
+
+import (
+	"context"
+	"errors"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+// Record is the logs sent to a logstore by SendLogContext
+type Record struct {
+	Project  string
+	Logstore string
+	Topic    string
+	Source   string
+	// Optional, the hash key to route the logs to a shard, adjusted if AdjustShargHash is set.
+	HashKey string
+	// Optional, tags of the log group, eg. tenant id. Logs of different tags are sent in different log groups,
+	// with the tags appended to ProducerConfig.LogTags.
+	Tags []*sls.LogTag
+	Logs []*sls.Log
+	// Optional, called once the logs are sent or failed.
+	Callback CallBack
+}
+
+// SendLogContext sends the logs of record, it blocks until the memory cached by the producer is below TotalSizeLnBytes,
+// MaxBlockSec is exceeded (ErrTimeout is returned), or ctx is done (ctx.Err() is returned).
+// ctx is only used while waiting for memory, the logs are sent in background once accepted.
+func (producer *Producer) SendLogContext(ctx context.Context, record Record) error {
+	if len(record.Logs) == 0 {
+		return errors.New("no logs in record")
+	}
+	if err := producer.waitMemory(ctx); err != nil {
+		return err
+	}
+	shardHash := record.HashKey
+	if shardHash != "" && producer.producerConfig.AdjustShargHash {
+		var err error
+		shardHash, err = AdjustHash(shardHash, producer.buckets)
+		if err != nil {
+			return err
+		}
+	}
+	return producer.logAccumulator.addLogToProducerBatch(record.Project, record.Logstore, shardHash, record.Topic, record.Source, record.Tags, record.Logs, record.Callback)
+}
+
+// SendLogFuture is like SendLogContext, but returns a channel receiving the result once the logs are sent or failed,
+// as an alternative to Record.Callback, which is still called if set. The channel is closed after the result.
+func (producer *Producer) SendLogFuture(ctx context.Context, record Record) (<-chan *Result, error) {
+	future := &futureCallback{
+		callback: record.Callback,
+		ch:       make(chan *Result, 1),
+	}
+	record.Callback = future
+	if err := producer.SendLogContext(ctx, record); err != nil {
+		return nil, err
+	}
+	return future.ch, nil
+}
+
+type futureCallback struct {
+	callback CallBack
+	ch       chan *Result
+}
+
+func (f *futureCallback) Success(result *Result) {
+	if f.callback != nil {
+		f.callback.Success(result)
+	}
+	f.ch <- result
+	close(f.ch)
+}
+
+func (f *futureCallback) Fail(result *Result) {
+	if f.callback != nil {
+		f.callback.Fail(result)
+	}
+	f.ch <- result
+	close(f.ch)
+}
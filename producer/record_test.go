@@ -0,0 +1,195 @@
+package producer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/slstest"
+	"github.com/go-kit/kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendLogFuture(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "logstore", 1, 2, false, 0))
+
+	config := GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	config.AllowLogLevel = "error"
+	p, err := NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	defer p.SafeClose()
+
+	ctx := context.Background()
+	future, err := p.SendLogFuture(ctx, Record{
+		Project:  "project",
+		Logstore: "logstore",
+		Topic:    "topic",
+		HashKey:  "ff",
+		Logs:     []*sls.Log{GenerateLog(uint32(time.Now().Unix()), map[string]string{"k": "v"})},
+	})
+	require.NoError(t, err)
+	select {
+	case result := <-future:
+		assert.True(t, result.IsSuccessful())
+	case <-time.After(10 * time.Second):
+		t.Fatal("no result")
+	}
+	_, ok := <-future
+	assert.False(t, ok)
+	assert.Len(t, server.Logs("project", "logstore"), 1)
+
+	future, err = p.SendLogFuture(ctx, Record{
+		Project:  "project",
+		Logstore: "not-exist",
+		Logs:     []*sls.Log{GenerateLog(uint32(time.Now().Unix()), map[string]string{"k": "v"})},
+	})
+	require.NoError(t, err)
+	result := <-future
+	assert.False(t, result.IsSuccessful())
+	assert.Equal(t, "LogStoreNotExist", result.GetErrorCode())
+
+	err = p.SendLogContext(ctx, Record{Project: "project", Logstore: "logstore"})
+	assert.Error(t, err)
+}
+
+func newMemoryTestProducer(maxBlockSec int) *Producer {
+	config := GetDefaultProducerConfig()
+	config.TotalSizeLnBytes = 100
+	config.MaxBlockSec = maxBlockSec
+	producer := &Producer{producerConfig: config, producerLogGroupSize: 200, logger: log.NewNopLogger()}
+	producer.monitor = newProducerMonitor(producer)
+	return producer
+}
+
+func TestWaitMemory(t *testing.T) {
+	producer := newMemoryTestProducer(0)
+	assert.ErrorIs(t, producer.waitTime(), ErrTimeout)
+
+	producer = newMemoryTestProducer(-1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, producer.waitMemory(ctx), context.DeadlineExceeded)
+
+	// woken up once a batch is done
+	done := make(chan error)
+	go func() {
+		done <- producer.waitTime()
+	}()
+	time.Sleep(50 * time.Millisecond)
+	producer.releaseMemory(50)
+	select {
+	case <-done:
+		t.Fatal("memory is still exceeded")
+	case <-time.After(50 * time.Millisecond):
+	}
+	producer.releaseMemory(50)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("not woken up")
+	}
+	assert.Equal(t, int64(100), atomic.LoadInt64(&producer.producerLogGroupSize))
+}
+
+func TestSendLogWithTags(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "logstore", 1, 1, false, 0))
+
+	config := GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	config.LogTags = []*sls.LogTag{{Key: proto.String("region"), Value: proto.String("cn")}}
+	p, err := NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	for _, tenant := range []string{"a", "b", "a"} {
+		err := p.SendLogContext(context.Background(), Record{
+			Project:  "project",
+			Logstore: "logstore",
+			Tags:     []*sls.LogTag{{Key: proto.String("tenant"), Value: proto.String(tenant)}},
+			Logs:     []*sls.Log{GenerateLog(uint32(time.Now().Unix()), map[string]string{"tenant": tenant})},
+		})
+		require.NoError(t, err)
+	}
+	p.SafeClose()
+
+	logGroups := server.LogGroups("project", "logstore", 0)
+	require.Len(t, logGroups, 2)
+	for _, logGroup := range logGroups {
+		tags := map[string]string{}
+		for _, tag := range logGroup.LogTags {
+			tags[tag.GetKey()] = tag.GetValue()
+		}
+		assert.Equal(t, "cn", tags["region"])
+		for _, log := range logGroup.Logs {
+			assert.Equal(t, tags["tenant"], log.Contents[0].GetValue())
+		}
+	}
+}
+
+func TestBatchKeyWithTags(t *testing.T) {
+	accumulator := &LogAccumulator{}
+	tag := func(key, value string) *sls.LogTag {
+		return &sls.LogTag{Key: proto.String(key), Value: proto.String(value)}
+	}
+	key := func(tags ...*sls.LogTag) string {
+		return accumulator.getKeyString("project", "logstore", "topic", "", "source", tags)
+	}
+	assert.Equal(t, "project|logstore|topic||source", key())
+	assert.Equal(t, key(tag("a", "1"), tag("b", "2")), key(tag("b", "2"), tag("a", "1")))
+	assert.NotEqual(t, key(tag("a", "1|b")), key(tag("a", "1"), tag("b", "")))
+	assert.NotEqual(t, key(), key(tag("a", "")))
+}
+
+func TestSendValue(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "logstore", 1, 1, false, 0))
+
+	config := GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	p, err := NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	type accessLog struct {
+		Time   int64  `sls:"time,time"`
+		Method string `sls:"method"`
+	}
+	require.NoError(t, p.SendValue("project", "logstore", "", "", accessLog{Time: 1700000000, Method: "GET"}))
+	require.NoError(t, p.SendValue("project", "logstore", "", "", []byte(`{"method":"POST"}`)))
+	assert.Error(t, p.SendValue("project", "logstore", "", "", 1))
+	p.SafeClose()
+
+	logs := server.Logs("project", "logstore")
+	require.Len(t, logs, 2)
+	assert.Equal(t, uint32(1700000000), logs[0].GetTime())
+	assert.Equal(t, "GET", logs[0].Contents[0].GetValue())
+	assert.Equal(t, "POST", logs[1].Contents[0].GetValue())
+}
@@ -0,0 +1,55 @@
+package producer
+
+import (
+	"context"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	attrTopic     = attribute.Key("sls.topic")
+	attrLogCount  = attribute.Key("sls.log_count")
+	attrBatchSize = attribute.Key("sls.batch_size")
+	attrWillRetry = attribute.Key("sls.will_retry")
+)
+
+// startSendSpan starts a span for sending producerBatch,
+// and returns the client whose calls are traced as children of the span.
+func (ioWorker *IoWorker) startSendSpan(producerBatch *ProducerBatch) (sls.ClientInterface, trace.Span) {
+	tp := ioWorker.producer.producerConfig.TracerProvider
+	if tp == nil {
+		return ioWorker.client, nil
+	}
+	ctx, span := tp.Tracer(sls.TracerName).Start(context.Background(), "producer send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			sls.AttrProject.String(producerBatch.getProject()),
+			sls.AttrLogstore.String(producerBatch.getLogstore()),
+			attrTopic.String(producerBatch.logGroup.GetTopic()),
+			attrLogCount.Int(len(producerBatch.logGroup.Logs)),
+			attrBatchSize.Int64(producerBatch.totalDataSize),
+			sls.AttrAttempt.Int(producerBatch.attemptCount+1),
+		))
+	if client, ok := ioWorker.client.(sls.ClientWithContext); ok {
+		return client.WithContext(ctx), span
+	}
+	return ioWorker.client, span
+}
+
+func endSendSpan(span trace.Span, err *sls.Error, willRetry bool) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.SetAttributes(
+			sls.AttrErrorCode.String(err.Code),
+			sls.AttrRequestID.String(err.RequestID),
+			attrWillRetry.Bool(willRetry),
+		)
+		span.SetStatus(codes.Error, err.Message)
+	}
+	span.End()
+}
@@ -0,0 +1,350 @@
+package producer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	walSegmentSuffix          = ".wal"
+	defaultWALSegmentSizeByte = 64 * 1024 * 1024
+	walRecordHeaderSize       = 8 // length and crc32 of payload
+	maxWALRecordSize          = 64 * 1024 * 1024
+)
+
+var errWALClosed = errors.New("producer wal is closed")
+
+// walSegment is a file of the write-ahead log, it is removed once all its records are sent
+type walSegment struct {
+	path    string
+	records int
+	acked   int
+	size    int64
+	sealed  bool // no more records will be appended
+}
+
+func (segment *walSegment) done() bool {
+	return segment.sealed && segment.acked >= segment.records
+}
+
+// walRecord is the logs of a send call, replayed if the producer restarts before they are sent
+type walRecord struct {
+	project   string
+	logstore  string
+	shardHash string
+	topic     string
+	source    string
+	tags      []*sls.LogTag
+	logs      []*sls.Log
+}
+
+// wal appends the logs accepted by the producer to segment files in dir.
+// Segments are never appended to once the producer restarts, the records in them are replayed
+// and the segments are removed after the replayed batches are sent.
+// Acks are not persisted, records of a partially sent segment are sent again after restart.
+// The records of batches failed without being acked, see WALAckOnFailure, keep their segments
+// until the producer restarts.
+type wal struct {
+	dir         string
+	segmentSize int64
+	sync        bool
+	logger      log.Logger
+
+	mu       sync.Mutex
+	active   *walSegment
+	file     *os.File
+	segments map[*walSegment]struct{}
+	nextSeq  int64
+	closed   bool
+}
+
+func openWAL(config *ProducerConfig, logger log.Logger) (*wal, error) {
+	if err := os.MkdirAll(config.WALDir, 0755); err != nil {
+		return nil, err
+	}
+	segmentSize := config.WALSegmentSizeBytes
+	if segmentSize <= 0 {
+		segmentSize = defaultWALSegmentSizeByte
+	}
+	w := &wal{
+		dir:         config.WALDir,
+		segmentSize: segmentSize,
+		sync:        config.WALSync,
+		logger:      logger,
+		segments:    map[*walSegment]struct{}{},
+	}
+	seqs, err := w.listSegments()
+	if err != nil {
+		return nil, err
+	}
+	if len(seqs) > 0 {
+		w.nextSeq = seqs[len(seqs)-1] + 1
+	}
+	return w, nil
+}
+
+// listSegments returns the sequences of existing segments in order
+func (w *wal) listSegments() ([]int64, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, walSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(name, walSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func (w *wal) segmentPath(seq int64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%020d%s", seq, walSegmentSuffix))
+}
+
+// replay reads the records of the segments left by the last run, a corrupted tail of segment is skipped
+func (w *wal) replay(fn func(record *walRecord, segment *walSegment)) error {
+	seqs, err := w.listSegments()
+	if err != nil {
+		return err
+	}
+	for _, seq := range seqs {
+		if seq >= w.nextSeq {
+			break
+		}
+		segment := &walSegment{path: w.segmentPath(seq), sealed: true}
+		records, err := readWALSegment(segment.path)
+		if err != nil {
+			level.Warn(w.logger).Log("msg", "skip the corrupted tail of wal segment", "segment", segment.path, "records", len(records), "error", err)
+		}
+		if len(records) == 0 {
+			os.Remove(segment.path)
+			continue
+		}
+		w.mu.Lock()
+		segment.records = len(records)
+		w.segments[segment] = struct{}{}
+		w.mu.Unlock()
+		level.Info(w.logger).Log("msg", "replay wal segment", "segment", segment.path, "records", len(records))
+		for _, record := range records {
+			fn(record, segment)
+		}
+	}
+	return nil
+}
+
+func readWALSegment(path string) ([]*walRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var records []*walRecord
+	header := make([]byte, walRecordHeaderSize)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return records, err
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size > maxWALRecordSize {
+			return records, fmt.Errorf("invalid record size %d", size)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return records, err
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			return records, errors.New("checksum mismatch")
+		}
+		record, err := decodeWALRecord(payload)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// append writes the record to the active segment, and returns the segment to ack once the logs are sent
+func (w *wal) append(record *walRecord) (*walSegment, error) {
+	payload, err := encodeWALRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	// header and payload are written by one call, a torn tail left by a crash is detected by the crc
+	data := make([]byte, walRecordHeaderSize, walRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(data, uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:], crc32.ChecksumIEEE(payload))
+	data = append(data, payload...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, errWALClosed
+	}
+	if w.active == nil || w.active.size >= w.segmentSize {
+		if err := w.rollLocked(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.writeLocked(data); err != nil {
+		// the active segment may end with a torn record now, the records after it would be skipped by replay,
+		// so they are appended to a new segment
+		if rollErr := w.rollLocked(); rollErr != nil {
+			level.Warn(w.logger).Log("msg", "failed to roll wal segment", "error", rollErr)
+		}
+		return nil, err
+	}
+	segment := w.active
+	segment.records++
+	segment.size += int64(len(data))
+	return segment, nil
+}
+
+func (w *wal) writeLocked(data []byte) error {
+	if _, err := w.file.Write(data); err != nil {
+		return err
+	}
+	if w.sync {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// rollLocked seals the active segment and creates a new one
+func (w *wal) rollLocked() error {
+	if err := w.sealLocked(); err != nil {
+		return err
+	}
+	segment := &walSegment{path: w.segmentPath(w.nextSeq)}
+	file, err := os.OpenFile(segment.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.nextSeq++
+	w.active = segment
+	w.file = file
+	w.segments[segment] = struct{}{}
+	return nil
+}
+
+func (w *wal) sealLocked() error {
+	if w.active == nil {
+		return nil
+	}
+	segment := w.active
+	err := w.file.Close()
+	segment.sealed = true
+	w.active, w.file = nil, nil
+	w.removeIfDoneLocked(segment)
+	return err
+}
+
+// ack marks the records as sent, segments are removed once all records in them are sent
+func (w *wal) ack(segments []*walSegment) {
+	if w == nil || len(segments) == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, segment := range segments {
+		segment.acked++
+		w.removeIfDoneLocked(segment)
+	}
+}
+
+func (w *wal) removeIfDoneLocked(segment *walSegment) {
+	if !segment.done() {
+		return
+	}
+	if _, ok := w.segments[segment]; !ok {
+		return
+	}
+	delete(w.segments, segment)
+	if err := os.Remove(segment.path); err != nil && !os.IsNotExist(err) {
+		level.Warn(w.logger).Log("msg", "failed to remove wal segment", "segment", segment.path, "error", err)
+	}
+}
+
+// close seals the active segment, records not sent yet are replayed by the next producer on the same dir
+func (w *wal) close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.sealLocked()
+}
+
+// encodeWALRecord encodes record as length prefixed strings followed by a log group of the tags and logs
+func encodeWALRecord(record *walRecord) ([]byte, error) {
+	logGroup := &sls.LogGroup{LogTags: record.tags, Logs: record.logs}
+	logs, err := logGroup.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{record.project, record.logstore, record.shardHash, record.topic, record.source}
+	size := len(logs)
+	for _, field := range fields {
+		size += binary.MaxVarintLen64 + len(field)
+	}
+	buf := make([]byte, 0, size)
+	for _, field := range fields {
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+	return append(buf, logs...), nil
+}
+
+func decodeWALRecord(payload []byte) (*walRecord, error) {
+	fields := make([]string, 5)
+	for i := range fields {
+		size, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < size {
+			return nil, errors.New("invalid record")
+		}
+		fields[i] = string(payload[n : n+int(size)])
+		payload = payload[n+int(size):]
+	}
+	logGroup := &sls.LogGroup{}
+	if err := logGroup.Unmarshal(payload); err != nil {
+		return nil, err
+	}
+	return &walRecord{
+		project:   fields[0],
+		logstore:  fields[1],
+		shardHash: fields[2],
+		topic:     fields[3],
+		source:    fields[4],
+		tags:      logGroup.LogTags,
+		logs:      logGroup.Logs,
+	}, nil
+}
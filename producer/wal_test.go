@@ -0,0 +1,187 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/slstest"
+	"github.com/go-kit/kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWALTestConfig(t *testing.T, server *slstest.Server, dir string) *ProducerConfig {
+	config := GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	config.AllowLogLevel = "error"
+	config.WALDir = dir
+	return config
+}
+
+func walSegments(t *testing.T, dir string) []string {
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+walSegmentSuffix))
+	require.NoError(t, err)
+	return segments
+}
+
+func TestWALRecord(t *testing.T) {
+	record := &walRecord{
+		project:   "project",
+		logstore:  "logstore",
+		shardHash: "00",
+		topic:     "topic",
+		source:    "",
+		tags:      []*sls.LogTag{{Key: proto.String("tenant"), Value: proto.String("a")}},
+		logs:      []*sls.Log{GenerateLog(1, map[string]string{"k": "v"})},
+	}
+	payload, err := encodeWALRecord(record)
+	require.NoError(t, err)
+	decoded, err := decodeWALRecord(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "00", decoded.shardHash)
+	assert.Equal(t, "topic", decoded.topic)
+	require.Len(t, decoded.tags, 1)
+	assert.Equal(t, "a", decoded.tags[0].GetValue())
+	require.Len(t, decoded.logs, 1)
+	assert.Equal(t, uint32(1), decoded.logs[0].GetTime())
+	assert.Equal(t, "v", decoded.logs[0].Contents[0].GetValue())
+
+	_, err = decodeWALRecord(payload[:3])
+	assert.Error(t, err)
+}
+
+func TestWALReplay(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "logstore", 1, 1, false, 0))
+	dir := t.TempDir()
+
+	// the producer crashes before sending anything
+	config := newWALTestConfig(t, server, dir)
+	config.WALSegmentSizeBytes = 1
+	crashed, err := NewProducer(config)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		log := GenerateLog(uint32(time.Now().Unix()), map[string]string{"index": fmt.Sprint(i)})
+		require.NoError(t, crashed.SendLog("project", "logstore", "topic", "source", log))
+	}
+	assert.Len(t, walSegments(t, dir), 3)
+
+	// with a corrupted tail, eg. the process is killed while writing
+	segments := walSegments(t, dir)
+	file, err := os.OpenFile(segments[len(segments)-1], os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{0, 0, 1})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	p, err := NewProducer(newWALTestConfig(t, server, dir))
+	require.NoError(t, err)
+	p.Start()
+	require.NoError(t, p.SendLog("project", "logstore", "topic", "source", GenerateLog(uint32(time.Now().Unix()), map[string]string{"index": "3"})))
+	p.SafeClose()
+
+	indexes := map[string]bool{}
+	for _, log := range server.Logs("project", "logstore") {
+		indexes[log.Contents[0].GetValue()] = true
+	}
+	assert.Equal(t, map[string]bool{"0": true, "1": true, "2": true, "3": true}, indexes)
+	assert.Empty(t, walSegments(t, dir))
+}
+
+func TestWALWriteError(t *testing.T) {
+	config := GetDefaultProducerConfig()
+	config.WALDir = t.TempDir()
+	w, err := openWAL(config, log.NewNopLogger())
+	require.NoError(t, err)
+	newRecord := func(index string) *walRecord {
+		return &walRecord{project: "project", logstore: "logstore", logs: []*sls.Log{GenerateLog(1, map[string]string{"index": index})}}
+	}
+	_, err = w.append(newRecord("0"))
+	require.NoError(t, err)
+
+	// a write fails after a part of the record is written
+	_, err = w.file.Write([]byte{0, 0, 1})
+	require.NoError(t, err)
+	require.NoError(t, w.file.Close())
+	_, err = w.append(newRecord("1"))
+	require.Error(t, err)
+	_, err = w.append(newRecord("2"))
+	require.NoError(t, err)
+	require.NoError(t, w.close())
+
+	replayed, err := openWAL(config, log.NewNopLogger())
+	require.NoError(t, err)
+	var indexes []string
+	require.NoError(t, replayed.replay(func(record *walRecord, segment *walSegment) {
+		indexes = append(indexes, record.logs[0].Contents[0].GetValue())
+	}))
+	assert.Equal(t, []string{"0", "2"}, indexes)
+}
+
+func TestWALKeepsUnsentLogs(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+
+	config := newWALTestConfig(t, server, dir)
+	config.Retries = 1
+	config.NoRetryStatusCodeList = nil
+	p, err := NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	// the project does not exist yet, so the logs can not be sent
+	require.NoError(t, p.SendLog("project", "logstore", "topic", "source", GenerateLog(1, map[string]string{"k": "v"})))
+	p.SafeClose()
+	assert.Len(t, walSegments(t, dir), 1)
+
+	w, err := openWAL(config, log.NewNopLogger())
+	require.NoError(t, err)
+	var records []*walRecord
+	require.NoError(t, w.replay(func(record *walRecord, segment *walSegment) {
+		records = append(records, record)
+	}))
+	require.Len(t, records, 1)
+	assert.Equal(t, "v", records[0].logs[0].Contents[0].GetValue())
+}
+
+func TestWALAckOnFailure(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+
+	config := newWALTestConfig(t, server, dir)
+	config.Retries = 1
+	config.NoRetryStatusCodeList = nil
+	config.WALAckOnFailure = true
+	p, err := NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	// the project does not exist, so the logs fail after all retries
+	result, err := p.SendLogFuture(context.Background(), Record{
+		Project:  "project",
+		Logstore: "logstore",
+		Logs:     []*sls.Log{GenerateLog(1, map[string]string{"k": "v"})},
+	})
+	require.NoError(t, err)
+	select {
+	case r := <-result:
+		assert.False(t, r.IsSuccessful())
+	case <-time.After(10 * time.Second):
+		t.Fatal("no result of the failed logs")
+	}
+	p.SafeClose()
+	assert.Empty(t, walSegments(t, dir))
+}
@@ -0,0 +1,126 @@
+// Package remotewrite receives Prometheus remote write requests and forwards the samples to a MetricStore by a producer,
+// so that Prometheus agents can write to a local sidecar, which batches and retries the writes to SLS.
+package remotewrite
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+const defaultMaxRequestBytes = 32 * 1024 * 1024
+
+// Config of Receiver
+type Config struct {
+	// Producer forwards the samples, UseMetricStoreURL must be enabled in its config.
+	// It is not started or closed by Receiver.
+	Producer *producer.Producer
+	// Project and MetricStore are where the samples are written to. If they are empty,
+	// they are taken from the request path /prometheus/{project}/{metricstore}/api/v1/write,
+	// which is the same as the remote write url of SLS.
+	Project     string
+	MetricStore string
+	// MaxRequestBytes is the max size of a compressed request body, default 32MB
+	MaxRequestBytes int64
+	Logger          log.Logger
+}
+
+// Receiver is a http.Handler of Prometheus remote write requests.
+// It responds 204 once the samples are accepted by the producer, 400 if the request is invalid,
+// and 500 if the producer rejects the samples, eg. it times out waiting for memory, so that the agent retries.
+// Native histograms and exemplars are ignored.
+type Receiver struct {
+	config Config
+	logger log.Logger
+}
+
+func NewReceiver(config Config) *Receiver {
+	logger := config.Logger
+	if logger == nil {
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout))
+	}
+	if config.MaxRequestBytes <= 0 {
+		config.MaxRequestBytes = defaultMaxRequestBytes
+	}
+	return &Receiver{config: config, logger: logger}
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	project, metricStore, err := r.destination(req.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	compressed, err := io.ReadAll(http.MaxBytesReader(w, req.Body, r.config.MaxRequestBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, "invalid snappy body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var writeRequest prompb.WriteRequest
+	if err := proto.Unmarshal(body, &writeRequest); err != nil {
+		http.Error(w, "invalid write request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	logs := ConvertTimeSeries(writeRequest.Timeseries)
+	if len(logs) > 0 {
+		if err := r.config.Producer.SendLogList(project, metricStore, "", "", logs); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to forward remote write request", "project", project, "metricStore", metricStore, "samples", len(logs), "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// destination returns the project and metric store of a request
+func (r *Receiver) destination(path string) (string, string, error) {
+	if r.config.Project != "" && r.config.MetricStore != "" {
+		return r.config.Project, r.config.MetricStore, nil
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != 6 || segments[0] != "prometheus" || segments[3] != "api" || segments[4] != "v1" || segments[5] != "write" {
+		return "", "", fmt.Errorf("invalid path %s, want /prometheus/{project}/{metricstore}/api/v1/write", path)
+	}
+	return segments[1], segments[2], nil
+}
+
+// ConvertTimeSeries converts the samples of time series to metric logs, one log for each sample
+func ConvertTimeSeries(series []prompb.TimeSeries) []*sls.Log {
+	var logs []*sls.Log
+	for _, ts := range series {
+		point := sls.MetricPoint{Labels: make(map[string]string, len(ts.Labels))}
+		for _, label := range ts.Labels {
+			if label.Name == sls.MetricNameKey {
+				point.Name = label.Value
+				continue
+			}
+			point.Labels[label.Name] = label.Value
+		}
+		for _, sample := range ts.Samples {
+			point.Timestamp = time.UnixMilli(sample.Timestamp)
+			point.Value = sample.Value
+			logs = append(logs, point.ToLog())
+		}
+	}
+	return logs
+}
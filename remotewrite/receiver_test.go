@@ -0,0 +1,78 @@
+package remotewrite
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+	"github.com/aliyun/aliyun-log-go-sdk/slstest"
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiver(t *testing.T) {
+	server := slstest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "metrics", 1, 1, false, 0))
+
+	config := producer.GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.UseMetricStoreURL = true
+	config.LingerMs = 100
+	p, err := producer.NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	receiver := httptest.NewServer(NewReceiver(Config{Producer: p}))
+	defer receiver.Close()
+
+	body, err := proto.Marshal(&prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+		Labels: []prompb.Label{
+			{Name: "job", Value: "node"},
+			{Name: "__name__", Value: "up"},
+			{Name: "instance", Value: "host:9100"},
+		},
+		Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000123}, {Value: 0.5, Timestamp: 1700000001000}},
+	}}})
+	require.NoError(t, err)
+	resp, err := http.Post(receiver.URL+"/prometheus/project/metrics/api/v1/write", "application/x-protobuf", bytes.NewReader(snappy.Encode(nil, body)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Post(receiver.URL+"/prometheus/project/metrics/api/v1/write", "application/x-protobuf", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Post(receiver.URL+"/api/v1/write", "application/x-protobuf", bytes.NewReader(snappy.Encode(nil, body)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	p.SafeClose()
+
+	logs := server.Logs("project", "metrics")
+	require.Len(t, logs, 2)
+	contents := map[string]string{}
+	for _, content := range logs[0].Contents {
+		contents[content.GetKey()] = content.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		sls.MetricNameKey:     "up",
+		sls.MetricLabelsKey:   "instance#$#host:9100|job#$#node",
+		sls.MetricTimeNanoKey: "1700000000123000000",
+		sls.MetricValueKey:    "1",
+	}, contents)
+	assert.Equal(t, uint32(1700000000), logs[0].GetTime())
+	assert.Equal(t, "0.5", logs[1].Contents[3].GetValue())
+}
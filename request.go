@@ -99,50 +99,49 @@ func request(project *LogProject, method, uri string, headers map[string]string,
 	var mockErr *mockErrorRetry
 
 	project.init()
-	ctx, cancel := context.WithTimeout(context.Background(), project.retryTimeout)
+	ctx, cancel := context.WithTimeout(project.Context(), project.retryTimeout)
 	defer cancel()
+	ctx, tracing := startAPISpan(ctx, project.tracerProvider, project.Name, method, uri)
 
-	//fmt.Println("request ", project, method, uri, headers, body)
 	// all GET method is read function
-	if method == http.MethodGet {
-		err = RetryWithCondition(ctx, backoff.NewExponentialBackOff(), func() (bool, error) {
-			if len(mock) == 0 {
-				//fmt.Println("real request", project, method, uri, headers, body)
-				r, slsErr = realRequest(ctx, project, method, uri, headers, body)
-				//fmt.Println("real request done")
-			} else {
-				r, mockErr = nil, mock[0].(*mockErrorRetry)
-				mockErr.RetryCnt--
-				if mockErr.RetryCnt <= 0 {
-					r = &http.Response{}
-					slsErr = nil
-					return false, nil
-				}
-				slsErr = &mockErr.Err
-			}
-			return retryReadErrorCheck(ctx, slsErr)
-		})
+	isRead := method == http.MethodGet
+	operation := func() error {
+		if len(mock) == 0 {
+			attemptCtx, attempt := tracing.startAttempt(ctx)
+			r, slsErr = realRequest(attemptCtx, project, method, uri, headers, body)
+			tracing.endAttempt(attempt, r, slsErr)
+			return slsErr
+		}
+		r, mockErr = nil, mock[0].(*mockErrorRetry)
+		mockErr.RetryCnt--
+		if mockErr.RetryCnt <= 0 {
+			r = &http.Response{}
+			slsErr = nil
+			return nil
+		}
+		slsErr = &mockErr.Err
+		return slsErr
+	}
+
+	if project.retryPolicy != nil {
+		err = project.retryPolicy.retry(ctx, isRead, operation)
 	} else {
 		err = RetryWithCondition(ctx, backoff.NewExponentialBackOff(), func() (bool, error) {
-			if len(mock) == 0 {
-				r, slsErr = realRequest(ctx, project, method, uri, headers, body)
-			} else {
-				r, mockErr = nil, mock[0].(*mockErrorRetry)
-				mockErr.RetryCnt--
-				if mockErr.RetryCnt <= 0 {
-					r = &http.Response{}
-					slsErr = nil
-					return false, nil
-				}
-				slsErr = &mockErr.Err
+			if e := operation(); e == nil {
+				return false, nil
+			}
+			if isRead {
+				return retryReadErrorCheck(ctx, slsErr)
 			}
 			return retryWriteErrorCheck(ctx, slsErr)
 		})
 	}
 
 	if err != nil {
+		tracing.end(r, err)
 		return r, err
 	}
+	tracing.end(r, slsErr)
 	return r, slsErr
 }
 
@@ -215,47 +214,51 @@ func realRequest(ctx context.Context, project *LogProject, method, uri string, h
 
 	// Handle the endpoint
 	urlStr := fmt.Sprintf("%s%s", baseURL, uri)
-	req, err := http.NewRequest(method, urlStr, reader)
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, reader)
 	if err != nil {
 		return nil, NewClientError(err)
 	}
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
-	if IsDebugLevelMatched(5) {
-		dump, e := httputil.DumpRequest(req, true)
-		if e != nil {
-			level.Info(Logger).Log("msg", e)
+	handler := func(req *http.Request) (*http.Response, error) {
+		if IsDebugLevelMatched(5) {
+			dump, e := httputil.DumpRequest(req, true)
+			if e != nil {
+				level.Info(Logger).Log("msg", e)
+			}
+			level.Info(Logger).Log("msg", "HTTP Request:\n%v", string(dump))
 		}
-		level.Info(Logger).Log("msg", "HTTP Request:\n%v", string(dump))
-	}
-	// Get ready to do request
-	resp, err := project.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the sls error from body.
-	if resp.StatusCode != http.StatusOK {
-		err := &Error{}
-		err.HTTPCode = (int32)(resp.StatusCode)
-		defer resp.Body.Close()
-		buf, ioErr := ioutil.ReadAll(resp.Body)
-		if ioErr != nil {
-			return nil, NewBadResponseError(ioErr.Error(), resp.Header, resp.StatusCode)
+		// Get ready to do request
+		resp, err := project.httpClient.Do(req)
+		if err != nil {
+			return nil, err
 		}
-		if jErr := json.Unmarshal(buf, err); jErr != nil {
-			return nil, NewBadResponseError(string(buf), resp.Header, resp.StatusCode)
+
+		// Parse the sls error from body.
+		if resp.StatusCode != http.StatusOK {
+			err := &Error{}
+			err.HTTPCode = (int32)(resp.StatusCode)
+			defer resp.Body.Close()
+			buf, ioErr := ioutil.ReadAll(resp.Body)
+			if ioErr != nil {
+				return nil, NewBadResponseError(ioErr.Error(), resp.Header, resp.StatusCode)
+			}
+			if jErr := json.Unmarshal(buf, err); jErr != nil {
+				return nil, NewBadResponseError(string(buf), resp.Header, resp.StatusCode)
+			}
+			err.RequestID = resp.Header.Get(RequestIDHeader)
+			err.retryAfter = parseRetryAfter(resp.Header.Get(RetryAfterHeader))
+			return nil, err
 		}
-		err.RequestID = resp.Header.Get(RequestIDHeader)
-		return nil, err
-	}
-	if IsDebugLevelMatched(5) {
-		dump, e := httputil.DumpResponse(resp, true)
-		if e != nil {
-			level.Info(Logger).Log("msg", e)
+		if IsDebugLevelMatched(5) {
+			dump, e := httputil.DumpResponse(resp, true)
+			if e != nil {
+				level.Info(Logger).Log("msg", e)
+			}
+			level.Info(Logger).Log("msg", "HTTP Response:\n%v", string(dump))
 		}
-		level.Info(Logger).Log("msg", "HTTP Response:\n%v", string(dump))
+		return resp, nil
 	}
-	return resp, nil
+	return chainInterceptors(project.interceptors, handler)(req)
 }
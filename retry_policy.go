@@ -0,0 +1,214 @@
+package sls
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// RetryCondition defines which errors are retryable for a class of requests.
+type RetryCondition struct {
+	// HTTPCodes are retryable http status codes, eg. 500, 502, 503
+	HTTPCodes []int
+	// ErrorCodes are retryable sls error codes, eg. "WriteQuotaExceed", "ServerBusy"
+	ErrorCodes []string
+	// NetworkError retries requests failed without response, eg. connection reset, dial timeout
+	NetworkError bool
+}
+
+// RetryPolicy controls how a client retries failed requests, it takes precedence over
+// the package level RetryOnServerErrorEnabled, MaxCompletedRetryCount and MaxCompletedRetryLatency.
+// All retries of a request are bounded by the client's retry timeout.
+//
+// A RetryPolicy must not be modified after it is set to a client.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts of a request, including the first one.
+	// 0 means no limit.
+	MaxAttempts int
+	// InitialInterval is the interval before the first retry, default is 500ms
+	InitialInterval time.Duration
+	// MaxInterval caps the interval between two retries, default is 60s
+	MaxInterval time.Duration
+	// Multiplier is the factor the interval grows by after each retry, default is 1.5
+	Multiplier float64
+	// Jitter randomizes each interval in [interval*(1-Jitter), interval*(1+Jitter)],
+	// 0 means no jitter.
+	Jitter float64
+	// HonorRetryAfter waits as long as the Retry-After header of the error response suggests,
+	// instead of the backoff interval.
+	HonorRetryAfter bool
+
+	// Read defines retryable errors of read requests (GET)
+	Read RetryCondition
+	// Write defines retryable errors of write requests (POST, PUT, DELETE)
+	Write RetryCondition
+
+	// CompletedRetryCount is the max number of queries of the GetXXXToCompleted functions,
+	// 0 means MaxCompletedRetryCount.
+	CompletedRetryCount int
+	// CompletedRetryLatency is the max latency of the GetXXXToCompleted functions,
+	// 0 means MaxCompletedRetryLatency.
+	CompletedRetryLatency time.Duration
+}
+
+// DefaultRetryPolicy returns the RetryPolicy of a client without retry policy when RetryOnServerErrorEnabled is true.
+// It always retries server errors and network errors, regardless of RetryOnServerErrorEnabled.
+func DefaultRetryPolicy() *RetryPolicy {
+	readCodes := make([]int, 0, 100)
+	for code := 500; code <= 599; code++ {
+		readCodes = append(readCodes, code)
+	}
+	return &RetryPolicy{
+		InitialInterval: backoff.DefaultInitialInterval,
+		MaxInterval:     backoff.DefaultMaxInterval,
+		Multiplier:      backoff.DefaultMultiplier,
+		Jitter:          backoff.DefaultRandomizationFactor,
+		Read: RetryCondition{
+			HTTPCodes:    readCodes,
+			NetworkError: true,
+		},
+		Write: RetryCondition{
+			HTTPCodes: []int{500, 502, 503},
+		},
+	}
+}
+
+// NoRetryPolicy returns a RetryPolicy that never retries.
+func NoRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: 1}
+}
+
+func (p *RetryPolicy) newBackOff() *backoff.ExponentialBackOff {
+	b := backoff.NewExponentialBackOff()
+	if p.InitialInterval > 0 {
+		b.InitialInterval = p.InitialInterval
+	}
+	if p.MaxInterval > 0 {
+		b.MaxInterval = p.MaxInterval
+	}
+	if p.Multiplier >= 1 {
+		b.Multiplier = p.Multiplier
+	}
+	b.RandomizationFactor = p.Jitter
+	// retries are bounded by context
+	b.MaxElapsedTime = 0
+	b.Reset()
+	return b
+}
+
+func (p *RetryPolicy) isRetryable(isRead bool, err error) bool {
+	cond := &p.Write
+	if isRead {
+		cond = &p.Read
+	}
+	switch e := err.(type) {
+	case *url.Error:
+		return cond.NetworkError
+	case *Error:
+		return containsInt(cond.HTTPCodes, int(e.HTTPCode)) || containsString(cond.ErrorCodes, e.Code)
+	case *BadResponseError:
+		return containsInt(cond.HTTPCodes, e.HTTPCode)
+	}
+	return false
+}
+
+// retry calls o until it returns no error, or the error is not retryable,
+// or max attempts is reached, or ctx is done.
+func (p *RetryPolicy) retry(ctx context.Context, isRead bool, o func() error) error {
+	b := p.newBackOff()
+	var err error
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "stopped retrying err: %v", err)
+		default:
+		}
+		err = o()
+		if err == nil || !p.isRetryable(isRead, err) {
+			return err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return err
+		}
+		wait := b.NextBackOff()
+		if p.HonorRetryAfter {
+			if retryAfter := retryAfterOf(err); retryAfter > 0 {
+				wait = retryAfter
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrapf(ctx.Err(), "stopped retrying err: %v", err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *RetryPolicy) completedRetryCount() int {
+	if p != nil && p.CompletedRetryCount > 0 {
+		return p.CompletedRetryCount
+	}
+	return MaxCompletedRetryCount
+}
+
+func (p *RetryPolicy) completedRetryLatency() time.Duration {
+	if p != nil && p.CompletedRetryLatency > 0 {
+		return p.CompletedRetryLatency
+	}
+	return MaxCompletedRetryLatency
+}
+
+// retryAfterOf returns the retry interval the server suggests in an error response
+func retryAfterOf(err error) time.Duration {
+	switch e := err.(type) {
+	case *Error:
+		return e.retryAfter
+	case *BadResponseError:
+		return parseRetryAfter(http.Header(e.RespHeader).Get(RetryAfterHeader))
+	}
+	return 0
+}
+
+// parseRetryAfter parses Retry-After header, in delay seconds or http date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
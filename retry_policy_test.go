@@ -0,0 +1,112 @@
+package sls
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryTestServer(statusCode int, errorCode string, header map[string]string) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(statusCode)
+		w.Write([]byte(`{"errorCode":"` + errorCode + `","errorMessage":"test error"}`))
+	}))
+	return server, &count
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	server, count := newRetryTestServer(http.StatusServiceUnavailable, "ServerBusy", nil)
+	defer server.Close()
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 3
+	policy.InitialInterval = time.Millisecond
+	client.SetRetryPolicy(policy)
+
+	_, err := client.GetLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+	slsErr, ok := err.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, "ServerBusy", slsErr.Code)
+}
+
+func TestRetryPolicyErrorCodes(t *testing.T) {
+	server, count := newRetryTestServer(http.StatusForbidden, "WriteQuotaExceed", nil)
+	defer server.Close()
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+
+	// 403 is not retried by default
+	client.SetRetryPolicy(DefaultRetryPolicy())
+	err := client.DeleteLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 4
+	policy.InitialInterval = time.Millisecond
+	policy.Write.ErrorCodes = []string{"WriteQuotaExceed"}
+	client.SetRetryPolicy(policy)
+	err = client.DeleteLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, int32(5), atomic.LoadInt32(count))
+
+	// read condition is independent of write condition
+	_, err = client.GetLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, int32(6), atomic.LoadInt32(count))
+}
+
+func TestRetryPolicyHonorRetryAfter(t *testing.T) {
+	server, count := newRetryTestServer(http.StatusServiceUnavailable, "ServerBusy", map[string]string{RetryAfterHeader: "1"})
+	defer server.Close()
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 2
+	policy.InitialInterval = time.Millisecond
+	policy.HonorRetryAfter = true
+	client.SetRetryPolicy(policy)
+
+	start := time.Now()
+	_, err := client.GetLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(count))
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestNoRetryPolicy(t *testing.T) {
+	server, count := newRetryTestServer(http.StatusInternalServerError, "InternalServerError", nil)
+	defer server.Close()
+
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	client.SetRetryPolicy(NoRetryPolicy())
+	_, err := client.GetLogStore("test-project", "test-logstore")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1"))
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.Greater(t, d, 50*time.Second)
+	assert.Equal(t, time.Duration(0), parseRetryAfter("invalid"))
+}
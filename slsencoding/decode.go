@@ -0,0 +1,316 @@
+package slsencoding
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+// Decoder converts logs to structs and maps, the zero value is ready to use.
+// Contents are matched to fields by the same rules as Encoder, keys without matching field are ignored.
+type Decoder struct {
+	// Separator joins the keys of nested structs and maps, default "."
+	Separator string
+	// TimeKey is the key the time of log is stored to when decoding to a map, as unix seconds.
+	// The time is not stored to maps if it is empty.
+	TimeKey string
+}
+
+var defaultDecoder = &Decoder{}
+
+// Unmarshal converts log to v by the default Decoder, see Decoder.Unmarshal
+func Unmarshal(log *sls.Log, v interface{}) error {
+	return defaultDecoder.Unmarshal(log, v)
+}
+
+// UnmarshalLogGroupList converts all logs in list by the default Decoder, see Decoder.UnmarshalLogGroupList
+func UnmarshalLogGroupList(list *sls.LogGroupList, v interface{}) error {
+	return defaultDecoder.UnmarshalLogGroupList(list, v)
+}
+
+// Unmarshal converts log to v, which is a pointer to a struct, map[string]string or map[string]interface{}.
+func (d *Decoder) Unmarshal(log *sls.Log, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("slsencoding: unmarshal to non-pointer or nil %T", v)
+	}
+	return d.unmarshal(log, rv.Elem())
+}
+
+// UnmarshalLogGroupList appends the logs in list to v, which is a pointer to a slice of
+// structs or maps, or pointers to them.
+func (d *Decoder) UnmarshalLogGroupList(list *sls.LogGroupList, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("slsencoding: unmarshal log group list to %T, want pointer to slice", v)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	for _, logGroup := range list.GetLogGroups() {
+		for _, log := range logGroup.GetLogs() {
+			elem := reflect.New(elemType).Elem()
+			target := elem
+			if elemType.Kind() == reflect.Ptr {
+				elem.Set(reflect.New(elemType.Elem()))
+				target = elem.Elem()
+			}
+			if err := d.unmarshal(log, target); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
+
+func (d *Decoder) unmarshal(log *sls.Log, rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Struct:
+		state := &decodeState{
+			decoder:  d,
+			contents: make(map[string]string, len(log.GetContents())),
+			time:     logTime(log),
+		}
+		for _, content := range log.GetContents() {
+			state.contents[content.GetKey()] = content.GetValue()
+		}
+		return state.decodeStruct(rv, "")
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		elemType := rv.Type().Elem()
+		if elemType.Kind() != reflect.String && elemType.Kind() != reflect.Interface {
+			break
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(log.GetContents())+1))
+		}
+		set := func(key, value string) {
+			rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), reflect.ValueOf(value).Convert(elemType))
+		}
+		for _, content := range log.GetContents() {
+			set(content.GetKey(), content.GetValue())
+		}
+		if d.TimeKey != "" {
+			set(d.TimeKey, strconv.FormatUint(uint64(log.GetTime()), 10))
+		}
+		return nil
+	}
+	return fmt.Errorf("slsencoding: unsupported type %s", rv.Type())
+}
+
+func logTime(log *sls.Log) time.Time {
+	return time.Unix(int64(log.GetTime()), int64(log.GetTimeNs()))
+}
+
+func (d *Decoder) separator() string {
+	if d.Separator == "" {
+		return defaultSeparator
+	}
+	return d.Separator
+}
+
+type decodeState struct {
+	decoder  *Decoder
+	contents map[string]string
+	time     time.Time
+}
+
+func (s *decodeState) join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + s.decoder.separator() + key
+}
+
+func (s *decodeState) decodeStruct(rv reflect.Value, prefix string) error {
+	for _, f := range cachedFields(rv.Type()) {
+		if f.time && prefix == "" {
+			fv, ok := fieldByIndexAlloc(rv, f.index)
+			if !ok {
+				continue
+			}
+			if err := setTime(fv, s.time); err != nil {
+				return fmt.Errorf("slsencoding: time field %s: %w", f.name, err)
+			}
+			continue
+		}
+		key := s.join(prefix, f.name)
+		if !s.hasKey(key) {
+			continue
+		}
+		fv, ok := fieldByIndexAlloc(rv, f.index)
+		if !ok {
+			continue
+		}
+		if err := s.decodeValue(fv, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasKey returns whether there is a content of key, or of a key nested in it
+func (s *decodeState) hasKey(key string) bool {
+	if _, ok := s.contents[key]; ok {
+		return true
+	}
+	prefix := key + s.decoder.separator()
+	for k := range s.contents {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *decodeState) decodeValue(v reflect.Value, key string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return s.decodeValue(v.Elem(), key)
+	}
+	value, ok := s.contents[key]
+	if ok {
+		handled, err := parseScalar(v, value)
+		if err != nil {
+			return fmt.Errorf("slsencoding: field %s: %w", key, err)
+		}
+		if handled {
+			return nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return s.decodeStruct(v, key)
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String && !ok {
+			return s.decodeMap(v, key)
+		}
+	}
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v.Addr().Interface()); err != nil {
+		return fmt.Errorf("slsencoding: field %s: %w", key, err)
+	}
+	return nil
+}
+
+// decodeMap collects the contents nested in key, the values of map should be scalar
+func (s *decodeState) decodeMap(v reflect.Value, key string) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	prefix := key + s.decoder.separator()
+	for k, value := range s.contents {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if elem.Kind() == reflect.Interface {
+			elem.Set(reflect.ValueOf(value))
+		} else if handled, err := parseScalar(elem, value); err != nil || !handled {
+			if err == nil {
+				err = fmt.Errorf("unsupported map value type %s", elem.Type())
+			}
+			return fmt.Errorf("slsencoding: field %s: %w", k, err)
+		}
+		v.SetMapIndex(reflect.ValueOf(strings.TrimPrefix(k, prefix)).Convert(v.Type().Key()), elem)
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// parseScalar parses value to v, false if v is not a scalar type
+func parseScalar(v reflect.Value, value string) (bool, error) {
+	switch v.Type() {
+	case timeType:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return true, err
+		}
+		v.Set(reflect.ValueOf(t))
+		return true, nil
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return true, err
+		}
+		v.SetInt(int64(d))
+		return true, nil
+	}
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		return true, v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return true, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return true, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return true, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return true, err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return false, nil
+		}
+		v.SetBytes([]byte(value))
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// setTime sets a time field, which is a time.Time, or an integer of unix seconds
+func setTime(v reflect.Value, t time.Time) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(t.Unix())
+		return nil
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(t.Unix()))
+		return nil
+	}
+	return errors.New("unsupported time type " + v.Type().String())
+}
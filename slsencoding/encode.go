@@ -0,0 +1,343 @@
+// Package slsencoding converts Go values to and from sls.Log.
+//
+// Structs are mapped by the `sls` tag of fields, eg.
+//
+//	type AccessLog struct {
+//		Time    time.Time     `sls:"time,time"` // used as the time of log, not added to contents
+//		Method  string        `sls:"method"`
+//		Status  int           `sls:"status,omitempty"`
+//		Latency time.Duration `sls:"latency"`
+//		Client  struct {
+//			IP string `sls:"ip"`
+//		} `sls:"client"` // flattened to client.ip
+//		Secret string `sls:"-"` // skipped
+//	}
+//
+// Fields without tag use the field name as key, unexported fields are skipped.
+// Nested structs and maps are flattened with Encoder.Separator, fields of embedded structs
+// are promoted without prefix. Slices, arrays and other values are encoded as json.
+package slsencoding
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/gogo/protobuf/proto"
+)
+
+const defaultSeparator = "."
+
+// Encoder converts structs, maps and json objects to logs, the zero value is ready to use.
+type Encoder struct {
+	// Separator joins the keys of nested structs and maps, default "."
+	Separator string
+	// TimeKey is the key of maps and json objects whose value is used as the time of log,
+	// it can be unix seconds or a RFC3339 string, and it is not added to contents.
+	// Fields of structs are selected by the "time" tag option instead.
+	TimeKey string
+	// Now returns the time of logs without a time field, default time.Now
+	Now func() time.Time
+}
+
+var defaultEncoder = &Encoder{}
+
+// Marshal converts v to a log by the default Encoder, see Encoder.Marshal
+func Marshal(v interface{}) (*sls.Log, error) {
+	return defaultEncoder.Marshal(v)
+}
+
+// MarshalJSONObject converts a json object to a log by the default Encoder, see Encoder.MarshalJSONObject
+func MarshalJSONObject(data []byte) (*sls.Log, error) {
+	return defaultEncoder.MarshalJSONObject(data)
+}
+
+// Marshal converts v to a log, v can be a struct, a map with string keys, a json object in []byte
+// or json.RawMessage, or a pointer to them.
+func (e *Encoder) Marshal(v interface{}) (*sls.Log, error) {
+	switch v := v.(type) {
+	case *sls.Log:
+		return v, nil
+	case []byte:
+		return e.MarshalJSONObject(v)
+	case json.RawMessage:
+		return e.MarshalJSONObject(v)
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("slsencoding: marshal nil value")
+		}
+		rv = rv.Elem()
+	}
+	state := &encodeState{encoder: e}
+	switch rv.Kind() {
+	case reflect.Struct:
+		if err := state.encodeStruct(rv, ""); err != nil {
+			return nil, err
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("slsencoding: unsupported map key type %s", rv.Type().Key())
+		}
+		if err := state.encodeMap(rv, "", true); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("slsencoding: unsupported type %s", rv.Type())
+	}
+	return state.log(), nil
+}
+
+// MarshalJSONObject converts a json object, eg. a line of a json lines file, to a log.
+// Nested objects are flattened, arrays are kept as json, and numbers are kept as they are written.
+func (e *Encoder) MarshalJSONObject(data []byte) (*sls.Log, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("slsencoding: invalid json object: %w", err)
+	}
+	if object == nil {
+		return nil, errors.New("slsencoding: json is not an object")
+	}
+	return e.Marshal(object)
+}
+
+func (e *Encoder) separator() string {
+	if e.Separator == "" {
+		return defaultSeparator
+	}
+	return e.Separator
+}
+
+func (e *Encoder) now() time.Time {
+	if e.Now == nil {
+		return time.Now()
+	}
+	return e.Now()
+}
+
+type encodeState struct {
+	encoder  *Encoder
+	contents []*sls.LogContent
+	time     time.Time
+	hasTime  bool
+}
+
+func (s *encodeState) log() *sls.Log {
+	t := s.time
+	if !s.hasTime {
+		t = s.encoder.now()
+	}
+	log := &sls.Log{
+		Time:     proto.Uint32(uint32(t.Unix())),
+		Contents: s.contents,
+	}
+	if nano := t.Nanosecond(); nano != 0 {
+		log.TimeNs = proto.Uint32(uint32(nano))
+	}
+	return log
+}
+
+func (s *encodeState) add(key, value string) {
+	s.contents = append(s.contents, &sls.LogContent{Key: proto.String(key), Value: proto.String(value)})
+}
+
+func (s *encodeState) join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + s.encoder.separator() + key
+}
+
+func (s *encodeState) encodeStruct(rv reflect.Value, prefix string) error {
+	for _, f := range cachedFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		if f.time {
+			if prefix != "" {
+				continue // only the time field of the top level struct is used
+			}
+			t, err := timeOf(fv)
+			if err != nil {
+				return fmt.Errorf("slsencoding: time field %s: %w", f.name, err)
+			}
+			s.time, s.hasTime = t, true
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if err := s.encodeValue(fv, s.join(prefix, f.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *encodeState) encodeMap(rv reflect.Value, prefix string, top bool) error {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		name := key.String()
+		fv := rv.MapIndex(key)
+		if top && s.encoder.TimeKey != "" && name == s.encoder.TimeKey {
+			t, err := parseTime(fv)
+			if err != nil {
+				return fmt.Errorf("slsencoding: time key %s: %w", name, err)
+			}
+			s.time, s.hasTime = t, true
+			continue
+		}
+		if err := s.encodeValue(fv, s.join(prefix, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *encodeState) encodeValue(v reflect.Value, key string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if value, ok, err := formatScalar(v); ok || err != nil {
+		if err != nil {
+			return fmt.Errorf("slsencoding: field %s: %w", key, err)
+		}
+		s.add(key, value)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return s.encodeStruct(v, key)
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return s.encodeMap(v, key, false)
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Errorf("slsencoding: field %s: %w", key, err)
+	}
+	s.add(key, string(data))
+	return nil
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// formatScalar formats a value that is a single content, false if v is a struct, map, slice or other composite value
+func formatScalar(v reflect.Value) (string, bool, error) {
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true, nil
+	case durationType:
+		return v.Interface().(time.Duration).String(), true, nil
+	case jsonNumberType:
+		return v.String(), true, nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true, nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// timeOf returns the time of a time field, which is a time.Time, or an integer of unix seconds
+func timeOf(v reflect.Value) (time.Time, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return time.Time{}, errors.New("nil time")
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time), nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return parseTime(v)
+	}
+	return time.Time{}, fmt.Errorf("unsupported time type %s", v.Type())
+}
+
+// parseTime parses unix seconds or a RFC3339 string as time
+func parseTime(v reflect.Value) (time.Time, error) {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Unix(v.Int(), 0), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return time.Unix(int64(v.Uint()), 0), nil
+	case reflect.Float32, reflect.Float64:
+		sec := v.Float()
+		return time.Unix(int64(sec), int64((sec-float64(int64(sec)))*1e9)), nil
+	case reflect.String:
+		str := v.String()
+		if sec, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return time.Unix(sec, 0), nil
+		}
+		if sec, err := strconv.ParseFloat(str, 64); err == nil {
+			return time.Unix(int64(sec), int64((sec-float64(int64(sec)))*1e9)), nil
+		}
+		return time.Parse(time.RFC3339Nano, str)
+	}
+	return time.Time{}, fmt.Errorf("unsupported time type %s", v.Type())
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package slsencoding
+
+import (
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	Host string `sls:"host"`
+}
+
+type testClient struct {
+	IP   string `sls:"ip"`
+	Port uint16 `sls:"port"`
+}
+
+type testAccessLog struct {
+	testBase
+	Time     time.Time         `sls:"time,time"`
+	Method   string            `sls:"method"`
+	Status   int               `sls:"status,omitempty"`
+	Latency  time.Duration     `sls:"latency"`
+	Ratio    float64           `sls:"ratio"`
+	OK       bool              `sls:"ok"`
+	Client   testClient        `sls:"client"`
+	Peer     *testClient       `sls:"peer"`
+	Labels   map[string]string `sls:"labels"`
+	Paths    []string          `sls:"paths"`
+	Secret   string            `sls:"-"`
+	Untagged string
+	private  string
+}
+
+func contentsOf(log *sls.Log) map[string]string {
+	contents := map[string]string{}
+	for _, content := range log.Contents {
+		contents[content.GetKey()] = content.GetValue()
+	}
+	return contents
+}
+
+func TestMarshalStruct(t *testing.T) {
+	ts := time.Unix(1700000000, 123)
+	log, err := Marshal(&testAccessLog{
+		testBase: testBase{Host: "h1"},
+		Time:     ts,
+		Method:   "GET",
+		Latency:  1500 * time.Millisecond,
+		Ratio:    0.5,
+		OK:       true,
+		Client:   testClient{IP: "10.0.0.1", Port: 80},
+		Labels:   map[string]string{"env": "prod"},
+		Paths:    []string{"/a", "/b"},
+		Secret:   "s",
+		Untagged: "u",
+		private:  "p",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1700000000), log.GetTime())
+	assert.Equal(t, uint32(123), log.GetTimeNs())
+	assert.Equal(t, map[string]string{
+		"host":        "h1",
+		"method":      "GET",
+		"latency":     "1.5s",
+		"ratio":       "0.5",
+		"ok":          "true",
+		"client.ip":   "10.0.0.1",
+		"client.port": "80",
+		"labels.env":  "prod",
+		"paths":       `["/a","/b"]`,
+		"Untagged":    "u",
+	}, contentsOf(log))
+
+	var decoded testAccessLog
+	require.NoError(t, Unmarshal(log, &decoded))
+	assert.True(t, ts.Equal(decoded.Time))
+	assert.Equal(t, "h1", decoded.Host)
+	assert.Equal(t, "GET", decoded.Method)
+	assert.Equal(t, 1500*time.Millisecond, decoded.Latency)
+	assert.Equal(t, 0.5, decoded.Ratio)
+	assert.True(t, decoded.OK)
+	assert.Equal(t, testClient{IP: "10.0.0.1", Port: 80}, decoded.Client)
+	assert.Nil(t, decoded.Peer)
+	assert.Equal(t, map[string]string{"env": "prod"}, decoded.Labels)
+	assert.Equal(t, []string{"/a", "/b"}, decoded.Paths)
+	assert.Equal(t, "u", decoded.Untagged)
+	assert.Empty(t, decoded.Secret)
+}
+
+func TestMarshalMapAndJSON(t *testing.T) {
+	encoder := &Encoder{Separator: "_", TimeKey: "ts"}
+	log, err := encoder.Marshal(map[string]interface{}{
+		"ts":   int64(1700000000),
+		"msg":  "hello",
+		"user": map[string]interface{}{"id": 1, "tags": []string{"a"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1700000000), log.GetTime())
+	assert.Equal(t, map[string]string{"msg": "hello", "user_id": "1", "user_tags": `["a"]`}, contentsOf(log))
+
+	log, err = encoder.MarshalJSONObject([]byte(`{"ts":"2023-11-14T22:13:20Z","n":12345678901234567890,"a":{"b":[1,2]},"c":null}`))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1700000000), log.GetTime())
+	assert.Equal(t, map[string]string{"n": "12345678901234567890", "a_b": "[1,2]"}, contentsOf(log))
+
+	_, err = MarshalJSONObject([]byte(`[1]`))
+	assert.Error(t, err)
+	_, err = Marshal(1)
+	assert.Error(t, err)
+}
+
+func TestUnmarshalLogGroupList(t *testing.T) {
+	first, err := Marshal(testClient{IP: "a", Port: 1})
+	require.NoError(t, err)
+	second, err := Marshal(map[string]string{"ip": "b", "port": "2"})
+	require.NoError(t, err)
+	list := &sls.LogGroupList{LogGroups: []*sls.LogGroup{{Logs: []*sls.Log{first}}, {Logs: []*sls.Log{second}}}}
+
+	var clients []*testClient
+	require.NoError(t, UnmarshalLogGroupList(list, &clients))
+	assert.Equal(t, []*testClient{{IP: "a", Port: 1}, {IP: "b", Port: 2}}, clients)
+
+	var maps []map[string]string
+	decoder := &Decoder{TimeKey: "__time__"}
+	require.NoError(t, decoder.UnmarshalLogGroupList(list, &maps))
+	require.Len(t, maps, 2)
+	assert.Equal(t, "b", maps[1]["ip"])
+	assert.NotEmpty(t, maps[1]["__time__"])
+
+	bad, err := Marshal(map[string]string{"port": "x"})
+	require.NoError(t, err)
+	var client testClient
+	assert.Error(t, Unmarshal(bad, &client))
+	assert.Error(t, Unmarshal(bad, client))
+}
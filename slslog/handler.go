@@ -0,0 +1,168 @@
+//go:build go1.21
+
+package slslog
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/gogo/protobuf/proto"
+)
+
+// HandlerOptions are the options of Handler, the zero value is ready to use
+type HandlerOptions struct {
+	// Level is the minimum level to send, default slog.LevelInfo
+	Level slog.Leveler
+	// AddSource adds the file:line of the log call as "source", and the function as "source.function"
+	AddSource bool
+}
+
+// Handler is a slog.Handler which sends records to a logstore.
+// The message, level and source of a record are the "msg", "level" and "source" contents,
+// attrs are contents keyed by their names, joined with the open groups by ".",
+// and the time of record is the time of log in nanosecond precision.
+type Handler struct {
+	sender   *sender
+	opts     HandlerOptions
+	contents []*sls.LogContent // from WithAttrs
+	prefix   string            // from WithGroup
+}
+
+// NewHandler starts a Handler, Close it to flush the queued logs to the producer
+func NewHandler(config Config, opts *HandlerOptions) *Handler {
+	h := &Handler{sender: newSender(config)}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	contents := make([]*sls.LogContent, 0, len(h.contents)+r.NumAttrs()+4)
+	contents = appendContent(contents, slog.MessageKey, r.Message)
+	contents = appendContent(contents, slog.LevelKey, r.Level.String())
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		contents = appendContent(contents, slog.SourceKey, frame.File+":"+strconv.Itoa(frame.Line))
+		contents = appendContent(contents, slog.SourceKey+".function", frame.Function)
+	}
+	contents = append(contents, h.contents...)
+	r.Attrs(func(attr slog.Attr) bool {
+		contents = appendAttr(contents, h.prefix, attr)
+		return true
+	})
+	h.sender.send(&sls.Log{
+		Time:     proto.Uint32(uint32(t.Unix())),
+		TimeNs:   proto.Uint32(uint32(t.Nanosecond())),
+		Contents: contents,
+	})
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.contents = make([]*sls.LogContent, len(h.contents), len(h.contents)+len(attrs))
+	copy(h2.contents, h.contents)
+	for _, attr := range attrs {
+		h2.contents = appendAttr(h2.contents, h.prefix, attr)
+	}
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = joinKey(h.prefix, name)
+	return &h2
+}
+
+// Dropped returns the number of logs dropped, shared by the handlers derived from the same NewHandler
+func (h *Handler) Dropped() int64 {
+	return atomic.LoadInt64(&h.sender.dropped)
+}
+
+// Close waits until the queued logs are handed to the producer, the logs after Close are dropped.
+// The handlers derived by WithAttrs and WithGroup are closed together.
+func (h *Handler) Close() error {
+	h.sender.close()
+	return nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func appendContent(contents []*sls.LogContent, key, value string) []*sls.LogContent {
+	return append(contents, &sls.LogContent{Key: proto.String(key), Value: proto.String(value)})
+}
+
+func appendAttr(contents []*sls.LogContent, prefix string, attr slog.Attr) []*sls.LogContent {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return contents
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix = joinKey(prefix, attr.Key)
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			contents = appendAttr(contents, prefix, groupAttr)
+		}
+		return contents
+	}
+	return appendContent(contents, joinKey(prefix, attr.Key), formatValue(attr.Value))
+}
+
+func formatValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case error:
+			return a.Error()
+		case encoding.TextMarshaler:
+			if text, err := a.MarshalText(); err == nil {
+				return string(text)
+			}
+		case []byte:
+			return string(a)
+		case fmt.Stringer:
+			return a.String()
+		}
+		if data, err := json.Marshal(v.Any()); err == nil {
+			return string(data)
+		}
+	}
+	return v.String()
+}
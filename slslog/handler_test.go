@@ -0,0 +1,44 @@
+//go:build go1.21
+
+package slslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	server, config := newTestConfig(t)
+	handler := NewHandler(config, &HandlerOptions{Level: slog.LevelDebug, AddSource: true})
+	logger := slog.New(handler).With("service", "api").WithGroup("req")
+	logger.Debug("handled", "id", 1, slog.Group("user", "name", "alice"), "err", errors.New("boom"), "latency", time.Second)
+	slog.New(handler).Log(context.Background(), slog.LevelDebug-1, "disabled")
+	require.NoError(t, handler.Close())
+	config.Producer.SafeClose()
+
+	logs := server.Logs("project", "logstore")
+	require.Len(t, logs, 1)
+	contents := contentsOf(logs[0])
+	assert.True(t, strings.HasPrefix(filepath.Base(contents["source"]), "handler_test.go:"), contents["source"])
+	assert.Contains(t, contents["source.function"], "TestHandler")
+	delete(contents, "source")
+	delete(contents, "source.function")
+	assert.Equal(t, map[string]string{
+		"msg":           "handled",
+		"level":         "DEBUG",
+		"service":       "api",
+		"req.id":        "1",
+		"req.user.name": "alice",
+		"req.err":       "boom",
+		"req.latency":   "1s",
+	}, contents)
+	assert.NotZero(t, logs[0].GetTime())
+}
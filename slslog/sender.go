@@ -0,0 +1,139 @@
+// Package slslog ships application logs to a logstore by a producer.
+//
+// Handler is a log/slog Handler (go1.21+), and Writer accepts the json lines written by
+// loggers such as zap (as a zapcore.WriteSyncer) and zerolog (as the output io.Writer).
+// Logs are queued in memory and sent by a background goroutine, so logging never blocks
+// on the network, what happens once the queue is full is decided by Config.DropPolicy.
+package slslog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+)
+
+const defaultQueueSize = 4096
+
+// DropPolicy decides what to do with a log when the queue is full
+type DropPolicy int
+
+const (
+	// DropNewest drops the log being written, the default policy
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest log in queue to make room for the log being written
+	DropOldest
+	// Block waits until there is room in queue, logging blocks when the producer is saturated
+	Block
+)
+
+var (
+	// ErrQueueFull is passed to Config.OnDrop for the logs dropped because the queue is full
+	ErrQueueFull = errors.New("slslog: queue is full")
+	// ErrClosed is passed to Config.OnDrop for the logs written after Close
+	ErrClosed = errors.New("slslog: closed")
+)
+
+// Config is where and how the logs are sent
+type Config struct {
+	// Producer sends the logs, it is not started or closed by Handler or Writer
+	Producer *producer.Producer
+	Project  string
+	Logstore string
+	Topic    string
+	Source   string
+	// QueueSize is the max number of logs waiting to be handed to the producer, default 4096
+	QueueSize int
+	// DropPolicy decides what to do with a log when the queue is full, default DropNewest
+	DropPolicy DropPolicy
+	// OnDrop is called for each dropped log, with ErrQueueFull, ErrClosed or the error of Producer.SendLog, optional.
+	// It is called while logging, it must not block or log to the same handler.
+	OnDrop func(log *sls.Log, err error)
+}
+
+// sender queues the logs and hands them to the producer in a background goroutine
+type sender struct {
+	config  Config
+	queue   chan *sls.Log
+	dropped int64
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+func newSender(config Config) *sender {
+	size := config.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	s := &sender{
+		config: config,
+		queue:  make(chan *sls.Log, size),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *sender) run() {
+	defer close(s.done)
+	for log := range s.queue {
+		if err := s.config.Producer.SendLog(s.config.Project, s.config.Logstore, s.config.Topic, s.config.Source, log); err != nil {
+			s.drop(log, err)
+		}
+	}
+}
+
+func (s *sender) send(log *sls.Log) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.drop(log, ErrClosed)
+		return
+	}
+	switch s.config.DropPolicy {
+	case Block:
+		s.queue <- log
+		return
+	case DropOldest:
+		for i := 0; i < 2; i++ {
+			select {
+			case s.queue <- log:
+				return
+			default:
+			}
+			select {
+			case old := <-s.queue:
+				s.drop(old, ErrQueueFull)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.queue <- log:
+			return
+		default:
+		}
+	}
+	s.drop(log, ErrQueueFull)
+}
+
+func (s *sender) drop(log *sls.Log, err error) {
+	atomic.AddInt64(&s.dropped, 1)
+	if s.config.OnDrop != nil {
+		s.config.OnDrop(log, err)
+	}
+}
+
+// close stops accepting logs, and waits until the queued logs are handed to the producer
+func (s *sender) close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
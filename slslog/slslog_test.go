@@ -0,0 +1,85 @@
+package slslog
+
+import (
+	"testing"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+	"github.com/aliyun/aliyun-log-go-sdk/slstest"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig(t *testing.T) (*slstest.Server, Config) {
+	server := slstest.NewServer()
+	t.Cleanup(server.Close)
+	client := server.NewClient()
+	_, err := client.CreateProject("project", "")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore("project", "logstore", 1, 1, false, 0))
+
+	producerConfig := producer.GetDefaultProducerConfig()
+	producerConfig.Endpoint = server.Endpoint
+	producerConfig.HTTPClient = server.HTTPClient()
+	producerConfig.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	producerConfig.LingerMs = 100
+	p, err := producer.NewProducer(producerConfig)
+	require.NoError(t, err)
+	p.Start()
+	t.Cleanup(p.SafeClose)
+	return server, Config{Producer: p, Project: "project", Logstore: "logstore"}
+}
+
+func contentsOf(log *sls.Log) map[string]string {
+	contents := map[string]string{}
+	for _, content := range log.Contents {
+		contents[content.GetKey()] = content.GetValue()
+	}
+	return contents
+}
+
+func TestWriter(t *testing.T) {
+	server, config := newTestConfig(t)
+	writer := NewWriter(config, "ts")
+	// the lines written by zap
+	line := `{"level":"info","ts":1700000000.5,"msg":"hello","req":{"id":1}}` + "\n"
+	n, err := writer.Write([]byte(line))
+	require.NoError(t, err)
+	assert.Equal(t, len(line), n)
+	_, err = writer.Write([]byte("not json\n"))
+	assert.Error(t, err)
+	require.NoError(t, writer.Sync())
+	require.NoError(t, writer.Close())
+	config.Producer.SafeClose()
+
+	logs := server.Logs("project", "logstore")
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint32(1700000000), logs[0].GetTime())
+	assert.Equal(t, uint32(500000000), logs[0].GetTimeNs())
+	assert.Equal(t, map[string]string{"level": "info", "msg": "hello", "req.id": "1"}, contentsOf(logs[0]))
+}
+
+func TestDropPolicy(t *testing.T) {
+	newLog := func(value string) *sls.Log {
+		return &sls.Log{Contents: []*sls.LogContent{{Key: proto.String("k"), Value: proto.String(value)}}}
+	}
+	var dropped []string
+	onDrop := func(log *sls.Log, err error) {
+		assert.ErrorIs(t, err, ErrQueueFull)
+		dropped = append(dropped, log.Contents[0].GetValue())
+	}
+	// the queue is not drained without run
+	s := &sender{config: Config{OnDrop: onDrop}, queue: make(chan *sls.Log, 1)}
+	s.send(newLog("a"))
+	s.send(newLog("b"))
+	assert.Equal(t, []string{"b"}, dropped)
+	assert.Equal(t, int64(1), s.dropped)
+
+	dropped = nil
+	s = &sender{config: Config{OnDrop: onDrop, DropPolicy: DropOldest}, queue: make(chan *sls.Log, 1)}
+	s.send(newLog("a"))
+	s.send(newLog("b"))
+	assert.Equal(t, []string{"a"}, dropped)
+	assert.Equal(t, "b", (<-s.queue).Contents[0].GetValue())
+}
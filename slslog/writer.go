@@ -0,0 +1,62 @@
+package slslog
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/aliyun/aliyun-log-go-sdk/slsencoding"
+)
+
+// Writer converts json lines to logs and sends them, eg.
+//
+//	// zap, the time key is "ts" by default
+//	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), slslog.NewWriter(config, "ts"), zap.InfoLevel)
+//	// zerolog, the time key is "time" by default
+//	logger := zerolog.New(slslog.NewWriter(config, zerolog.TimestampFieldName)).With().Timestamp().Logger()
+//
+// Nested objects are flattened with ".", the value of timeKey is used as the time of log,
+// which is unix seconds or a RFC3339 string.
+type Writer struct {
+	sender  *sender
+	encoder *slsencoding.Encoder
+}
+
+// NewWriter starts a Writer, Close it to flush the queued logs to the producer
+func NewWriter(config Config, timeKey string) *Writer {
+	return &Writer{
+		sender:  newSender(config),
+		encoder: &slsencoding.Encoder{TimeKey: timeKey},
+	}
+}
+
+// Write sends each json line in p as a log, it returns an error if a line is not a json object
+func (w *Writer) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		log, err := w.encoder.MarshalJSONObject(line)
+		if err != nil {
+			return 0, err
+		}
+		w.sender.send(log)
+	}
+	return len(p), nil
+}
+
+// Sync does nothing, the logs are flushed by Close, it makes Writer a zapcore.WriteSyncer
+func (w *Writer) Sync() error {
+	return nil
+}
+
+// Dropped returns the number of logs dropped
+func (w *Writer) Dropped() int64 {
+	return atomic.LoadInt64(&w.sender.dropped)
+}
+
+// Close waits until the queued logs are handed to the producer, the logs written after Close are dropped
+func (w *Writer) Close() error {
+	w.sender.close()
+	return nil
+}
@@ -0,0 +1,238 @@
+package slsquery
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Builder builds a query of a search statement, and an optional analytic statement, eg.
+//
+//	query, err := slsquery.New().
+//		Search(slsquery.Field("level", "ERROR"), slsquery.Not(slsquery.Field("msg", "connection reset"))).
+//		Topic("nginx").
+//		Select("host", "count(1) AS pv").
+//		Where("status >= ?", 500).
+//		GroupBy("host").
+//		OrderBy("pv DESC").
+//		Limit(10).
+//		Build()
+//
+// which is `level: ERROR and not msg: "connection reset" and __topic__: nginx | SELECT host, count(1) AS pv
+// WHERE status >= 500 GROUP BY host ORDER BY pv DESC LIMIT 10`.
+type Builder struct {
+	search  []Expr
+	columns []string
+	where   []string
+	groupBy []string
+	orderBy []string
+	limit   int
+	err     error
+}
+
+// New creates an empty Builder, which matches all logs
+func New() *Builder {
+	return &Builder{}
+}
+
+// Search adds exprs to the search statement, all of which must match
+func (b *Builder) Search(exprs ...Expr) *Builder {
+	b.search = append(b.search, exprs...)
+	return b
+}
+
+// Field adds `key: value` to the search statement
+func (b *Builder) Field(key, value string) *Builder {
+	return b.Search(Field(key, value))
+}
+
+// Not adds `not key: value` to the search statement
+func (b *Builder) Not(key, value string) *Builder {
+	return b.Search(Not(Field(key, value)))
+}
+
+// Topic adds `__topic__: topic` to the search statement
+func (b *Builder) Topic(topic string) *Builder {
+	return b.Search(Topic(topic))
+}
+
+// Select adds the columns of the analytic statement, eg. "count(1) AS pv"
+func (b *Builder) Select(columns ...string) *Builder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Where adds a condition to the WHERE clause of the analytic statement, the placeholders ? in cond are replaced by
+// the literals of args, eg. Where("host = ?", "web-1"). Conditions are joined by AND.
+func (b *Builder) Where(cond string, args ...interface{}) *Builder {
+	cond, err := Interpolate(cond, args...)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	b.where = append(b.where, cond)
+	return b
+}
+
+// GroupBy adds the columns of the GROUP BY clause
+func (b *Builder) GroupBy(columns ...string) *Builder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// OrderBy adds the columns of the ORDER BY clause, eg. "pv DESC"
+func (b *Builder) OrderBy(columns ...string) *Builder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+// Limit sets the LIMIT clause, no LIMIT if n <= 0
+func (b *Builder) Limit(n int) *Builder {
+	b.limit = n
+	return b
+}
+
+// Build returns the query, or the first error of Where
+func (b *Builder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	var search Expr = All()
+	if len(b.search) > 0 {
+		search = And(b.search...)
+	}
+	query := search.String()
+	if len(b.columns) == 0 {
+		if len(b.where) > 0 || len(b.groupBy) > 0 || len(b.orderBy) > 0 || b.limit > 0 {
+			return "", fmt.Errorf("slsquery: analytic statement without SELECT")
+		}
+		return query, nil
+	}
+	var sb strings.Builder
+	sb.WriteString(query)
+	sb.WriteString(" | SELECT ")
+	sb.WriteString(strings.Join(b.columns, ", "))
+	if len(b.where) > 0 {
+		sb.WriteString(" WHERE ")
+		for i, cond := range b.where {
+			if i > 0 {
+				sb.WriteString(" AND ")
+			}
+			if len(b.where) > 1 {
+				sb.WriteString("(" + cond + ")")
+			} else {
+				sb.WriteString(cond)
+			}
+		}
+	}
+	if len(b.groupBy) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(b.groupBy, ", "))
+	}
+	if len(b.orderBy) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+	if b.limit > 0 {
+		sb.WriteString(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	return sb.String(), nil
+}
+
+// Interpolate replaces the placeholders ? out of quotes in sql by the literals of args,
+// a character escaped by backslash in double quotes, eg. in the strings of search query, does not end the quotes
+func Interpolate(sql string, args ...interface{}) (string, error) {
+	var sb strings.Builder
+	var quote byte
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case quote == '"' && ch == '\\' && i+1 < len(sql):
+			sb.WriteByte(ch)
+			i++
+			ch = sql[i]
+		case quote != 0:
+			// a quote in quotes is escaped by doubling it, which is handled as closing and opening again
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '?':
+			if n >= len(args) {
+				return "", fmt.Errorf("slsquery: expected more than %d arguments in %q", len(args), sql)
+			}
+			literal, err := Literal(args[n])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(literal)
+			n++
+			continue
+		}
+		sb.WriteByte(ch)
+	}
+	if n != len(args) {
+		return "", fmt.Errorf("slsquery: expected %d arguments in %q, got %d", n, sql, len(args))
+	}
+	return sb.String(), nil
+}
+
+// Literal returns the SQL literal of v, strings are quoted with single quotes, and time.Time is unix seconds
+func Literal(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return QuoteString(v), nil
+	case []byte:
+		return QuoteString(string(v)), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return formatFloatLiteral(float64(v))
+	case float64:
+		return formatFloatLiteral(v)
+	case time.Time:
+		return strconv.FormatInt(v.Unix(), 10), nil
+	default:
+		return "", fmt.Errorf("slsquery: unsupported argument type %T", v)
+	}
+}
+
+func formatFloatLiteral(v float64) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", fmt.Errorf("slsquery: unsupported argument %v", v)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64), nil
+}
+
+// QuoteString quotes s as a string literal of SQL, the single quotes in s are doubled
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// QuoteIdentifier quotes name as an identifier of SQL, eg. the keys with dots "user.name"
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,221 @@
+// Package slsquery builds and validates the queries of SLS, a search statement optionally followed by
+// an analytic statement of SQL, eg.
+//
+//	level: ERROR and not msg: "connection reset" | SELECT host, count(1) AS pv GROUP BY host
+//
+// Expr is the search statement, built by Field, Compare, Range, And, Or, Not etc., which are quoted as needed,
+// Builder builds the whole query, and Parse validates a query string and returns its structure.
+package slsquery
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is an expression of search statement
+type Expr interface {
+	// String returns the expression in the syntax of SLS
+	String() string
+}
+
+// AllExpr matches all logs, "*"
+type AllExpr struct{}
+
+// TermExpr matches the logs whose Key has Value, or any field has Value if Key is empty, eg. `key: value`.
+// Value is quoted if it has special characters or keywords, unless Wildcard is set, which keeps the
+// wildcards * and ? of Value, eg. `key: val*`. Phrase matches the words of Value in order, eg. `key: #"a b"`.
+type TermExpr struct {
+	Key      string
+	Value    string
+	Wildcard bool
+	Phrase   bool
+}
+
+// CompareExpr compares a numeric Key with Value, eg. `latency > 100`, Op is one of =, >, >=, < and <=
+type CompareExpr struct {
+	Key   string
+	Op    string
+	Value float64
+}
+
+// RangeExpr matches a numeric Key in a range, eg. `latency in [100 200)`
+type RangeExpr struct {
+	Key          string
+	Min          float64
+	Max          float64
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+// NotExpr matches the logs not matching Expr
+type NotExpr struct {
+	Expr Expr
+}
+
+// AndExpr matches the logs matching all of the expressions
+type AndExpr []Expr
+
+// OrExpr matches the logs matching any of the expressions
+type OrExpr []Expr
+
+// All matches all logs
+func All() Expr {
+	return AllExpr{}
+}
+
+// Field matches the logs whose key has value, eg. Field("msg", "connection reset") is `msg: "connection reset"`
+func Field(key, value string) Expr {
+	return TermExpr{Key: key, Value: value}
+}
+
+// Term matches the logs with text in any field, eg. Term("timeout") is `timeout`
+func Term(text string) Expr {
+	return TermExpr{Value: text}
+}
+
+// Prefix matches the logs whose key starts with prefix, eg. Prefix("host", "web") is `host: web*`.
+// The prefix is not quoted, it must be a word without delimiters.
+func Prefix(key, prefix string) Expr {
+	return TermExpr{Key: key, Value: prefix + "*", Wildcard: true}
+}
+
+// Phrase matches the logs whose key has the words of phrase in order, or any field if key is empty,
+// eg. Phrase("msg", "connection reset") is `msg: #"connection reset"`
+func Phrase(key, phrase string) Expr {
+	return TermExpr{Key: key, Value: phrase, Phrase: true}
+}
+
+// Topic matches the logs of topic, `__topic__: topic`
+func Topic(topic string) Expr {
+	return Field("__topic__", topic)
+}
+
+// Compare compares a numeric key with value, op is one of =, >, >=, < and <=
+func Compare(key, op string, value float64) Expr {
+	return CompareExpr{Key: key, Op: op, Value: value}
+}
+
+// Range matches a numeric key in [min, max)
+func Range(key string, min, max float64) Expr {
+	return RangeExpr{Key: key, Min: min, Max: max, MaxExclusive: true}
+}
+
+// Not matches the logs not matching expr
+func Not(expr Expr) Expr {
+	return NotExpr{Expr: expr}
+}
+
+// And matches the logs matching all of exprs
+func And(exprs ...Expr) Expr {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return AndExpr(exprs)
+}
+
+// Or matches the logs matching any of exprs
+func Or(exprs ...Expr) Expr {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return OrExpr(exprs)
+}
+
+func (AllExpr) String() string {
+	return "*"
+}
+
+func (e TermExpr) String() string {
+	value := e.Value
+	if e.Phrase {
+		value = "#" + quote(value)
+	} else if !e.Wildcard {
+		value = quoteValue(value)
+	}
+	if e.Key == "" {
+		return value
+	}
+	return quoteKey(e.Key) + ": " + value
+}
+
+func (e CompareExpr) String() string {
+	return quoteKey(e.Key) + " " + e.Op + " " + formatNumber(e.Value)
+}
+
+func (e RangeExpr) String() string {
+	open, close := "[", "]"
+	if e.MinExclusive {
+		open = "("
+	}
+	if e.MaxExclusive {
+		close = ")"
+	}
+	return quoteKey(e.Key) + " in " + open + formatNumber(e.Min) + " " + formatNumber(e.Max) + close
+}
+
+func (e NotExpr) String() string {
+	return "not " + group(e.Expr)
+}
+
+func (e AndExpr) String() string {
+	return join(e, " and ")
+}
+
+func (e OrExpr) String() string {
+	return join(e, " or ")
+}
+
+func join(exprs []Expr, sep string) string {
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = group(expr)
+	}
+	return strings.Join(parts, sep)
+}
+
+// group wraps the compound expressions in parentheses
+func group(expr Expr) string {
+	switch e := expr.(type) {
+	case AndExpr, OrExpr:
+		return "(" + e.String() + ")"
+	default:
+		return expr.String()
+	}
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+var (
+	bareValueRegexp = regexp.MustCompile(`^[\pL\pN_.]+$`)
+	bareKeyRegexp   = regexp.MustCompile(`^(__tag__:)?[\pL_][\pL\pN_.]*$`)
+)
+
+func isKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not", "in":
+		return true
+	}
+	return false
+}
+
+// quoteValue quotes value with double quotes unless it is a word, quotes and backslashes in it are escaped by backslashes
+func quoteValue(value string) string {
+	if bareValueRegexp.MatchString(value) && !isKeyword(value) {
+		return value
+	}
+	return quote(value)
+}
+
+func quoteKey(key string) string {
+	if bareKeyRegexp.MatchString(key) && !isKeyword(key) {
+		return key
+	}
+	return quote(key)
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
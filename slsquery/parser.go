@@ -0,0 +1,409 @@
+package slsquery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is the error of Parse, at the byte Offset of Query
+type SyntaxError struct {
+	Query  string
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("slsquery: %s at offset %d", e.Msg, e.Offset)
+}
+
+// Query is a parsed query
+type Query struct {
+	Search Expr
+	// SQL is the analytic statement after |, empty if there is none
+	SQL string
+}
+
+// String returns the query in the syntax of SLS, with the search statement quoted as Expr
+func (q *Query) String() string {
+	if q.SQL == "" {
+		return q.Search.String()
+	}
+	return q.Search.String() + " | " + q.SQL
+}
+
+// Parse validates query, and returns its search statement as Expr and its analytic statement, eg.
+//
+//	q, err := slsquery.Parse(`level: ERROR and latency > 100 | SELECT count(1) AS pv`)
+//
+// An empty query matches all logs. The error is a *SyntaxError at the position where it is found.
+// The analytic statement is checked for its SELECT, quotes and parentheses only.
+func Parse(query string) (*Query, error) {
+	p := &parser{query: query}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	switch tok := p.peek(); tok.kind {
+	case tokEOF:
+		return &Query{Search: All()}, nil
+	case tokPipe:
+		return nil, p.errorf(tok.offset, "missing search statement before |")
+	}
+	search, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{Search: search}
+	switch tok := p.next(); tok.kind {
+	case tokEOF:
+		return q, nil
+	case tokPipe:
+		sql, err := checkSQL(query, tok.offset+1)
+		if err != nil {
+			return nil, err
+		}
+		q.SQL = sql
+		return q, nil
+	default:
+		return nil, p.errorf(tok.offset, "unexpected %s", tok)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokPhrase
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokColon
+	tokOp
+	tokPipe
+)
+
+type token struct {
+	kind   tokenKind
+	text   string // the word, the unquoted string or phrase, or the operator
+	offset int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// is reports whether tok is the keyword, case insensitive
+func (t token) is(keyword string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, keyword)
+}
+
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(offset int, format string, args ...interface{}) error {
+	return &SyntaxError{Query: p.query, Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+const wordDelimiters = "()[]:<>=|\""
+
+var delimiterKinds = map[byte]tokenKind{'(': tokLParen, ')': tokRParen, '[': tokLBracket, ']': tokRBracket, ':': tokColon}
+
+// lex splits the search statement into tokens, until the first | out of quotes
+func (p *parser) lex() error {
+	s := p.query
+	i := 0
+	for i < len(s) {
+		ch := s[i]
+		switch {
+		case isSpace(ch):
+			i++
+		case ch == '|':
+			p.tokens = append(p.tokens, token{kind: tokPipe, text: "|", offset: i})
+			p.tokens = append(p.tokens, token{kind: tokEOF, offset: len(s)})
+			return nil
+		case ch == '"':
+			text, end, err := p.lexString(i)
+			if err != nil {
+				return err
+			}
+			p.tokens = append(p.tokens, token{kind: tokString, text: text, offset: i})
+			i = end
+		case ch == '#':
+			// phrase search, eg. #"connection reset"
+			if i+1 >= len(s) || s[i+1] != '"' {
+				return p.errorf(i, "expected quoted phrase after #")
+			}
+			text, end, err := p.lexString(i + 1)
+			if err != nil {
+				return err
+			}
+			p.tokens = append(p.tokens, token{kind: tokPhrase, text: text, offset: i})
+			i = end
+		case ch == '>' || ch == '<':
+			op := string(ch)
+			if i+1 < len(s) && s[i+1] == '=' {
+				op += "="
+			}
+			p.tokens = append(p.tokens, token{kind: tokOp, text: op, offset: i})
+			i += len(op)
+		case ch == '=':
+			p.tokens = append(p.tokens, token{kind: tokOp, text: "=", offset: i})
+			i++
+		case strings.IndexByte(wordDelimiters, ch) >= 0:
+			p.tokens = append(p.tokens, token{kind: delimiterKinds[ch], text: string(ch), offset: i})
+			i++
+		default:
+			j := i
+			for j < len(s) && !isSpace(s[j]) && strings.IndexByte(wordDelimiters, s[j]) < 0 {
+				j++
+				// the keys of tags have a colon, eg. __tag__:__path__
+				if s[i:j] == "__tag__" && j+1 < len(s) && s[j] == ':' && !isSpace(s[j+1]) && strings.IndexByte(wordDelimiters, s[j+1]) < 0 {
+					j++
+				}
+			}
+			p.tokens = append(p.tokens, token{kind: tokWord, text: s[i:j], offset: i})
+			i = j
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, offset: len(s)})
+	return nil
+}
+
+// lexString returns the unquoted string quoted with double quotes at offset i, and the offset after it
+func (p *parser) lexString(i int) (string, int, error) {
+	s := p.query
+	var sb strings.Builder
+	j := i + 1
+	for ; j < len(s) && s[j] != '"'; j++ {
+		if s[j] == '\\' && j+1 < len(s) {
+			j++
+		}
+		sb.WriteByte(s[j])
+	}
+	if j >= len(s) {
+		return "", 0, p.errorf(i, "unterminated quoted string")
+	}
+	return sb.String(), j + 1, nil
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expr{left}
+	for p.peek().is("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+	return Or(exprs...), nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []Expr{left}
+	for {
+		tok := p.peek()
+		if tok.is("and") {
+			p.next()
+		} else if tok.is("or") || (tok.kind != tokWord && tok.kind != tokString && tok.kind != tokPhrase && tok.kind != tokLParen) {
+			break
+		}
+		// terms without operators are joined by and
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+	return And(exprs...), nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.peek().is("not") {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not(expr), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, p.errorf(tok.offset, "missing ) of (")
+		}
+		return expr, nil
+	case tokString:
+		if p.peek().kind == tokColon {
+			p.next()
+			return p.parseValue(tok.text)
+		}
+		return TermExpr{Value: tok.text}, nil
+	case tokPhrase:
+		return TermExpr{Value: tok.text, Phrase: true}, nil
+	case tokWord:
+		if isKeyword(tok.text) {
+			return nil, p.errorf(tok.offset, "expected expression, got %s", tok)
+		}
+		switch next := p.peek(); {
+		case next.kind == tokColon:
+			p.next()
+			return p.parseValue(tok.text)
+		case next.kind == tokOp:
+			p.next()
+			value, err := p.parseNumber(next.text)
+			if err != nil {
+				return nil, err
+			}
+			return CompareExpr{Key: tok.text, Op: next.text, Value: value}, nil
+		case next.is("in"):
+			p.next()
+			return p.parseRange(tok.text)
+		}
+		if tok.text == "*" {
+			return All(), nil
+		}
+		return TermExpr{Value: tok.text, Wildcard: hasWildcard(tok.text)}, nil
+	case tokEOF, tokPipe:
+		return nil, p.errorf(tok.offset, "expected expression, got %s", tok)
+	default:
+		return nil, p.errorf(tok.offset, "unexpected %s", tok)
+	}
+}
+
+// parseValue parses the value after `key:`
+func (p *parser) parseValue(key string) (Expr, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokString:
+		return TermExpr{Key: key, Value: tok.text}, nil
+	case tok.kind == tokPhrase:
+		return TermExpr{Key: key, Value: tok.text, Phrase: true}, nil
+	case tok.kind == tokWord && !isKeyword(tok.text):
+		return TermExpr{Key: key, Value: tok.text, Wildcard: hasWildcard(tok.text)}, nil
+	default:
+		return nil, p.errorf(tok.offset, "expected value of %s, got %s", key, tok)
+	}
+}
+
+func (p *parser) parseNumber(after string) (float64, error) {
+	tok := p.next()
+	if tok.kind == tokWord {
+		if v, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return v, nil
+		}
+	}
+	return 0, p.errorf(tok.offset, "expected number after %s, got %s", after, tok)
+}
+
+// parseRange parses the range after `key in`, eg. [100 200)
+func (p *parser) parseRange(key string) (Expr, error) {
+	open := p.next()
+	if open.kind != tokLBracket && open.kind != tokLParen {
+		return nil, p.errorf(open.offset, "expected [ or ( of range, got %s", open)
+	}
+	min, err := p.parseNumber(open.text)
+	if err != nil {
+		return nil, err
+	}
+	max, err := p.parseNumber(strconv.FormatFloat(min, 'f', -1, 64))
+	if err != nil {
+		return nil, err
+	}
+	close := p.next()
+	if close.kind != tokRBracket && close.kind != tokRParen {
+		return nil, p.errorf(close.offset, "expected ] or ) of range, got %s", close)
+	}
+	if min > max {
+		return nil, p.errorf(open.offset, "invalid range, %v > %v", min, max)
+	}
+	return RangeExpr{Key: key, Min: min, Max: max, MinExclusive: open.kind == tokLParen, MaxExclusive: close.kind == tokRParen}, nil
+}
+
+func hasWildcard(s string) bool {
+	return strings.ContainsAny(s, "*?")
+}
+
+var sqlStartRegexp = regexp.MustCompile(`(?i)^\s*(select|with)\b`)
+
+// checkSQL checks the analytic statement from offset of query, and returns it trimmed
+func checkSQL(query string, offset int) (string, error) {
+	sql := query[offset:]
+	if strings.TrimSpace(sql) == "" {
+		return "", &SyntaxError{Query: query, Offset: offset - 1, Msg: "missing analytic statement after |"}
+	}
+	if !sqlStartRegexp.MatchString(sql) {
+		return "", &SyntaxError{Query: query, Offset: offset + len(sql) - len(strings.TrimLeft(sql, " \t\r\n")), Msg: "analytic statement must start with SELECT"}
+	}
+	var quote byte
+	quoteStart := 0
+	var parens []int
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote, quoteStart = ch, i
+		case ch == '(':
+			parens = append(parens, i)
+		case ch == ')':
+			if len(parens) == 0 {
+				return "", &SyntaxError{Query: query, Offset: offset + i, Msg: `unexpected ")"`}
+			}
+			parens = parens[:len(parens)-1]
+		}
+	}
+	if quote != 0 {
+		return "", &SyntaxError{Query: query, Offset: offset + quoteStart, Msg: "unterminated quoted string"}
+	}
+	if len(parens) > 0 {
+		return "", &SyntaxError{Query: query, Offset: offset + parens[len(parens)-1], Msg: "missing ) of ("}
+	}
+	return strings.TrimSpace(sql), nil
+}
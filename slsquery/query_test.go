@@ -0,0 +1,133 @@
+package slsquery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr(t *testing.T) {
+	cases := []struct {
+		expr     Expr
+		expected string
+	}{
+		{Field("level", "ERROR"), `level: ERROR`},
+		{Field("msg", `say "hi" \ bye`), `msg: "say \"hi\" \\ bye"`},
+		{Field("path", "/api:v1"), `path: "/api:v1"`},
+		{Field("op", "and"), `op: "and"`},
+		{Field("user.name", "*"), `user.name: "*"`},
+		{Field("__tag__:__path__", "/var/log"), `__tag__:__path__: "/var/log"`},
+		{Field("content-type", "json"), `"content-type": json`},
+		{Term("timeout"), `timeout`},
+		{Term("#abc"), `"#abc"`},
+		{Phrase("msg", `connection "reset"`), `msg: #"connection \"reset\""`},
+		{Phrase("", "abc"), `#"abc"`},
+		{Prefix("host", "web"), `host: web*`},
+		{Topic("nginx access"), `__topic__: "nginx access"`},
+		{Compare("latency", ">=", 100.5), `latency >= 100.5`},
+		{Range("status", 500, 600), `status in [500 600)`},
+		{Not(Field("level", "INFO")), `not level: INFO`},
+		{Or(Field("a", "1"), And(Field("b", "2"), Not(Or(Term("x"), Term("y"))))), `a: 1 or (b: 2 and not (x or y))`},
+		{And(Field("a", "1")), `a: 1`},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.expr.String())
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	query, err := New().
+		Search(Field("level", "ERROR"), Not(Field("msg", "connection reset"))).
+		Topic("nginx").
+		Select("host", "count(1) AS pv").
+		Where("status >= ?", 500).
+		Where("host != ? and time > ?", "it's", time.Unix(1700000000, 0)).
+		GroupBy("host").
+		OrderBy("pv DESC").
+		Limit(10).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, `level: ERROR and not msg: "connection reset" and __topic__: nginx | SELECT host, count(1) AS pv `+
+		`WHERE (status >= 500) AND (host != 'it''s' and time > 1700000000) GROUP BY host ORDER BY pv DESC LIMIT 10`, query)
+
+	query, err = New().Build()
+	require.NoError(t, err)
+	assert.Equal(t, "*", query)
+
+	_, err = New().Select("count(1)").Where("a = ? and b = ?", 1).Build()
+	assert.Error(t, err)
+	_, err = New().Where("a = 1").Build()
+	assert.Error(t, err)
+
+	query, err = Interpolate(`select '?', "?" where a = ? and b is ?`, []byte("x"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `select '?', "?" where a = 'x' and b is NULL`, query)
+	query, err = Interpolate(Field("msg", `a "b?"`).String()+` | select count(1) where x = ?`, 1)
+	require.NoError(t, err)
+	assert.Equal(t, `msg: "a \"b?\"" | select count(1) where x = 1`, query)
+	assert.Equal(t, `"user.name"`, QuoteIdentifier("user.name"))
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		query  string
+		search Expr
+		sql    string
+	}{
+		{"", All(), ""},
+		{"* | select count(1) as pv", All(), "select count(1) as pv"},
+		{`level: ERROR and not msg: "connection \"reset\""`, And(Field("level", "ERROR"), Not(Field("msg", `connection "reset"`))), ""},
+		{`a: 1 b: 2 OR c: 3`, Or(And(Field("a", "1"), Field("b", "2")), Field("c", "3")), ""},
+		{`(a: 1 or b: 2) and latency > 100 and status in [500 600)`, And(Or(Field("a", "1"), Field("b", "2")), Compare("latency", ">", 100), Range("status", 500, 600)), ""},
+		{`host: web* and timeout`, And(Prefix("host", "web"), Term("timeout")), ""},
+		{`__tag__:__path__: "/var/log" | SELECT host WHERE a = 'x|y'`, Field("__tag__:__path__", "/var/log"), "SELECT host WHERE a = 'x|y'"},
+		{`"content-type": json`, Field("content-type", "json"), ""},
+		{`#"abc def"`, Phrase("", "abc def"), ""},
+		{`msg: #"connection reset" not #"timeout"`, And(Phrase("msg", "connection reset"), Not(Phrase("", "timeout"))), ""},
+	}
+	for _, c := range cases {
+		q, err := Parse(c.query)
+		require.NoError(t, err, c.query)
+		assert.Equal(t, c.search, q.Search, c.query)
+		assert.Equal(t, c.sql, q.SQL, c.query)
+		// the query string of builder is parsed to the same query
+		again, err := Parse(q.String())
+		require.NoError(t, err, q.String())
+		assert.Equal(t, q, again)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := []struct {
+		query  string
+		offset int
+	}{
+		{`msg: "unterminated`, 5},
+		{`(a: 1 or b: 2`, 0},
+		{`a: 1)`, 4},
+		{`a: 1 and`, 8},
+		{`not`, 3},
+		{`a:`, 2},
+		{`a: and`, 3},
+		{`latency > abc`, 10},
+		{`status in [600 500]`, 10},
+		{`status in [500 600`, 18},
+		{`| select 1`, 0},
+		{`* |  `, 2},
+		{`* | count(1)`, 4},
+		{`* | select count(1`, 16},
+		{`* | select 'a`, 11},
+		{`#abc`, 0},
+		{`msg: #"abc`, 6},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.query)
+		var syntaxErr *SyntaxError
+		require.True(t, errors.As(err, &syntaxErr), c.query)
+		assert.Equal(t, c.offset, syntaxErr.Offset, "%s: %v", c.query, err)
+		assert.Equal(t, c.query, syntaxErr.Query)
+	}
+}
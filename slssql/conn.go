@@ -0,0 +1,168 @@
+package slssql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+var errNotSupported = errors.New("slssql: only queries are supported")
+
+// conn is stateless, queries are sent by the client of connector
+type conn struct {
+	client sls.ClientInterface
+	config Config
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errNotSupported
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := interpolate(query, args)
+	if err != nil {
+		return nil, err
+	}
+	search, err := searchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	from, to := timeRange(query, time.Now(), c.config.DefaultRange)
+	req := &sls.GetLogRequest{
+		From:     from,
+		To:       to,
+		Query:    search,
+		PowerSQL: c.config.PowerSQL,
+	}
+	// the query and its retries are canceled with ctx, if client supports context
+	client := c.client
+	if ctxClient, ok := client.(sls.ClientWithContext); ok {
+		client = ctxClient.WithContext(ctx)
+	}
+	// incomplete results are retried by the RetryPolicy of client
+	resp, err := client.GetLogsToCompletedV3(c.config.Project, c.config.Logstore, req)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsComplete() {
+		return nil, fmt.Errorf("slssql: results of query are incomplete after retries: %s", req.Query)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &rows{result: sls.NewSQLResult(resp)}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput is -1, the number of placeholders is checked by interpolate
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errNotSupported
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return s.conn.QueryContext(context.Background(), s.query, named)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+type rows struct {
+	result *sls.SQLResult
+}
+
+func (r *rows) Columns() []string {
+	names := make([]string, len(r.result.Columns))
+	for i, column := range r.result.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.result.Next() {
+		return io.EOF
+	}
+	values, err := r.result.Values()
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		dest[i] = value
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName returns the SQL type of column in upper case, or the kind of column if the type is not returned
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	column := r.result.Columns[index]
+	if column.DatabaseType != "" {
+		return strings.ToUpper(column.DatabaseType)
+	}
+	switch column.Kind {
+	case sls.ColumnInt:
+		return "BIGINT"
+	case sls.ColumnFloat:
+		return "DOUBLE"
+	case sls.ColumnBool:
+		return "BOOLEAN"
+	case sls.ColumnTime:
+		return "TIMESTAMP"
+	default:
+		return "VARCHAR"
+	}
+}
+
+var scanTypes = map[sls.ColumnKind]reflect.Type{
+	sls.ColumnString: reflect.TypeOf(""),
+	sls.ColumnInt:    reflect.TypeOf(int64(0)),
+	sls.ColumnFloat:  reflect.TypeOf(float64(0)),
+	sls.ColumnBool:   reflect.TypeOf(false),
+	sls.ColumnTime:   reflect.TypeOf(time.Time{}),
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	return scanTypes[r.result.Columns[index].Kind]
+}
+
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return true, true
+}
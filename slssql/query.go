@@ -0,0 +1,80 @@
+package slssql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-log-go-sdk/slsquery"
+)
+
+// interpolate replaces the placeholders ? out of quotes by the literals of args by slsquery.Interpolate,
+// a query without args is not changed, so that the wildcards ? of its search statement are kept
+func interpolate(query string, args []driver.NamedValue) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return "", fmt.Errorf("slssql: named argument %s is not supported", arg.Name)
+		}
+		values[i] = arg.Value
+	}
+	return slsquery.Interpolate(query, values...)
+}
+
+var (
+	timeBetweenRegexp = regexp.MustCompile(`(?i)"?\b__time__"?\s+between\s+(\d+)\s+and\s+(\d+)`)
+	timeFromRegexp    = regexp.MustCompile(`(?i)"?\b__time__"?\s*(>=|>)\s*(\d+)`)
+	timeToRegexp      = regexp.MustCompile(`(?i)"?\b__time__"?\s*(<=|<)\s*(\d+)`)
+)
+
+// timeRange returns the time range [from, to) of GetLogsV3 by the conditions of __time__ with numbers in query,
+// the missing end is now, and the missing start is defaultRange before the end
+func timeRange(query string, now time.Time, defaultRange time.Duration) (from, to int64) {
+	from, to = -1, -1
+	if m := timeBetweenRegexp.FindStringSubmatch(query); m != nil {
+		from, _ = strconv.ParseInt(m[1], 10, 64)
+		to, _ = strconv.ParseInt(m[2], 10, 64)
+		to++
+	} else {
+		if m := timeFromRegexp.FindStringSubmatch(query); m != nil {
+			from, _ = strconv.ParseInt(m[2], 10, 64)
+			if m[1] == ">" {
+				from++
+			}
+		}
+		if m := timeToRegexp.FindStringSubmatch(query); m != nil {
+			to, _ = strconv.ParseInt(m[2], 10, 64)
+			if m[1] == "<=" {
+				to++
+			}
+		}
+	}
+	if to < 0 {
+		to = now.Unix() + 1
+	}
+	if from < 0 {
+		from = to - int64(defaultRange/time.Second)
+	}
+	return from, to
+}
+
+var sqlOnlyRegexp = regexp.MustCompile(`(?i)^(select|with)\b`)
+
+// searchQuery returns the query of GetLogsV3, a query of SQL only is run on all logs
+func searchQuery(query string) (string, error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if query == "" {
+		return "", errors.New("slssql: empty query")
+	}
+	if sqlOnlyRegexp.MatchString(query) {
+		return "* | " + query, nil
+	}
+	return query, nil
+}
@@ -0,0 +1,75 @@
+package slstest
+
+import (
+	"fmt"
+	"strconv"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+var zstdCompressor = sls.NewZstdCompressor(zstd.SpeedFastest)
+
+// decompress decompresses a request body of x-log-compresstype
+func decompress(body []byte, compressType, rawSize string) ([]byte, error) {
+	switch compressType {
+	case "":
+		return body, nil
+	case "lz4":
+		size, err := strconv.Atoi(rawSize)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid x-log-bodyrawsize: %s", rawSize)
+		}
+		out := make([]byte, size)
+		n, err := lz4.UncompressBlock(body, out)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lz4 body: %v", err)
+		}
+		return out[:n], nil
+	case "zstd":
+		out, err := zstdCompressor.Decompress(body, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %v", err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported x-log-compresstype: %s", compressType)
+}
+
+// compress compresses a response body with lz4 or zstd
+func compress(raw []byte, compressType string) ([]byte, error) {
+	if compressType == "zstd" {
+		return zstdCompressor.Compress(raw, nil)
+	}
+	out := make([]byte, lz4.CompressBlockBound(len(raw)))
+	var hashTable [1 << 16]int
+	n, err := lz4.CompressBlock(raw, out, hashTable[:])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		n = copyIncompressible(raw, out)
+	}
+	return out[:n], nil
+}
+
+// copyIncompressible writes src as a lz4 block of a single literal sequence
+func copyIncompressible(src, dst []byte) int {
+	lLen := len(src)
+	di := 0
+	if lLen < 0xF {
+		dst[di] = byte(lLen << 4)
+	} else {
+		dst[di] = 0xF0
+		di++
+		for lLen -= 0xF; lLen >= 0xFF; lLen -= 0xFF {
+			dst[di] = 0xFF
+			di++
+		}
+		dst[di] = byte(lLen)
+	}
+	di++
+	di += copy(dst[di:], src)
+	return di
+}
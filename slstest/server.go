@@ -0,0 +1,656 @@
+// Package slstest provides an in-process fake of the SLS REST api backed by memory,
+// for testing code built on the sdk, the producer and the consumer library offline.
+//
+// The server implements projects, logstores, shards, PostLogStoreLogs/PutLogs with
+// lz4 or zstd bodies, GetCursor, PullLogs, consumer groups with heartbeat and checkpoint,
+// and index CRUD. Signatures are not verified, so any credentials can be used.
+//
+//	server := slstest.NewServer()
+//	defer server.Close()
+//	client := server.NewClient()
+//	client.CreateProject("test-project", "")
+//
+// The producer and consumer library can be pointed to the server by setting
+// Endpoint to server.Endpoint and HTTPClient to server.HTTPClient().
+package slstest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/gogo/protobuf/proto"
+)
+
+// Server is an in-process fake SLS server
+type Server struct {
+	// Endpoint is the endpoint to create clients with, eg. "127.0.0.1:12345"
+	Endpoint string
+	// URL is the base url of the server, eg. "http://127.0.0.1:12345"
+	URL string
+
+	server    *httptest.Server
+	mu        sync.Mutex
+	projects  map[string]*project
+	requestID uint64
+}
+
+// NewServer starts and returns a new Server, the caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		projects: map[string]*project{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	s.Endpoint = strings.TrimPrefix(s.server.URL, "http://")
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// HTTPClient returns a http client which sends requests of all projects to the server.
+// The sdk puts the project name into the host, eg. "my-project.127.0.0.1:12345",
+// which can not be resolved without it.
+func (s *Server) HTTPClient() *http.Client {
+	addr := s.server.Listener.Addr().String()
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+// NewClient returns a client connected to the server
+func (s *Server) NewClient() sls.ClientInterface {
+	client := sls.CreateNormalInterface(s.Endpoint, "slstest-access-key-id", "slstest-access-key-secret", "")
+	client.SetHTTPClient(s.HTTPClient())
+	return client
+}
+
+// LogGroups returns the log groups written to the shard in order, nil if the shard does not exist
+func (s *Server) LogGroups(projectName, logstoreName string, shardID int) []*sls.LogGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[projectName]
+	if !ok {
+		return nil
+	}
+	ls, ok := p.logstores[logstoreName]
+	if !ok {
+		return nil
+	}
+	shard := ls.getShard(shardID)
+	if shard == nil {
+		return nil
+	}
+	logGroups := make([]*sls.LogGroup, 0, len(shard.logGroups))
+	for _, stored := range shard.logGroups {
+		logGroups = append(logGroups, stored.logGroup)
+	}
+	return logGroups
+}
+
+// Logs returns all logs written to the logstore, ordered by shard
+func (s *Server) Logs(projectName, logstoreName string) []*sls.Log {
+	s.mu.Lock()
+	var shards int
+	if p, ok := s.projects[projectName]; ok {
+		if ls, ok := p.logstores[logstoreName]; ok {
+			shards = len(ls.shards)
+		}
+	}
+	s.mu.Unlock()
+	var logs []*sls.Log
+	for i := 0; i < shards; i++ {
+		for _, logGroup := range s.LogGroups(projectName, logstoreName, i) {
+			logs = append(logs, logGroup.Logs...)
+		}
+	}
+	return logs
+}
+
+// SetShardReadOnly marks the shard read-only like the parent of a split,
+// no more logs are written to it. It returns false if the shard does not exist.
+func (s *Server) SetShardReadOnly(projectName, logstoreName string, shardID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[projectName]
+	if !ok {
+		return false
+	}
+	ls, ok := p.logstores[logstoreName]
+	if !ok {
+		return false
+	}
+	shard := ls.getShard(shardID)
+	if shard == nil {
+		return false
+	}
+	shard.meta.Status = "readonly"
+	return true
+}
+
+// serverError is the error returned to clients
+type serverError struct {
+	httpCode int
+	Code     string `json:"errorCode"`
+	Message  string `json:"errorMessage"`
+}
+
+func (e *serverError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func newError(httpCode int, code, format string, args ...interface{}) *serverError {
+	return &serverError{httpCode: httpCode, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func invalidParameter(format string, args ...interface{}) *serverError {
+	return newError(http.StatusBadRequest, "ParameterInvalid", format, args...)
+}
+
+// request is a parsed request from the sdk
+type request struct {
+	*http.Request
+	project  string
+	segments []string // segments of path, eg. ["logstores", "test", "shards"]
+	body     []byte
+}
+
+// response is written to the client as json unless raw is set
+type response struct {
+	body    interface{}
+	raw     []byte
+	headers map[string]string
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := fmt.Sprintf("slstest-%d", atomic.AddUint64(&s.requestID, 1))
+	w.Header().Set(sls.RequestIDHeader, requestID)
+
+	resp, err := s.handle(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.httpCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+	for k, v := range resp.headers {
+		w.Header().Set(k, v)
+	}
+	if resp.raw != nil {
+		w.Write(resp.raw)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if resp.body == nil {
+		return
+	}
+	json.NewEncoder(w).Encode(resp.body)
+}
+
+func (s *Server) handle(r *http.Request) (*response, *serverError) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, invalidParameter("failed to read body: %v", err)
+	}
+	req := &request{
+		Request: r,
+		project: projectOfHost(r.Host, s.Endpoint),
+		body:    body,
+	}
+	if path := strings.Trim(r.URL.Path, "/"); path != "" {
+		req.segments = strings.Split(path, "/")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(req.segments) == 0 {
+		return s.handleProject(req)
+	}
+	p, ok := s.projects[req.project]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "ProjectNotExist", "The Project does not exist : %s", req.project)
+	}
+	switch req.segments[0] {
+	case "logstores":
+		return s.handleLogstores(req, p)
+	case "prometheus":
+		return s.handlePrometheus(req, p)
+	}
+	return nil, notSupported(req)
+}
+
+// handlePrometheus serves the metric store urls, eg. /prometheus/{project}/{metricstore}/api/v1/write
+func (s *Server) handlePrometheus(req *request, p *project) (*response, *serverError) {
+	if len(req.segments) < 6 || req.segments[3] != "api" || req.segments[4] != "v1" {
+		return nil, notSupported(req)
+	}
+	ls, ok := p.logstores[req.segments[2]]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "LogStoreNotExist", "logstore %s does not exist", req.segments[2])
+	}
+	if req.segments[5] == "write" && req.Method == http.MethodPost {
+		return s.postLogs(req, ls, "")
+	}
+	return nil, notSupported(req)
+}
+
+func notSupported(req *request) *serverError {
+	return invalidParameter("slstest does not support %s %s", req.Method, req.URL.Path)
+}
+
+// projectOfHost returns the project in host, eg. "my-project.127.0.0.1:12345" -> "my-project"
+func projectOfHost(host, endpoint string) string {
+	if host == endpoint {
+		return ""
+	}
+	return strings.TrimSuffix(host, "."+endpoint)
+}
+
+func decodeJSON(req *request, v interface{}) *serverError {
+	if err := json.Unmarshal(req.body, v); err != nil {
+		return invalidParameter("invalid json body: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) handleProject(req *request) (*response, *serverError) {
+	if req.project == "" {
+		if req.Method != http.MethodGet {
+			return nil, notSupported(req)
+		}
+		projects := make([]*project, 0, len(s.projects))
+		for _, p := range s.projects {
+			projects = append(projects, p)
+		}
+		return &response{body: map[string]interface{}{
+			"projects": projects,
+			"count":    len(projects),
+			"total":    len(projects),
+		}}, nil
+	}
+
+	p, exist := s.projects[req.project]
+	switch req.Method {
+	case http.MethodPost:
+		if exist {
+			return nil, newError(http.StatusBadRequest, "ProjectAlreadyExist", "Project %s already exist", req.project)
+		}
+		var body struct {
+			Description string `json:"description"`
+		}
+		if err := decodeJSON(req, &body); err != nil {
+			return nil, err
+		}
+		s.projects[req.project] = newProject(req.project, body.Description)
+		return &response{}, nil
+	}
+	if !exist {
+		return nil, newError(http.StatusNotFound, "ProjectNotExist", "The Project does not exist : %s", req.project)
+	}
+	switch req.Method {
+	case http.MethodGet:
+		return &response{body: p}, nil
+	case http.MethodPut:
+		var body struct {
+			Description string `json:"description"`
+		}
+		if err := decodeJSON(req, &body); err != nil {
+			return nil, err
+		}
+		p.Description = body.Description
+		return &response{}, nil
+	case http.MethodDelete:
+		delete(s.projects, req.project)
+		return &response{}, nil
+	}
+	return nil, notSupported(req)
+}
+
+func (s *Server) handleLogstores(req *request, p *project) (*response, *serverError) {
+	if len(req.segments) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			names := make([]string, 0, len(p.logstores))
+			for name := range p.logstores {
+				names = append(names, name)
+			}
+			return &response{body: map[string]interface{}{
+				"logstores": names,
+				"count":     len(names),
+				"total":     len(names),
+			}}, nil
+		case http.MethodPost:
+			var meta sls.LogStore
+			if err := decodeJSON(req, &meta); err != nil {
+				return nil, err
+			}
+			if meta.Name == "" {
+				return nil, invalidParameter("logstoreName is empty")
+			}
+			if _, ok := p.logstores[meta.Name]; ok {
+				return nil, newError(http.StatusBadRequest, "LogStoreAlreadyExist", "logstore %s already exist", meta.Name)
+			}
+			p.logstores[meta.Name] = newLogstore(meta)
+			return &response{}, nil
+		}
+		return nil, notSupported(req)
+	}
+
+	name := req.segments[1]
+	ls, ok := p.logstores[name]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "LogStoreNotExist", "logstore %s does not exist", name)
+	}
+	if len(req.segments) == 2 {
+		switch req.Method {
+		case http.MethodGet:
+			return &response{body: ls.meta}, nil
+		case http.MethodPut:
+			var meta sls.LogStore
+			if err := decodeJSON(req, &meta); err != nil {
+				return nil, err
+			}
+			// shards can only be changed by split and merge
+			meta.Name, meta.ShardCount, meta.CreateTime = ls.meta.Name, ls.meta.ShardCount, ls.meta.CreateTime
+			ls.meta = meta
+			return &response{}, nil
+		case http.MethodDelete:
+			delete(p.logstores, name)
+			return &response{}, nil
+		case http.MethodPost:
+			return s.postLogs(req, ls, "")
+		}
+		return nil, notSupported(req)
+	}
+
+	switch req.segments[2] {
+	case "shards":
+		return s.handleShards(req, ls)
+	case "index":
+		return s.handleIndex(req, ls)
+	case "consumergroups":
+		return s.handleConsumerGroups(req, ls)
+	}
+	return nil, notSupported(req)
+}
+
+func (s *Server) handleShards(req *request, ls *logstore) (*response, *serverError) {
+	if len(req.segments) == 3 {
+		if req.Method != http.MethodGet {
+			return nil, notSupported(req)
+		}
+		shards := make([]sls.Shard, 0, len(ls.shards))
+		for _, shard := range ls.shards {
+			shards = append(shards, shard.meta)
+		}
+		return &response{body: shards}, nil
+	}
+	if len(req.segments) != 4 {
+		return nil, notSupported(req)
+	}
+	switch req.segments[3] {
+	case "lb":
+		if req.Method == http.MethodPost {
+			return s.postLogs(req, ls, "")
+		}
+	case "route":
+		if req.Method == http.MethodPost {
+			return s.postLogs(req, ls, req.URL.Query().Get("key"))
+		}
+	default:
+		if req.Method != http.MethodGet {
+			break
+		}
+		id, err := strconv.Atoi(req.segments[3])
+		shard := ls.getShard(id)
+		if err != nil || shard == nil {
+			return nil, newError(http.StatusNotFound, "ShardNotExist", "shard %s does not exist", req.segments[3])
+		}
+		switch req.URL.Query().Get("type") {
+		case "cursor":
+			return getCursor(req, shard)
+		case "logs", "log":
+			return pullLogs(req, shard)
+		}
+	}
+	return nil, notSupported(req)
+}
+
+func getCursor(req *request, shard *shard) (*response, *serverError) {
+	offset, err := shard.cursorOf(req.URL.Query().Get("from"))
+	if err != nil {
+		return nil, invalidParameter("%v", err)
+	}
+	return &response{body: map[string]string{"cursor": encodeCursor(offset)}}, nil
+}
+
+func (s *Server) postLogs(req *request, ls *logstore, key string) (*response, *serverError) {
+	raw, err := decompress(req.body, req.Header.Get("x-log-compresstype"), req.Header.Get("x-log-bodyrawsize"))
+	if err != nil {
+		return nil, invalidParameter("%v", err)
+	}
+	logGroup := &sls.LogGroup{}
+	if err := proto.Unmarshal(raw, logGroup); err != nil {
+		return nil, invalidParameter("invalid log group: %v", err)
+	}
+	shard := ls.next()
+	if key != "" {
+		shard = ls.route(key)
+	}
+	if shard == nil || shard.readOnly() {
+		return nil, newError(http.StatusForbidden, "ShardReadOnly", "no writable shard for the logs")
+	}
+	shard.append(logGroup)
+	return &response{}, nil
+}
+
+func pullLogs(req *request, shard *shard) (*response, *serverError) {
+	query := req.URL.Query()
+	if query.Get("query") != "" {
+		return nil, invalidParameter("slstest does not support pulling logs with query")
+	}
+	begin, err := decodeCursor(query.Get("cursor"))
+	if err != nil {
+		return nil, invalidParameter("%v", err)
+	}
+	end := int64(len(shard.logGroups))
+	if endCursor := query.Get("end_cursor"); endCursor != "" {
+		if end, err = decodeCursor(endCursor); err != nil {
+			return nil, invalidParameter("%v", err)
+		}
+	}
+	if end > int64(len(shard.logGroups)) {
+		end = int64(len(shard.logGroups))
+	}
+	if begin > end {
+		begin = end
+	}
+	if count, err := strconv.ParseInt(query.Get("count"), 10, 64); err == nil && count > 0 && begin+count < end {
+		end = begin + count
+	}
+
+	logGroupList := &sls.LogGroupList{}
+	for _, stored := range shard.logGroups[begin:end] {
+		logGroupList.LogGroups = append(logGroupList.LogGroups, stored.logGroup)
+	}
+	headers := map[string]string{
+		"X-Log-Cursor":       encodeCursor(end),
+		"X-Log-Count":        strconv.FormatInt(end-begin, 10),
+		"X-Log-Bodyrawsize":  "0",
+		"X-Log-Compresstype": "lz4",
+	}
+	if end == begin {
+		return &response{raw: []byte{}, headers: headers}, nil
+	}
+	raw, err := proto.Marshal(logGroupList)
+	if err != nil {
+		return nil, newError(http.StatusInternalServerError, "InternalServerError", "%v", err)
+	}
+	compressType := "lz4"
+	if req.Header.Get("Accept-Encoding") == "zstd" {
+		compressType = "zstd"
+	}
+	out, err := compress(raw, compressType)
+	if err != nil {
+		return nil, newError(http.StatusInternalServerError, "InternalServerError", "%v", err)
+	}
+	headers["X-Log-Bodyrawsize"] = strconv.Itoa(len(raw))
+	headers["X-Log-Compresstype"] = compressType
+	headers["X-Log-Read-Last-Cursor"] = strconv.FormatInt(end-1, 10)
+	return &response{raw: out, headers: headers}, nil
+}
+
+func (s *Server) handleIndex(req *request, ls *logstore) (*response, *serverError) {
+	if len(req.segments) != 3 {
+		return nil, notSupported(req)
+	}
+	switch req.Method {
+	case http.MethodPost:
+		if ls.index != nil {
+			return nil, newError(http.StatusBadRequest, "IndexAlreadyExist", "log store index is already created")
+		}
+		return setIndex(req, ls)
+	}
+	if ls.index == nil {
+		return nil, newError(http.StatusNotFound, "IndexConfigNotExist", "index config doesn't exist")
+	}
+	switch req.Method {
+	case http.MethodGet:
+		return &response{raw: ls.index, headers: map[string]string{"Content-Type": "application/json"}}, nil
+	case http.MethodPut:
+		return setIndex(req, ls)
+	case http.MethodDelete:
+		ls.index = nil
+		return &response{}, nil
+	}
+	return nil, notSupported(req)
+}
+
+func setIndex(req *request, ls *logstore) (*response, *serverError) {
+	var index json.RawMessage
+	if err := decodeJSON(req, &index); err != nil {
+		return nil, err
+	}
+	ls.index = index
+	return &response{}, nil
+}
+
+func (s *Server) handleConsumerGroups(req *request, ls *logstore) (*response, *serverError) {
+	if len(req.segments) == 3 {
+		switch req.Method {
+		case http.MethodGet:
+			groups := make([]*consumerGroup, 0, len(ls.consumerGroups))
+			for _, cg := range ls.consumerGroups {
+				groups = append(groups, cg)
+			}
+			return &response{body: groups}, nil
+		case http.MethodPost:
+			var cg sls.ConsumerGroup
+			if err := decodeJSON(req, &cg); err != nil {
+				return nil, err
+			}
+			if _, ok := ls.consumerGroups[cg.ConsumerGroupName]; ok {
+				return nil, newError(http.StatusBadRequest, "ConsumerGroupAlreadyExist", "consumer group %s already exist", cg.ConsumerGroupName)
+			}
+			ls.consumerGroups[cg.ConsumerGroupName] = newConsumerGroup(cg.ConsumerGroupName, cg.Timeout, cg.InOrder)
+			return &response{}, nil
+		}
+		return nil, notSupported(req)
+	}
+	if len(req.segments) != 4 {
+		return nil, notSupported(req)
+	}
+
+	name := req.segments[3]
+	cg, ok := ls.consumerGroups[name]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "ConsumerGroupNotExist", "consumer group %s does not exist", name)
+	}
+	switch req.Method {
+	case http.MethodGet:
+		checkpoints := make([]*checkpoint, 0, len(cg.checkpoints))
+		for _, shard := range ls.shards {
+			if c, ok := cg.checkpoints[shard.meta.ShardID]; ok {
+				checkpoints = append(checkpoints, c)
+			}
+		}
+		return &response{body: checkpoints}, nil
+	case http.MethodPut:
+		var body struct {
+			InOrder *bool `json:"order"`
+			Timeout int   `json:"timeout"`
+		}
+		if err := decodeJSON(req, &body); err != nil {
+			return nil, err
+		}
+		if body.InOrder != nil {
+			cg.InOrder = *body.InOrder
+		}
+		if body.Timeout > 0 {
+			cg.Timeout = body.Timeout
+		}
+		return &response{}, nil
+	case http.MethodDelete:
+		delete(ls.consumerGroups, name)
+		return &response{}, nil
+	case http.MethodPost:
+		switch req.URL.Query().Get("type") {
+		case "heartbeat":
+			return heartbeat(req, ls, cg)
+		case "checkpoint":
+			return updateCheckpoint(req, ls, cg)
+		}
+	}
+	return nil, notSupported(req)
+}
+
+func heartbeat(req *request, ls *logstore, cg *consumerGroup) (*response, *serverError) {
+	consumer := req.URL.Query().Get("consumer")
+	if consumer == "" {
+		return nil, invalidParameter("consumer is empty")
+	}
+	return &response{body: cg.heartbeat(consumer, ls.shards, time.Now())}, nil
+}
+
+func updateCheckpoint(req *request, ls *logstore, cg *consumerGroup) (*response, *serverError) {
+	var body struct {
+		Shard      int    `json:"shard"`
+		Checkpoint string `json:"checkpoint"`
+	}
+	if err := decodeJSON(req, &body); err != nil {
+		return nil, err
+	}
+	if ls.getShard(body.Shard) == nil {
+		return nil, newError(http.StatusNotFound, "ShardNotExist", "shard %d does not exist", body.Shard)
+	}
+	consumer := req.URL.Query().Get("consumer")
+	if req.URL.Query().Get("forceSuccess") != "true" && cg.owners[body.Shard] != consumer {
+		return nil, newError(http.StatusBadRequest, "ConsumerNotMatch", "shard %d is not held by consumer %s", body.Shard, consumer)
+	}
+	cg.checkpoints[body.Shard] = &checkpoint{
+		ShardID:    body.Shard,
+		CheckPoint: body.Checkpoint,
+		UpdateTime: time.Now().UnixNano() / 1000,
+		Consumer:   consumer,
+	}
+	return &response{}, nil
+}
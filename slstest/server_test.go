@@ -0,0 +1,218 @@
+package slstest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	consumerLibrary "github.com/aliyun/aliyun-log-go-sdk/consumer"
+	"github.com/aliyun/aliyun-log-go-sdk/producer"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testProject  = "test-project"
+	testLogstore = "test-logstore"
+)
+
+func newTestServer(t *testing.T, shardCount int) (*Server, sls.ClientInterface) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	client := server.NewClient()
+	_, err := client.CreateProject(testProject, "for test")
+	require.NoError(t, err)
+	require.NoError(t, client.CreateLogStore(testProject, testLogstore, 1, shardCount, false, 0))
+	return server, client
+}
+
+func newLogGroup(contents ...string) *sls.LogGroup {
+	logGroup := &sls.LogGroup{Topic: proto.String("topic")}
+	for _, content := range contents {
+		logGroup.Logs = append(logGroup.Logs, &sls.Log{
+			Time:     proto.Uint32(uint32(time.Now().Unix())),
+			Contents: []*sls.LogContent{{Key: proto.String("content"), Value: proto.String(content)}},
+		})
+	}
+	return logGroup
+}
+
+func TestProjectAndLogstore(t *testing.T) {
+	_, client := newTestServer(t, 2)
+
+	p, err := client.GetProject(testProject)
+	require.NoError(t, err)
+	assert.Equal(t, "for test", p.Description)
+	exist, err := client.CheckProjectExist("not-exist")
+	require.NoError(t, err)
+	assert.False(t, exist)
+	projects, err := client.ListProject()
+	require.NoError(t, err)
+	assert.Equal(t, []string{testProject}, projects)
+
+	logstores, err := client.ListLogStore(testProject)
+	require.NoError(t, err)
+	assert.Equal(t, []string{testLogstore}, logstores)
+	logstore, err := client.GetLogStore(testProject, testLogstore)
+	require.NoError(t, err)
+	assert.Equal(t, 2, logstore.ShardCount)
+	shards, err := client.ListShards(testProject, testLogstore)
+	require.NoError(t, err)
+	require.Len(t, shards, 2)
+	assert.Equal(t, "00000000000000000000000000000000", shards[0].InclusiveBeginKey)
+	assert.Equal(t, shards[0].ExclusiveBeginKey, shards[1].InclusiveBeginKey)
+
+	err = client.CreateLogStore(testProject, testLogstore, 1, 2, false, 0)
+	assert.Equal(t, "LogStoreAlreadyExist", err.(*sls.Error).Code)
+	exist, err = client.CheckLogstoreExist(testProject, "not-exist")
+	require.NoError(t, err)
+	assert.False(t, exist)
+
+	_, err = client.GetIndex(testProject, testLogstore)
+	assert.Equal(t, "IndexConfigNotExist", err.(*sls.Error).Code)
+	require.NoError(t, client.CreateIndex(testProject, testLogstore, *sls.CreateDefaultIndex()))
+	index, err := client.GetIndex(testProject, testLogstore)
+	require.NoError(t, err)
+	assert.NotNil(t, index.Line)
+	require.NoError(t, client.DeleteIndex(testProject, testLogstore))
+
+	require.NoError(t, client.DeleteLogStore(testProject, testLogstore))
+	exist, err = client.CheckLogstoreExist(testProject, testLogstore)
+	require.NoError(t, err)
+	assert.False(t, exist)
+}
+
+func TestPostAndPullLogs(t *testing.T) {
+	server, client := newTestServer(t, 1)
+
+	for i, compressType := range []int{sls.Compress_LZ4, sls.Compress_ZSTD, sls.Compress_None} {
+		err := client.PostLogStoreLogsV2(testProject, testLogstore, &sls.PostLogStoreLogsRequest{
+			LogGroup:     newLogGroup(fmt.Sprintf("log-%d", i)),
+			CompressType: compressType,
+		})
+		require.NoError(t, err)
+	}
+	require.Len(t, server.Logs(testProject, testLogstore), 3)
+
+	begin, err := client.GetCursor(testProject, testLogstore, 0, "begin")
+	require.NoError(t, err)
+	end, err := client.GetCursor(testProject, testLogstore, 0, "end")
+	require.NoError(t, err)
+
+	for _, compressType := range []int{sls.Compress_LZ4, sls.Compress_ZSTD} {
+		logGroupList, plm, err := client.PullLogsWithQuery(&sls.PullLogRequest{
+			Project:          testProject,
+			Logstore:         testLogstore,
+			ShardID:          0,
+			Cursor:           begin,
+			LogGroupMaxCount: 2,
+			CompressType:     compressType,
+		})
+		require.NoError(t, err)
+		require.Len(t, logGroupList.LogGroups, 2)
+		assert.Equal(t, "log-1", logGroupList.LogGroups[1].Logs[0].Contents[0].GetValue())
+		assert.NotEmpty(t, logGroupList.LogGroups[1].GetCursor())
+
+		logGroupList, plm, err = client.PullLogsWithQuery(&sls.PullLogRequest{
+			Project:          testProject,
+			Logstore:         testLogstore,
+			ShardID:          0,
+			Cursor:           plm.NextCursor,
+			LogGroupMaxCount: 2,
+			CompressType:     compressType,
+		})
+		require.NoError(t, err)
+		require.Len(t, logGroupList.LogGroups, 1)
+		assert.Equal(t, end, plm.NextCursor)
+	}
+
+	_, _, err = client.PullLogsWithQuery(&sls.PullLogRequest{
+		Project:          testProject,
+		Logstore:         testLogstore,
+		ShardID:          0,
+		Cursor:           end,
+		LogGroupMaxCount: 2,
+	})
+	require.NoError(t, err)
+}
+
+func TestRouteByHashKey(t *testing.T) {
+	server, client := newTestServer(t, 2)
+	for _, key := range []string{"00", "ff"} {
+		key := key
+		err := client.PostLogStoreLogsV2(testProject, testLogstore, &sls.PostLogStoreLogsRequest{
+			LogGroup: newLogGroup(key),
+			HashKey:  &key,
+		})
+		require.NoError(t, err)
+	}
+	assert.Len(t, server.LogGroups(testProject, testLogstore, 0), 1)
+	assert.Len(t, server.LogGroups(testProject, testLogstore, 1), 1)
+	assert.Equal(t, "ff", server.LogGroups(testProject, testLogstore, 1)[0].Logs[0].Contents[0].GetValue())
+}
+
+func TestProducer(t *testing.T) {
+	server, _ := newTestServer(t, 2)
+
+	config := producer.GetDefaultProducerConfig()
+	config.Endpoint = server.Endpoint
+	config.HTTPClient = server.HTTPClient()
+	config.CredentialsProvider = sls.NewStaticCredentialsProvider("id", "secret", "")
+	config.LingerMs = 100
+	p, err := producer.NewProducer(config)
+	require.NoError(t, err)
+	p.Start()
+	for i := 0; i < 100; i++ {
+		log := producer.GenerateLog(uint32(time.Now().Unix()), map[string]string{"index": fmt.Sprint(i)})
+		require.NoError(t, p.SendLog(testProject, testLogstore, "topic", "127.0.0.1", log))
+	}
+	p.SafeClose()
+
+	assert.Len(t, server.Logs(testProject, testLogstore), 100)
+}
+
+func TestConsumer(t *testing.T) {
+	server, client := newTestServer(t, 2)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, client.PutLogs(testProject, testLogstore, newLogGroup(fmt.Sprint(i))))
+	}
+
+	var mu sync.Mutex
+	consumed := map[string]bool{}
+	worker := consumerLibrary.InitConsumerWorkerWithCheckpointTracker(consumerLibrary.LogHubConfig{
+		Endpoint:                  server.Endpoint,
+		HTTPClient:                server.HTTPClient(),
+		AccessKeyID:               "id",
+		AccessKeySecret:           "secret",
+		Project:                   testProject,
+		Logstore:                  testLogstore,
+		ConsumerGroupName:         "test-group",
+		ConsumerName:              "test-consumer",
+		CursorPosition:            consumerLibrary.BEGIN_CURSOR,
+		HeartbeatIntervalInSecond: 1,
+		AllowLogLevel:             "error",
+	}, func(shard int, logGroupList *sls.LogGroupList, tracker consumerLibrary.CheckPointTracker) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, logGroup := range logGroupList.LogGroups {
+			for _, log := range logGroup.Logs {
+				consumed[log.Contents[0].GetValue()] = true
+			}
+		}
+		return "", tracker.SaveCheckPoint(true)
+	})
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 10
+	}, 10*time.Second, 100*time.Millisecond)
+	worker.StopAndWait()
+
+	checkpoints, err := client.GetCheckpoint(testProject, testLogstore, "test-group")
+	require.NoError(t, err)
+	assert.Len(t, checkpoints, 2)
+}
@@ -0,0 +1,252 @@
+package slstest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+)
+
+type project struct {
+	Name           string `json:"projectName"`
+	Description    string `json:"description"`
+	Status         string `json:"status"`
+	Region         string `json:"region"`
+	CreateTime     string `json:"createTime"`
+	LastModifyTime string `json:"lastModifyTime"`
+
+	logstores map[string]*logstore
+}
+
+func newProject(name, description string) *project {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	return &project{
+		Name:           name,
+		Description:    description,
+		Status:         "Normal",
+		CreateTime:     now,
+		LastModifyTime: now,
+		logstores:      map[string]*logstore{},
+	}
+}
+
+type logstore struct {
+	meta           sls.LogStore
+	shards         []*shard
+	index          json.RawMessage // nil if index is not created
+	consumerGroups map[string]*consumerGroup
+	nextShard      int // round robin shard of PostLogStoreLogs without hash key
+}
+
+func newLogstore(meta sls.LogStore) *logstore {
+	if meta.ShardCount <= 0 {
+		meta.ShardCount = 2
+	}
+	now := uint32(time.Now().Unix())
+	meta.CreateTime = now
+	meta.LastModifyTime = now
+	s := &logstore{
+		meta:           meta,
+		consumerGroups: map[string]*consumerGroup{},
+	}
+	// split the md5 key space evenly, like the server does
+	space := new(big.Int).Lsh(big.NewInt(1), 128)
+	step := new(big.Int).Div(space, big.NewInt(int64(meta.ShardCount)))
+	for i := 0; i < meta.ShardCount; i++ {
+		begin := new(big.Int).Mul(step, big.NewInt(int64(i)))
+		end := new(big.Int).Add(begin, step)
+		if i == meta.ShardCount-1 {
+			end = space
+		}
+		s.shards = append(s.shards, &shard{
+			meta: sls.Shard{
+				ShardID:           i,
+				Status:            "readwrite",
+				InclusiveBeginKey: hashKey(begin),
+				ExclusiveBeginKey: hashKey(end),
+				CreateTime:        int(now),
+			},
+		})
+	}
+	return s
+}
+
+func hashKey(i *big.Int) string {
+	if i.BitLen() > 128 {
+		return "ffffffffffffffffffffffffffffffff"
+	}
+	return fmt.Sprintf("%032x", i)
+}
+
+// route returns the shard whose key range contains key
+func (s *logstore) route(key string) *shard {
+	key = strings.ToLower(key)
+	if len(key) < 32 {
+		key += strings.Repeat("0", 32-len(key))
+	}
+	for _, shard := range s.shards {
+		if key >= shard.meta.InclusiveBeginKey && key < shard.meta.ExclusiveBeginKey {
+			return shard
+		}
+	}
+	return s.shards[len(s.shards)-1]
+}
+
+// next returns the next writable shard in round robin, nil if all shards are read-only
+func (s *logstore) next() *shard {
+	for range s.shards {
+		shard := s.shards[s.nextShard%len(s.shards)]
+		s.nextShard++
+		if !shard.readOnly() {
+			return shard
+		}
+	}
+	return nil
+}
+
+func (s *logstore) getShard(id int) *shard {
+	if id < 0 || id >= len(s.shards) {
+		return nil
+	}
+	return s.shards[id]
+}
+
+type storedLogGroup struct {
+	receiveTime int64 // unix seconds
+	logGroup    *sls.LogGroup
+}
+
+type shard struct {
+	meta      sls.Shard
+	logGroups []storedLogGroup
+}
+
+func (s *shard) readOnly() bool {
+	return s.meta.Status == "readonly"
+}
+
+func (s *shard) append(logGroup *sls.LogGroup) {
+	s.logGroups = append(s.logGroups, storedLogGroup{
+		receiveTime: time.Now().Unix(),
+		logGroup:    logGroup,
+	})
+}
+
+// cursorOf returns the cursor of the first log group received at or after from,
+// from is "begin", "end" or unix seconds
+func (s *shard) cursorOf(from string) (int64, error) {
+	switch from {
+	case "begin":
+		return 0, nil
+	case "end":
+		return int64(len(s.logGroups)), nil
+	}
+	t, err := strconv.ParseInt(from, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid from: %s", from)
+	}
+	i := sort.Search(len(s.logGroups), func(i int) bool {
+		return s.logGroups[i].receiveTime >= t
+	})
+	return int64(i), nil
+}
+
+// encodeCursor encodes the offset of a log group in the shard the same way the sdk decodes it
+func encodeCursor(offset int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(strconv.FormatInt(offset, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	offset, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return offset, nil
+}
+
+type checkpoint struct {
+	ShardID    int    `json:"shard"`
+	CheckPoint string `json:"checkpoint"`
+	UpdateTime int64  `json:"updateTime"`
+	Consumer   string `json:"consumer"`
+}
+
+type consumerGroup struct {
+	Name    string `json:"name"`
+	Timeout int    `json:"timeout"`
+	InOrder bool   `json:"order"`
+
+	checkpoints map[int]*checkpoint
+	heartbeats  map[string]time.Time // consumer -> last heartbeat
+	owners      map[int]string       // shard -> consumer
+}
+
+func newConsumerGroup(name string, timeout int, inOrder bool) *consumerGroup {
+	return &consumerGroup{
+		Name:        name,
+		Timeout:     timeout,
+		InOrder:     inOrder,
+		checkpoints: map[int]*checkpoint{},
+		heartbeats:  map[string]time.Time{},
+		owners:      map[int]string{},
+	}
+}
+
+// heartbeat refreshes the consumer and returns the shards assigned to it.
+// Shards of expired consumers are reassigned to the consumer holding the fewest shards.
+func (cg *consumerGroup) heartbeat(consumer string, shards []*shard, now time.Time) []int {
+	cg.heartbeats[consumer] = now
+	timeout := time.Duration(cg.Timeout) * time.Second
+	for name, last := range cg.heartbeats {
+		if timeout > 0 && now.Sub(last) > timeout {
+			delete(cg.heartbeats, name)
+		}
+	}
+	held := map[string]int{}
+	for name := range cg.heartbeats {
+		held[name] = 0
+	}
+	for shard, owner := range cg.owners {
+		if _, ok := cg.heartbeats[owner]; ok {
+			held[owner]++
+		} else {
+			delete(cg.owners, shard)
+		}
+	}
+	names := make([]string, 0, len(held))
+	for name := range held {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, shard := range shards {
+		id := shard.meta.ShardID
+		if _, ok := cg.owners[id]; ok {
+			continue
+		}
+		owner := names[0]
+		for _, name := range names {
+			if held[name] < held[owner] {
+				owner = name
+			}
+		}
+		cg.owners[id] = owner
+		held[owner]++
+	}
+	assigned := []int{}
+	for _, shard := range shards {
+		if cg.owners[shard.meta.ShardID] == consumer {
+			assigned = append(assigned, shard.meta.ShardID)
+		}
+	}
+	return assigned
+}
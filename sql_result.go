@@ -0,0 +1,483 @@
+package sls
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnKind is the Go type of the values of a column
+type ColumnKind int
+
+const (
+	// ColumnString values are string
+	ColumnString ColumnKind = iota
+	// ColumnInt values are int64
+	ColumnInt
+	// ColumnFloat values are float64
+	ColumnFloat
+	// ColumnBool values are bool
+	ColumnBool
+	// ColumnTime values are time.Time
+	ColumnTime
+)
+
+// String returns the name of kind
+func (k ColumnKind) String() string {
+	switch k {
+	case ColumnInt:
+		return "int"
+	case ColumnFloat:
+		return "float"
+	case ColumnBool:
+		return "bool"
+	case ColumnTime:
+		return "time"
+	default:
+		return "string"
+	}
+}
+
+// ColumnDesc describes a column of SQLResult
+type ColumnDesc struct {
+	Name string
+	// DatabaseType is the type of column in GetLogsV3ResponseMeta.ColumnTypes, eg. bigint, empty if it is not returned
+	DatabaseType string
+	// Kind is the Go type of values, by DatabaseType, or inferred from the values if DatabaseType is empty
+	Kind ColumnKind
+}
+
+// SQLResult is the rows of a query with SQL by typed columns, eg.
+//
+//	resp, err := client.GetLogsToCompletedV3(project, logstore, &sls.GetLogRequest{Query: "* | select host, count(1) as pv group by host", ...})
+//	result := sls.NewSQLResult(resp)
+//	for result.Next() {
+//		var host string
+//		var pv int64
+//		if err := result.Scan(&host, &pv); err != nil {
+//			return err
+//		}
+//	}
+//
+// SQL NULL is returned by the server as "null", it is scanned as nil into pointers and *interface{},
+// and it is an error to scan it into other types.
+type SQLResult struct {
+	Columns []ColumnDesc
+	rows    []map[string]string
+	pos     int
+}
+
+// NewSQLResult creates a SQLResult of resp. Columns are in the order of resp.Meta.Keys, the sorted keys of the logs
+// if there are no keys. The kinds of columns are by resp.Meta.ColumnTypes, or inferred from the values,
+// a column is int, float or bool if all its values are, string otherwise.
+func NewSQLResult(resp *GetLogsV3Response) *SQLResult {
+	names := resp.Meta.Keys
+	if len(names) == 0 {
+		keys := map[string]struct{}{}
+		for _, log := range resp.Logs {
+			for key := range log {
+				keys[key] = struct{}{}
+			}
+		}
+		for key := range keys {
+			names = append(names, key)
+		}
+		sort.Strings(names)
+	}
+	columns := make([]ColumnDesc, len(names))
+	for i, name := range names {
+		column := ColumnDesc{Name: name}
+		if len(resp.Meta.ColumnTypes) == len(names) {
+			column.DatabaseType = resp.Meta.ColumnTypes[i]
+			column.Kind = kindOfDatabaseType(column.DatabaseType)
+		} else {
+			column.Kind = inferColumnKind(resp.Logs, name)
+		}
+		columns[i] = column
+	}
+	return &SQLResult{Columns: columns, rows: resp.Logs, pos: -1}
+}
+
+// kindOfDatabaseType maps the type of SQL to the kind of column
+func kindOfDatabaseType(databaseType string) ColumnKind {
+	t := strings.ToLower(databaseType)
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = t[:i]
+	}
+	switch strings.TrimSpace(t) {
+	case "bigint", "integer", "int", "smallint", "tinyint", "long":
+		return ColumnInt
+	case "double", "real", "float", "decimal":
+		return ColumnFloat
+	case "boolean", "bool":
+		return ColumnBool
+	case "timestamp", "timestamp with time zone", "date", "time":
+		return ColumnTime
+	default:
+		return ColumnString
+	}
+}
+
+func inferColumnKind(rows []map[string]string, name string) ColumnKind {
+	isInt, isFloat, isBool, seen := true, true, true, false
+	for _, row := range rows {
+		value, ok := row[name]
+		if !ok || value == "" || value == "null" {
+			continue
+		}
+		seen = true
+		if isInt {
+			_, err := strconv.ParseInt(value, 10, 64)
+			isInt = err == nil
+		}
+		if isFloat {
+			_, err := strconv.ParseFloat(value, 64)
+			isFloat = err == nil
+		}
+		if isBool {
+			isBool = value == "true" || value == "false"
+		}
+	}
+	switch {
+	case !seen:
+		return ColumnString
+	case isInt:
+		return ColumnInt
+	case isFloat:
+		return ColumnFloat
+	case isBool:
+		return ColumnBool
+	default:
+		return ColumnString
+	}
+}
+
+// Len returns the number of rows
+func (r *SQLResult) Len() int {
+	return len(r.rows)
+}
+
+// Next moves to the next row, false if there are no more rows
+func (r *SQLResult) Next() bool {
+	if r.pos+1 >= len(r.rows) {
+		r.pos = len(r.rows)
+		return false
+	}
+	r.pos++
+	return true
+}
+
+// Values returns the values of the current row in the order of Columns, typed by the kinds of columns, nil for NULL
+func (r *SQLResult) Values() ([]interface{}, error) {
+	row, err := r.row()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(r.Columns))
+	for i, column := range r.Columns {
+		raw, ok := row[column.Name]
+		if !ok || raw == "null" {
+			continue
+		}
+		if values[i], err = column.value(raw); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Scan copies the columns of the current row into dest, one for each column in order. dest is a pointer to
+// string, []byte, int, uint, float, bool, time.Time, interface{}, a pointer of them for NULL, or a sql.Scanner.
+func (r *SQLResult) Scan(dest ...interface{}) error {
+	row, err := r.row()
+	if err != nil {
+		return err
+	}
+	if len(dest) != len(r.Columns) {
+		return NewClientError(fmt.Errorf("expected %d destination arguments in Scan, not %d", len(r.Columns), len(dest)))
+	}
+	for i, column := range r.Columns {
+		raw, ok := row[column.Name]
+		if err := column.scan(raw, ok && raw != "null", dest[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanStruct copies the columns of the current row into the fields of the struct dest points to.
+// A column is copied to the field tagged `sls:"name"`, or the field of the same name regardless of case,
+// columns without fields are ignored.
+func (r *SQLResult) ScanStruct(dest interface{}) error {
+	row, err := r.row()
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return NewClientError(fmt.Errorf("ScanStruct of non struct pointer %T", dest))
+	}
+	return r.scanStruct(row, v.Elem())
+}
+
+// ScanAll appends all the rows not read to the slice of structs dest points to
+func (r *SQLResult) ScanAll(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return NewClientError(fmt.Errorf("ScanAll of non slice pointer %T", dest))
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return NewClientError(fmt.Errorf("ScanAll of non struct slice %T", dest))
+	}
+	for r.Next() {
+		elem := reflect.New(elemType)
+		if err := r.scanStruct(r.rows[r.pos], elem.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return nil
+}
+
+func (r *SQLResult) row() (map[string]string, error) {
+	if r.pos < 0 || r.pos >= len(r.rows) {
+		return nil, NewClientError(fmt.Errorf("Scan called without calling Next"))
+	}
+	return r.rows[r.pos], nil
+}
+
+func (r *SQLResult) scanStruct(row map[string]string, v reflect.Value) error {
+	fields := sqlStructFields(v.Type())
+	for _, column := range r.Columns {
+		index, ok := fields[strings.ToLower(column.Name)]
+		if !ok {
+			continue
+		}
+		raw, ok := row[column.Name]
+		if err := column.scan(raw, ok && raw != "null", fieldByIndexAlloc(v, index).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlStructFields returns the index of fields by the lower case of column names, fields of embedded structs are promoted
+func sqlStructFields(t reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("sls")
+			if tag == "-" {
+				continue
+			}
+			name := strings.Split(tag, ",")[0]
+			fieldIndex := append(append([]int{}, index...), i)
+			if sf.Anonymous && name == "" {
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}) {
+					walk(ft, fieldIndex)
+					continue
+				}
+			}
+			if sf.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if _, ok := fields[strings.ToLower(name)]; !ok {
+				fields[strings.ToLower(name)] = fieldIndex
+			}
+		}
+	}
+	walk(t, nil)
+	return fields
+}
+
+// fieldByIndexAlloc returns the field of index, allocating the nil pointers of embedded structs
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// value converts raw to the Go type of column
+func (c *ColumnDesc) value(raw string) (interface{}, error) {
+	var v interface{}
+	var err error
+	switch c.Kind {
+	case ColumnInt:
+		v, err = strconv.ParseInt(raw, 10, 64)
+	case ColumnFloat:
+		v, err = strconv.ParseFloat(raw, 64)
+	case ColumnBool:
+		v, err = strconv.ParseBool(raw)
+	case ColumnTime:
+		v, err = parseSQLTime(raw)
+	default:
+		return raw, nil
+	}
+	if err != nil {
+		return nil, c.convertError(raw, c.Kind.String(), err)
+	}
+	return v, nil
+}
+
+func (c *ColumnDesc) convertError(raw, to string, err error) error {
+	return NewClientError(fmt.Errorf("converting column %s value %q to %s: %v", c.Name, raw, to, err))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// scan converts raw, or NULL if valid is false, into dest
+func (c *ColumnDesc) scan(raw string, valid bool, dest interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		if !valid {
+			return scanner.Scan(nil)
+		}
+		v, err := c.value(raw)
+		if err != nil {
+			return err
+		}
+		return scanner.Scan(v)
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return NewClientError(fmt.Errorf("destination of column %s is not a pointer", c.Name))
+	}
+	return c.assign(raw, valid, v.Elem())
+}
+
+func (c *ColumnDesc) assign(raw string, valid bool, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.Ptr:
+		if !valid {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := c.assign(raw, valid, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case v.Kind() == reflect.Interface:
+		if !valid {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		value, err := c.value(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	case !valid:
+		return NewClientError(fmt.Errorf("converting NULL of column %s to %s is unsupported", c.Name, v.Type()))
+	case v.Type() == timeType:
+		t, err := parseSQLTime(raw)
+		if err != nil {
+			return c.convertError(raw, v.Type().String(), err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return c.convertError(raw, v.Type().String(), fmt.Errorf("unsupported type"))
+		}
+		v.SetBytes([]byte(raw))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			// integral values of float columns, eg. 2.0
+			f, ferr := strconv.ParseFloat(raw, 64)
+			if ferr != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return c.convertError(raw, v.Type().String(), err)
+			}
+			n = int64(f)
+		}
+		if v.OverflowInt(n) {
+			return c.convertError(raw, v.Type().String(), fmt.Errorf("value out of range"))
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return c.convertError(raw, v.Type().String(), err)
+		}
+		if v.OverflowUint(n) {
+			return c.convertError(raw, v.Type().String(), fmt.Errorf("value out of range"))
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return c.convertError(raw, v.Type().String(), err)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.convertError(raw, v.Type().String(), err)
+		}
+		v.SetBool(b)
+	default:
+		return c.convertError(raw, v.Type().String(), fmt.Errorf("unsupported type"))
+	}
+	return nil
+}
+
+var sqlTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02",
+}
+
+// parseSQLTime parses the timestamp and date of SQL in UTC, and unix time in seconds, or milliseconds if it is too big for seconds
+func parseSQLTime(raw string) (time.Time, error) {
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if n > 1e11 || n < -1e11 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	for _, layout := range sqlTimeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format")
+}
@@ -0,0 +1,103 @@
+package sls
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLResultScan(t *testing.T) {
+	resp := &GetLogsV3Response{
+		Meta: GetLogsV3ResponseMeta{
+			HasSQL:      true,
+			Keys:        []string{"host", "pv", "latency", "ok", "t"},
+			ColumnTypes: []string{"varchar", "bigint", "double", "boolean", "timestamp"},
+		},
+		Logs: []map[string]string{
+			{"host": "a", "pv": "10", "latency": "1.5", "ok": "true", "t": "2024-01-02 03:04:05.678"},
+			{"host": "b", "pv": "null", "latency": "2", "ok": "false", "t": "null"},
+		},
+	}
+	result := NewSQLResult(resp)
+	assert.Equal(t, []ColumnDesc{
+		{Name: "host", DatabaseType: "varchar", Kind: ColumnString},
+		{Name: "pv", DatabaseType: "bigint", Kind: ColumnInt},
+		{Name: "latency", DatabaseType: "double", Kind: ColumnFloat},
+		{Name: "ok", DatabaseType: "boolean", Kind: ColumnBool},
+		{Name: "t", DatabaseType: "timestamp", Kind: ColumnTime},
+	}, result.Columns)
+
+	var host string
+	var pv *int64
+	var latency float64
+	var ok bool
+	var ts time.Time
+	assert.Error(t, result.Scan(&host, &pv, &latency, &ok, &ts), "Next is not called")
+	require.True(t, result.Next())
+	require.NoError(t, result.Scan(&host, &pv, &latency, &ok, &ts))
+	assert.Equal(t, "a", host)
+	assert.Equal(t, int64(10), *pv)
+	assert.Equal(t, 1.5, latency)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 678e6, time.UTC), ts)
+	values, err := result.Values()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", int64(10), 1.5, true, ts}, values)
+
+	require.True(t, result.Next())
+	var count int
+	var nullTime sql.NullTime
+	var value interface{}
+	require.NoError(t, result.Scan(&host, &pv, &count, &value, &nullTime))
+	assert.Nil(t, pv)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, false, value)
+	assert.False(t, nullTime.Valid)
+	assert.Error(t, result.Scan(&host, &count, &latency, &ok, &ts), "NULL to int")
+	assert.Error(t, result.Scan(&host))
+	assert.False(t, result.Next())
+}
+
+func TestSQLResultInferAndStruct(t *testing.T) {
+	resp := &GetLogsV3Response{
+		Meta: GetLogsV3ResponseMeta{HasSQL: true},
+		Logs: []map[string]string{
+			{"host": "a", "pv": "10", "avg": "1", "__time__": "1700000000"},
+			{"host": "b", "pv": "20", "avg": "2.5", "__time__": "1700000060"},
+		},
+	}
+	result := NewSQLResult(resp)
+	kinds := map[string]ColumnKind{}
+	for _, column := range result.Columns {
+		kinds[column.Name] = column.Kind
+	}
+	assert.Equal(t, map[string]ColumnKind{"host": ColumnString, "pv": ColumnInt, "avg": ColumnFloat, "__time__": ColumnInt}, kinds)
+
+	type base struct {
+		Time time.Time `sls:"__time__"`
+	}
+	type row struct {
+		base
+		Host    string
+		PV      uint32
+		Average float64 `sls:"avg"`
+		Ignored string  `sls:"-"`
+	}
+	var rows []row
+	require.NoError(t, result.ScanAll(&rows))
+	require.Len(t, rows, 2)
+	assert.Equal(t, "b", rows[1].Host)
+	assert.Equal(t, uint32(20), rows[1].PV)
+	assert.Equal(t, 2.5, rows[1].Average)
+	assert.Equal(t, int64(1700000060), rows[1].Time.Unix())
+
+	result = NewSQLResult(resp)
+	require.True(t, result.Next())
+	var r row
+	require.NoError(t, result.ScanStruct(&r))
+	assert.Equal(t, "a", r.Host)
+	assert.Error(t, result.ScanStruct(r))
+}
@@ -1,12 +1,15 @@
 package sls
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TokenAutoUpdateClient struct {
@@ -24,6 +27,11 @@ type TokenAutoUpdateClient struct {
 	lastFetch          time.Time
 	lastRetryFailCount int
 	lastRetryInterval  time.Duration
+
+	// parent and ctx are set on the client returned by WithContext,
+	// whose calls are bound to ctx and made by the live client of parent
+	parent *TokenAutoUpdateClient
+	ctx    context.Context
 }
 
 var errSTSFetchHighFrequency = errors.New("sts token fetch frequency is too high")
@@ -126,7 +134,10 @@ func (c *TokenAutoUpdateClient) processError(err error) (retry bool) {
 		return false
 	}
 	if IsTokenError(err) {
-		if fetchErr := c.fetchSTSToken(); fetchErr != nil {
+		if c.ctx != nil && c.ctx.Err() != nil {
+			return false
+		}
+		if fetchErr := c.root().fetchSTSToken(); fetchErr != nil {
 			level.Warn(Logger).Log("msg", "operation error : ", err.Error(), "fetch sts token error : ", fetchErr.Error())
 			// if fetch error, return false
 			return false
@@ -137,28 +148,80 @@ func (c *TokenAutoUpdateClient) processError(err error) (retry bool) {
 
 }
 
+// WithContext returns a client whose calls are bound to ctx.
+// The returned client makes calls by the live client of c, so it keeps using the token refreshed by c,
+// and the token refreshed by it on `Unauthorized` is also used by c.
+// If the underlying client does not support context, ctx only stops refreshing token on `Unauthorized`.
+func (c *TokenAutoUpdateClient) WithContext(ctx context.Context) ClientInterface {
+	if ctx == nil {
+		panic("nil context")
+	}
+	root := c.root()
+	return &TokenAutoUpdateClient{
+		logClient:   root.logClient,
+		shutdown:    root.shutdown,
+		maxTryTimes: root.maxTryTimes,
+		parent:      root,
+		ctx:         ctx,
+	}
+}
+
+// root returns the client which refreshes token, it is c itself unless c is returned by WithContext
+func (c *TokenAutoUpdateClient) root() *TokenAutoUpdateClient {
+	if c.parent != nil {
+		return c.parent
+	}
+	return c
+}
+
+// client returns the client to make calls by, which is bound to ctx of c if any
+func (c *TokenAutoUpdateClient) client() ClientInterface {
+	if c.ctx == nil {
+		return c.logClient
+	}
+	if logClient, ok := c.logClient.(ClientWithContext); ok {
+		return logClient.WithContext(c.ctx)
+	}
+	return c.logClient
+}
+
 func (c *TokenAutoUpdateClient) SetUserAgent(userAgent string) {
-	c.logClient.SetUserAgent(userAgent)
+	c.root().logClient.SetUserAgent(userAgent)
 }
 
 // SetHTTPClient set a custom http client, all request will send to sls by this client
 func (c *TokenAutoUpdateClient) SetHTTPClient(client *http.Client) {
-	c.logClient.SetHTTPClient(client)
+	c.root().logClient.SetHTTPClient(client)
 }
 
 // SetRetryTimeout set retry timeout
 func (c *TokenAutoUpdateClient) SetRetryTimeout(timeout time.Duration) {
-	c.logClient.SetRetryTimeout(timeout)
+	c.root().logClient.SetRetryTimeout(timeout)
+}
+
+// WithTracerProvider enables OpenTelemetry tracing of the underlying client and returns the same client.
+func (c *TokenAutoUpdateClient) WithTracerProvider(tp trace.TracerProvider) *TokenAutoUpdateClient {
+	if client, ok := c.root().logClient.(*Client); ok {
+		client.WithTracerProvider(tp)
+	}
+	return c
+}
+
+// SetRetryPolicy set retry policy of the underlying client, nil means the default retry behavior
+func (c *TokenAutoUpdateClient) SetRetryPolicy(policy *RetryPolicy) {
+	if client, ok := c.root().logClient.(*Client); ok {
+		client.SetRetryPolicy(policy)
+	}
 }
 
 // SetAuthVersion set auth version that the client used
 func (c *TokenAutoUpdateClient) SetAuthVersion(version AuthVersionType) {
-	c.logClient.SetAuthVersion(version)
+	c.root().logClient.SetAuthVersion(version)
 }
 
 // SetRegion set a region, must be set if using signature version v4
 func (c *TokenAutoUpdateClient) SetRegion(region string) {
-	c.logClient.SetRegion(region)
+	c.root().logClient.SetRegion(region)
 }
 
 func (c *TokenAutoUpdateClient) Close() error {
@@ -172,7 +235,7 @@ func (c *TokenAutoUpdateClient) ResetAccessKeyToken(accessKeyID, accessKeySecret
 
 func (c *TokenAutoUpdateClient) CreateProject(name, description string) (prj *LogProject, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		prj, err = c.logClient.CreateProject(name, description)
+		prj, err = c.client().CreateProject(name, description)
 		if !c.processError(err) {
 			return
 		}
@@ -182,7 +245,7 @@ func (c *TokenAutoUpdateClient) CreateProject(name, description string) (prj *Lo
 
 func (c *TokenAutoUpdateClient) CreateProjectV2(name, description, dataRedundancyType string) (prj *LogProject, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		prj, err = c.logClient.CreateProjectV2(name, description, dataRedundancyType)
+		prj, err = c.client().CreateProjectV2(name, description, dataRedundancyType)
 		if !c.processError(err) {
 			return
 		}
@@ -193,7 +256,7 @@ func (c *TokenAutoUpdateClient) CreateProjectV2(name, description, dataRedundanc
 // UpdateProject create a new loghub project.
 func (c *TokenAutoUpdateClient) UpdateProject(name, description string) (prj *LogProject, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		prj, err = c.logClient.UpdateProject(name, description)
+		prj, err = c.client().UpdateProject(name, description)
 		if !c.processError(err) {
 			return
 		}
@@ -203,7 +266,7 @@ func (c *TokenAutoUpdateClient) UpdateProject(name, description string) (prj *Lo
 
 func (c *TokenAutoUpdateClient) GetProject(name string) (prj *LogProject, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		prj, err = c.logClient.GetProject(name)
+		prj, err = c.client().GetProject(name)
 		if !c.processError(err) {
 			return
 		}
@@ -213,7 +276,7 @@ func (c *TokenAutoUpdateClient) GetProject(name string) (prj *LogProject, err er
 
 func (c *TokenAutoUpdateClient) ListProject() (projectNames []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		projectNames, err = c.logClient.ListProject()
+		projectNames, err = c.client().ListProject()
 		if !c.processError(err) {
 			return
 		}
@@ -223,7 +286,7 @@ func (c *TokenAutoUpdateClient) ListProject() (projectNames []string, err error)
 
 func (c *TokenAutoUpdateClient) ListProjectV2(offset, size int) (projects []LogProject, count, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		projects, count, total, err = c.logClient.ListProjectV2(offset, size)
+		projects, count, total, err = c.client().ListProjectV2(offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -233,7 +296,7 @@ func (c *TokenAutoUpdateClient) ListProjectV2(offset, size int) (projects []LogP
 
 func (c *TokenAutoUpdateClient) CheckProjectExist(name string) (ok bool, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ok, err = c.logClient.CheckProjectExist(name)
+		ok, err = c.client().CheckProjectExist(name)
 		if !c.processError(err) {
 			return
 		}
@@ -243,7 +306,7 @@ func (c *TokenAutoUpdateClient) CheckProjectExist(name string) (ok bool, err err
 
 func (c *TokenAutoUpdateClient) DeleteProject(name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteProject(name)
+		err = c.client().DeleteProject(name)
 		if !c.processError(err) {
 			return
 		}
@@ -253,7 +316,7 @@ func (c *TokenAutoUpdateClient) DeleteProject(name string) (err error) {
 
 func (c *TokenAutoUpdateClient) ListLogStore(project string) (logstoreList []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		logstoreList, err = c.logClient.ListLogStore(project)
+		logstoreList, err = c.client().ListLogStore(project)
 		if !c.processError(err) {
 			return
 		}
@@ -263,7 +326,7 @@ func (c *TokenAutoUpdateClient) ListLogStore(project string) (logstoreList []str
 
 func (c *TokenAutoUpdateClient) ListLogStoreV2(project string, offset, size int, telemetryType string) (logstoreList []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		logstoreList, err = c.logClient.ListLogStoreV2(project, offset, size, telemetryType)
+		logstoreList, err = c.client().ListLogStoreV2(project, offset, size, telemetryType)
 		if !c.processError(err) {
 			return
 		}
@@ -273,7 +336,7 @@ func (c *TokenAutoUpdateClient) ListLogStoreV2(project string, offset, size int,
 
 func (c *TokenAutoUpdateClient) GetLogStore(project string, logstore string) (logstoreRst *LogStore, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		logstoreRst, err = c.logClient.GetLogStore(project, logstore)
+		logstoreRst, err = c.client().GetLogStore(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -283,7 +346,7 @@ func (c *TokenAutoUpdateClient) GetLogStore(project string, logstore string) (lo
 
 func (c *TokenAutoUpdateClient) CreateLogStore(project string, logstore string, ttl, shardCnt int, autoSplit bool, maxSplitShard int) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateLogStore(project, logstore, ttl, shardCnt, autoSplit, maxSplitShard)
+		err = c.client().CreateLogStore(project, logstore, ttl, shardCnt, autoSplit, maxSplitShard)
 		if !c.processError(err) {
 			return
 		}
@@ -293,7 +356,7 @@ func (c *TokenAutoUpdateClient) CreateLogStore(project string, logstore string,
 
 func (c *TokenAutoUpdateClient) CreateLogStoreV2(project string, logstore *LogStore) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateLogStoreV2(project, logstore)
+		err = c.client().CreateLogStoreV2(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -303,7 +366,7 @@ func (c *TokenAutoUpdateClient) CreateLogStoreV2(project string, logstore *LogSt
 
 func (c *TokenAutoUpdateClient) DeleteLogStore(project string, logstore string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteLogStore(project, logstore)
+		err = c.client().DeleteLogStore(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -313,7 +376,7 @@ func (c *TokenAutoUpdateClient) DeleteLogStore(project string, logstore string)
 
 func (c *TokenAutoUpdateClient) UpdateLogStore(project string, logstore string, ttl, shardCnt int) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateLogStore(project, logstore, ttl, shardCnt)
+		err = c.client().UpdateLogStore(project, logstore, ttl, shardCnt)
 		if !c.processError(err) {
 			return
 		}
@@ -323,7 +386,7 @@ func (c *TokenAutoUpdateClient) UpdateLogStore(project string, logstore string,
 
 func (c *TokenAutoUpdateClient) UpdateLogStoreV2(project string, logstore *LogStore) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateLogStoreV2(project, logstore)
+		err = c.client().UpdateLogStoreV2(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -333,7 +396,7 @@ func (c *TokenAutoUpdateClient) UpdateLogStoreV2(project string, logstore *LogSt
 
 func (c *TokenAutoUpdateClient) ListMachineGroup(project string, offset, size int) (m []string, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		m, total, err = c.logClient.ListMachineGroup(project, offset, size)
+		m, total, err = c.client().ListMachineGroup(project, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -343,7 +406,7 @@ func (c *TokenAutoUpdateClient) ListMachineGroup(project string, offset, size in
 
 func (c *TokenAutoUpdateClient) GetLogStoreMeteringMode(project string, logstore string) (res *GetMeteringModeResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		res, err = c.logClient.GetLogStoreMeteringMode(project, logstore)
+		res, err = c.client().GetLogStoreMeteringMode(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -353,7 +416,7 @@ func (c *TokenAutoUpdateClient) GetLogStoreMeteringMode(project string, logstore
 
 func (c *TokenAutoUpdateClient) UpdateLogStoreMeteringMode(project string, logstore string, meteringMode string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateLogStoreMeteringMode(project, logstore, meteringMode)
+		err = c.client().UpdateLogStoreMeteringMode(project, logstore, meteringMode)
 		if !c.processError(err) {
 			return
 		}
@@ -363,7 +426,7 @@ func (c *TokenAutoUpdateClient) UpdateLogStoreMeteringMode(project string, logst
 
 func (c *TokenAutoUpdateClient) ListMachines(project, machineGroupName string) (ms []*Machine, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ms, total, err = c.logClient.ListMachines(project, machineGroupName)
+		ms, total, err = c.client().ListMachines(project, machineGroupName)
 		if !c.processError(err) {
 			return
 		}
@@ -373,7 +436,7 @@ func (c *TokenAutoUpdateClient) ListMachines(project, machineGroupName string) (
 
 func (c *TokenAutoUpdateClient) ListMachinesV2(project, machineGroupName string, offset, size int) (ms []*Machine, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ms, total, err = c.logClient.ListMachinesV2(project, machineGroupName, offset, size)
+		ms, total, err = c.client().ListMachinesV2(project, machineGroupName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -383,7 +446,7 @@ func (c *TokenAutoUpdateClient) ListMachinesV2(project, machineGroupName string,
 
 func (c *TokenAutoUpdateClient) CheckLogstoreExist(project string, logstore string) (ok bool, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ok, err = c.logClient.CheckLogstoreExist(project, logstore)
+		ok, err = c.client().CheckLogstoreExist(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -393,7 +456,7 @@ func (c *TokenAutoUpdateClient) CheckLogstoreExist(project string, logstore stri
 
 func (c *TokenAutoUpdateClient) CheckMachineGroupExist(project string, machineGroup string) (ok bool, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ok, err = c.logClient.CheckMachineGroupExist(project, machineGroup)
+		ok, err = c.client().CheckMachineGroupExist(project, machineGroup)
 		if !c.processError(err) {
 			return
 		}
@@ -403,7 +466,7 @@ func (c *TokenAutoUpdateClient) CheckMachineGroupExist(project string, machineGr
 
 func (c *TokenAutoUpdateClient) GetMachineGroup(project string, machineGroup string) (m *MachineGroup, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		m, err = c.logClient.GetMachineGroup(project, machineGroup)
+		m, err = c.client().GetMachineGroup(project, machineGroup)
 		if !c.processError(err) {
 			return
 		}
@@ -413,7 +476,7 @@ func (c *TokenAutoUpdateClient) GetMachineGroup(project string, machineGroup str
 
 func (c *TokenAutoUpdateClient) CreateMachineGroup(project string, m *MachineGroup) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateMachineGroup(project, m)
+		err = c.client().CreateMachineGroup(project, m)
 		if !c.processError(err) {
 			return
 		}
@@ -423,7 +486,7 @@ func (c *TokenAutoUpdateClient) CreateMachineGroup(project string, m *MachineGro
 
 func (c *TokenAutoUpdateClient) UpdateMachineGroup(project string, m *MachineGroup) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateMachineGroup(project, m)
+		err = c.client().UpdateMachineGroup(project, m)
 		if !c.processError(err) {
 			return
 		}
@@ -433,7 +496,7 @@ func (c *TokenAutoUpdateClient) UpdateMachineGroup(project string, m *MachineGro
 
 func (c *TokenAutoUpdateClient) DeleteMachineGroup(project string, machineGroup string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteMachineGroup(project, machineGroup)
+		err = c.client().DeleteMachineGroup(project, machineGroup)
 		if !c.processError(err) {
 			return
 		}
@@ -443,7 +506,7 @@ func (c *TokenAutoUpdateClient) DeleteMachineGroup(project string, machineGroup
 
 func (c *TokenAutoUpdateClient) CreateMetricConfig(project string, metricStore string, metricConfig *MetricsConfig) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateMetricConfig(project, metricStore, metricConfig)
+		err = c.client().CreateMetricConfig(project, metricStore, metricConfig)
 		if !c.processError(err) {
 			return
 		}
@@ -453,7 +516,7 @@ func (c *TokenAutoUpdateClient) CreateMetricConfig(project string, metricStore s
 
 func (c *TokenAutoUpdateClient) DeleteMetricConfig(project string, metricStore string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteMetricConfig(project, metricStore)
+		err = c.client().DeleteMetricConfig(project, metricStore)
 		if !c.processError(err) {
 			return
 		}
@@ -463,7 +526,7 @@ func (c *TokenAutoUpdateClient) DeleteMetricConfig(project string, metricStore s
 
 func (c *TokenAutoUpdateClient) GetMetricConfig(project string, metricStore string) (metricConfig *MetricsConfig, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		metricConfig, err = c.logClient.GetMetricConfig(project, metricStore)
+		metricConfig, err = c.client().GetMetricConfig(project, metricStore)
 		if !c.processError(err) {
 			return
 		}
@@ -473,7 +536,7 @@ func (c *TokenAutoUpdateClient) GetMetricConfig(project string, metricStore stri
 
 func (c *TokenAutoUpdateClient) UpdateMetricConfig(project string, metricStore string, metricConfig *MetricsConfig) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateMetricConfig(project, metricStore, metricConfig)
+		err = c.client().UpdateMetricConfig(project, metricStore, metricConfig)
 		if !c.processError(err) {
 			return
 		}
@@ -483,7 +546,7 @@ func (c *TokenAutoUpdateClient) UpdateMetricConfig(project string, metricStore s
 
 func (c *TokenAutoUpdateClient) ListConfig(project string, offset, size int) (cfgNames []string, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		cfgNames, total, err = c.logClient.ListConfig(project, offset, size)
+		cfgNames, total, err = c.client().ListConfig(project, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -493,7 +556,7 @@ func (c *TokenAutoUpdateClient) ListConfig(project string, offset, size int) (cf
 
 func (c *TokenAutoUpdateClient) CheckConfigExist(project string, config string) (ok bool, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ok, err = c.logClient.CheckConfigExist(project, config)
+		ok, err = c.client().CheckConfigExist(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -503,7 +566,7 @@ func (c *TokenAutoUpdateClient) CheckConfigExist(project string, config string)
 
 func (c *TokenAutoUpdateClient) GetConfig(project string, config string) (logConfig *LogConfig, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		logConfig, err = c.logClient.GetConfig(project, config)
+		logConfig, err = c.client().GetConfig(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -513,7 +576,7 @@ func (c *TokenAutoUpdateClient) GetConfig(project string, config string) (logCon
 
 func (c *TokenAutoUpdateClient) UpdateConfig(project string, config *LogConfig) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateConfig(project, config)
+		err = c.client().UpdateConfig(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -523,7 +586,7 @@ func (c *TokenAutoUpdateClient) UpdateConfig(project string, config *LogConfig)
 
 func (c *TokenAutoUpdateClient) CreateConfig(project string, config *LogConfig) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateConfig(project, config)
+		err = c.client().CreateConfig(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -533,7 +596,7 @@ func (c *TokenAutoUpdateClient) CreateConfig(project string, config *LogConfig)
 
 func (c *TokenAutoUpdateClient) DeleteConfig(project string, config string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteConfig(project, config)
+		err = c.client().DeleteConfig(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -543,7 +606,7 @@ func (c *TokenAutoUpdateClient) DeleteConfig(project string, config string) (err
 
 func (c *TokenAutoUpdateClient) GetAppliedMachineGroups(project string, confName string) (groupNames []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		groupNames, err = c.logClient.GetAppliedMachineGroups(project, confName)
+		groupNames, err = c.client().GetAppliedMachineGroups(project, confName)
 		if !c.processError(err) {
 			return
 		}
@@ -553,7 +616,7 @@ func (c *TokenAutoUpdateClient) GetAppliedMachineGroups(project string, confName
 
 func (c *TokenAutoUpdateClient) GetAppliedConfigs(project string, groupName string) (confNames []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		confNames, err = c.logClient.GetAppliedConfigs(project, groupName)
+		confNames, err = c.client().GetAppliedConfigs(project, groupName)
 		if !c.processError(err) {
 			return
 		}
@@ -563,7 +626,7 @@ func (c *TokenAutoUpdateClient) GetAppliedConfigs(project string, groupName stri
 
 func (c *TokenAutoUpdateClient) ApplyConfigToMachineGroup(project string, confName, groupName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.ApplyConfigToMachineGroup(project, confName, groupName)
+		err = c.client().ApplyConfigToMachineGroup(project, confName, groupName)
 		if !c.processError(err) {
 			return
 		}
@@ -573,7 +636,7 @@ func (c *TokenAutoUpdateClient) ApplyConfigToMachineGroup(project string, confNa
 
 func (c *TokenAutoUpdateClient) RemoveConfigFromMachineGroup(project string, confName, groupName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.RemoveConfigFromMachineGroup(project, confName, groupName)
+		err = c.client().RemoveConfigFromMachineGroup(project, confName, groupName)
 		if !c.processError(err) {
 			return
 		}
@@ -583,7 +646,7 @@ func (c *TokenAutoUpdateClient) RemoveConfigFromMachineGroup(project string, con
 
 func (c *TokenAutoUpdateClient) CreateETL(project string, etljob ETL) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateETL(project, etljob)
+		err = c.client().CreateETL(project, etljob)
 		if !c.processError(err) {
 			return
 		}
@@ -593,7 +656,7 @@ func (c *TokenAutoUpdateClient) CreateETL(project string, etljob ETL) (err error
 
 func (c *TokenAutoUpdateClient) UpdateETL(project string, etljob ETL) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateETL(project, etljob)
+		err = c.client().UpdateETL(project, etljob)
 		if !c.processError(err) {
 			return
 		}
@@ -603,7 +666,7 @@ func (c *TokenAutoUpdateClient) UpdateETL(project string, etljob ETL) (err error
 
 func (c *TokenAutoUpdateClient) GetETL(project string, etlName string) (ETLJob *ETL, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ETLJob, err = c.logClient.GetETL(project, etlName)
+		ETLJob, err = c.client().GetETL(project, etlName)
 		if !c.processError(err) {
 			return
 		}
@@ -613,7 +676,7 @@ func (c *TokenAutoUpdateClient) GetETL(project string, etlName string) (ETLJob *
 
 func (c *TokenAutoUpdateClient) ListETL(project string, offset int, size int) (ETLResponse *ListETLResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ETLResponse, err = c.logClient.ListETL(project, offset, size)
+		ETLResponse, err = c.client().ListETL(project, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -623,7 +686,7 @@ func (c *TokenAutoUpdateClient) ListETL(project string, offset int, size int) (E
 
 func (c *TokenAutoUpdateClient) DeleteETL(project string, etlName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteETL(project, etlName)
+		err = c.client().DeleteETL(project, etlName)
 		if !c.processError(err) {
 			return
 		}
@@ -633,7 +696,7 @@ func (c *TokenAutoUpdateClient) DeleteETL(project string, etlName string) (err e
 
 func (c *TokenAutoUpdateClient) StartETL(project string, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.StartETL(project, name)
+		err = c.client().StartETL(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -643,7 +706,7 @@ func (c *TokenAutoUpdateClient) StartETL(project string, name string) (err error
 
 func (c *TokenAutoUpdateClient) StopETL(project string, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.StopETL(project, name)
+		err = c.client().StopETL(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -653,7 +716,7 @@ func (c *TokenAutoUpdateClient) StopETL(project string, name string) (err error)
 
 func (c *TokenAutoUpdateClient) RestartETL(project string, etljob ETL) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.RestartETL(project, etljob)
+		err = c.client().RestartETL(project, etljob)
 		if !c.processError(err) {
 			return
 		}
@@ -663,7 +726,7 @@ func (c *TokenAutoUpdateClient) RestartETL(project string, etljob ETL) (err erro
 
 func (c *TokenAutoUpdateClient) CreateEtlMeta(project string, etlMeta *EtlMeta) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateEtlMeta(project, etlMeta)
+		err = c.client().CreateEtlMeta(project, etlMeta)
 		if !c.processError(err) {
 			return
 		}
@@ -673,7 +736,7 @@ func (c *TokenAutoUpdateClient) CreateEtlMeta(project string, etlMeta *EtlMeta)
 
 func (c *TokenAutoUpdateClient) UpdateEtlMeta(project string, etlMeta *EtlMeta) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateEtlMeta(project, etlMeta)
+		err = c.client().UpdateEtlMeta(project, etlMeta)
 		if !c.processError(err) {
 			return
 		}
@@ -683,7 +746,7 @@ func (c *TokenAutoUpdateClient) UpdateEtlMeta(project string, etlMeta *EtlMeta)
 
 func (c *TokenAutoUpdateClient) DeleteEtlMeta(project string, etlMetaName, etlMetaKey string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteEtlMeta(project, etlMetaName, etlMetaKey)
+		err = c.client().DeleteEtlMeta(project, etlMetaName, etlMetaKey)
 		if !c.processError(err) {
 			return
 		}
@@ -693,7 +756,7 @@ func (c *TokenAutoUpdateClient) DeleteEtlMeta(project string, etlMetaName, etlMe
 
 func (c *TokenAutoUpdateClient) GetEtlMeta(project string, etlMetaName, etlMetaKey string) (etlMeta *EtlMeta, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		etlMeta, err = c.logClient.GetEtlMeta(project, etlMetaName, etlMetaKey)
+		etlMeta, err = c.client().GetEtlMeta(project, etlMetaName, etlMetaKey)
 		if !c.processError(err) {
 			return
 		}
@@ -703,7 +766,7 @@ func (c *TokenAutoUpdateClient) GetEtlMeta(project string, etlMetaName, etlMetaK
 
 func (c *TokenAutoUpdateClient) ListEtlMeta(project string, etlMetaName string, offset, size int) (total int, count int, etlMetaList []*EtlMeta, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		total, count, etlMetaList, err = c.logClient.ListEtlMeta(project, etlMetaName, offset, size)
+		total, count, etlMetaList, err = c.client().ListEtlMeta(project, etlMetaName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -713,7 +776,7 @@ func (c *TokenAutoUpdateClient) ListEtlMeta(project string, etlMetaName string,
 
 func (c *TokenAutoUpdateClient) ListEtlMetaWithTag(project string, etlMetaName, etlMetaTag string, offset, size int) (total int, count int, etlMetaList []*EtlMeta, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		total, count, etlMetaList, err = c.logClient.ListEtlMetaWithTag(project, etlMetaName, etlMetaTag, offset, size)
+		total, count, etlMetaList, err = c.client().ListEtlMetaWithTag(project, etlMetaName, etlMetaTag, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -723,7 +786,7 @@ func (c *TokenAutoUpdateClient) ListEtlMetaWithTag(project string, etlMetaName,
 
 func (c *TokenAutoUpdateClient) ListEtlMetaName(project string, offset, size int) (total int, count int, etlMetaNameList []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		total, count, etlMetaNameList, err = c.logClient.ListEtlMetaName(project, offset, size)
+		total, count, etlMetaNameList, err = c.client().ListEtlMetaName(project, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -733,7 +796,7 @@ func (c *TokenAutoUpdateClient) ListEtlMetaName(project string, offset, size int
 
 func (c *TokenAutoUpdateClient) ListShards(project, logstore string) (shardIDs []*Shard, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		shardIDs, err = c.logClient.ListShards(project, logstore)
+		shardIDs, err = c.client().ListShards(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -743,7 +806,7 @@ func (c *TokenAutoUpdateClient) ListShards(project, logstore string) (shardIDs [
 
 func (c *TokenAutoUpdateClient) SplitShard(project, logstore string, shardID int, splitKey string) (shards []*Shard, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		shards, err = c.logClient.SplitShard(project, logstore, shardID, splitKey)
+		shards, err = c.client().SplitShard(project, logstore, shardID, splitKey)
 		if !c.processError(err) {
 			return
 		}
@@ -753,7 +816,7 @@ func (c *TokenAutoUpdateClient) SplitShard(project, logstore string, shardID int
 
 func (c *TokenAutoUpdateClient) SplitNumShard(project, logstore string, shardID, shardNum int) (shards []*Shard, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		shards, err = c.logClient.SplitNumShard(project, logstore, shardID, shardNum)
+		shards, err = c.client().SplitNumShard(project, logstore, shardID, shardNum)
 		if !c.processError(err) {
 			return
 		}
@@ -763,7 +826,7 @@ func (c *TokenAutoUpdateClient) SplitNumShard(project, logstore string, shardID,
 
 func (c *TokenAutoUpdateClient) MergeShards(project, logstore string, shardID int) (shards []*Shard, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		shards, err = c.logClient.MergeShards(project, logstore, shardID)
+		shards, err = c.client().MergeShards(project, logstore, shardID)
 		if !c.processError(err) {
 			return
 		}
@@ -773,7 +836,7 @@ func (c *TokenAutoUpdateClient) MergeShards(project, logstore string, shardID in
 
 func (c *TokenAutoUpdateClient) PutLogs(project, logstore string, lg *LogGroup) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PutLogs(project, logstore, lg)
+		err = c.client().PutLogs(project, logstore, lg)
 		if !c.processError(err) {
 			return
 		}
@@ -783,7 +846,7 @@ func (c *TokenAutoUpdateClient) PutLogs(project, logstore string, lg *LogGroup)
 
 func (c *TokenAutoUpdateClient) PutLogsWithMetricStoreURL(project, logstore string, lg *LogGroup) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PutLogsWithMetricStoreURL(project, logstore, lg)
+		err = c.client().PutLogsWithMetricStoreURL(project, logstore, lg)
 		if !c.processError(err) {
 			return
 		}
@@ -793,7 +856,7 @@ func (c *TokenAutoUpdateClient) PutLogsWithMetricStoreURL(project, logstore stri
 
 func (c *TokenAutoUpdateClient) PostLogStoreLogs(project, logstore string, lg *LogGroup, hashKey *string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PostLogStoreLogs(project, logstore, lg, hashKey)
+		err = c.client().PostLogStoreLogs(project, logstore, lg, hashKey)
 		if !c.processError(err) {
 			return
 		}
@@ -804,7 +867,7 @@ func (c *TokenAutoUpdateClient) PostLogStoreLogs(project, logstore string, lg *L
 // PostRawLogWithCompressType put raw log data to log service, no marshal
 func (c *TokenAutoUpdateClient) PostRawLogWithCompressType(project, logstore string, rawLogData []byte, compressType int, hashKey *string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PostRawLogWithCompressType(project, logstore, rawLogData, compressType, hashKey)
+		err = c.client().PostRawLogWithCompressType(project, logstore, rawLogData, compressType, hashKey)
 		if !c.processError(err) {
 			return
 		}
@@ -815,7 +878,7 @@ func (c *TokenAutoUpdateClient) PostRawLogWithCompressType(project, logstore str
 // PutRawLogWithCompressType put raw log data to log service, no marshal
 func (c *TokenAutoUpdateClient) PutRawLogWithCompressType(project, logstore string, rawLogData []byte, compressType int) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PutRawLogWithCompressType(project, logstore, rawLogData, compressType)
+		err = c.client().PutRawLogWithCompressType(project, logstore, rawLogData, compressType)
 		if !c.processError(err) {
 			return
 		}
@@ -825,7 +888,7 @@ func (c *TokenAutoUpdateClient) PutRawLogWithCompressType(project, logstore stri
 
 func (c *TokenAutoUpdateClient) PutLogsWithCompressType(project, logstore string, lg *LogGroup, compressType int) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PutLogsWithCompressType(project, logstore, lg, compressType)
+		err = c.client().PutLogsWithCompressType(project, logstore, lg, compressType)
 		if !c.processError(err) {
 			return
 		}
@@ -835,7 +898,7 @@ func (c *TokenAutoUpdateClient) PutLogsWithCompressType(project, logstore string
 
 func (c *TokenAutoUpdateClient) GetCursor(project, logstore string, shardID int, from string) (cursor string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		cursor, err = c.logClient.GetCursor(project, logstore, shardID, from)
+		cursor, err = c.client().GetCursor(project, logstore, shardID, from)
 		if !c.processError(err) {
 			return
 		}
@@ -845,7 +908,7 @@ func (c *TokenAutoUpdateClient) GetCursor(project, logstore string, shardID int,
 
 func (c *TokenAutoUpdateClient) GetCursorTime(project, logstore string, shardID int, cursor string) (cursorTime time.Time, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		cursorTime, err = c.logClient.GetCursorTime(project, logstore, shardID, cursor)
+		cursorTime, err = c.client().GetCursorTime(project, logstore, shardID, cursor)
 		if !c.processError(err) {
 			return
 		}
@@ -869,7 +932,7 @@ func (c *TokenAutoUpdateClient) GetLogsBytes(project, logstore string, shardID i
 // Deprecated: use GetLogsBytesWithQuery instead
 func (c *TokenAutoUpdateClient) GetLogsBytesV2(plr *PullLogRequest) (out []byte, nextCursor string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		out, nextCursor, err = c.logClient.GetLogsBytesV2(plr)
+		out, nextCursor, err = c.client().GetLogsBytesV2(plr)
 		if !c.processError(err) {
 			return
 		}
@@ -879,7 +942,7 @@ func (c *TokenAutoUpdateClient) GetLogsBytesV2(plr *PullLogRequest) (out []byte,
 
 func (c *TokenAutoUpdateClient) GetLogsBytesWithQuery(plr *PullLogRequest) (out []byte, plm *PullLogMeta, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		out, plm, err = c.logClient.GetLogsBytesWithQuery(plr)
+		out, plm, err = c.client().GetLogsBytesWithQuery(plr)
 		if !c.processError(err) {
 			return
 		}
@@ -903,7 +966,7 @@ func (c *TokenAutoUpdateClient) PullLogs(project, logstore string, shardID int,
 // Deprecated: use PullLogsWithQuery instead
 func (c *TokenAutoUpdateClient) PullLogsV2(plr *PullLogRequest) (gl *LogGroupList, nextCursor string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		gl, nextCursor, err = c.logClient.PullLogsV2(plr)
+		gl, nextCursor, err = c.client().PullLogsV2(plr)
 		if !c.processError(err) {
 			return
 		}
@@ -913,7 +976,7 @@ func (c *TokenAutoUpdateClient) PullLogsV2(plr *PullLogRequest) (gl *LogGroupLis
 
 func (c *TokenAutoUpdateClient) PullLogsWithQuery(plr *PullLogRequest) (gl *LogGroupList, plm *PullLogMeta, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		gl, plm, err = c.logClient.PullLogsWithQuery(plr)
+		gl, plm, err = c.client().PullLogsWithQuery(plr)
 		if !c.processError(err) {
 			return
 		}
@@ -923,7 +986,7 @@ func (c *TokenAutoUpdateClient) PullLogsWithQuery(plr *PullLogRequest) (gl *LogG
 
 func (c *TokenAutoUpdateClient) GetHistograms(project, logstore string, topic string, from int64, to int64, queryExp string) (h *GetHistogramsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		h, err = c.logClient.GetHistograms(project, logstore, topic, from, to, queryExp)
+		h, err = c.client().GetHistograms(project, logstore, topic, from, to, queryExp)
 		if !c.processError(err) {
 			return
 		}
@@ -933,7 +996,7 @@ func (c *TokenAutoUpdateClient) GetHistograms(project, logstore string, topic st
 
 func (c *TokenAutoUpdateClient) GetHistogramsV2(project, logstore string, ghr *GetHistogramRequest) (h *GetHistogramsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		h, err = c.logClient.GetHistogramsV2(project, logstore, ghr)
+		h, err = c.client().GetHistogramsV2(project, logstore, ghr)
 		if !c.processError(err) {
 			return
 		}
@@ -943,7 +1006,7 @@ func (c *TokenAutoUpdateClient) GetHistogramsV2(project, logstore string, ghr *G
 
 func (c *TokenAutoUpdateClient) GetHistogramsToCompleted(project, logstore string, topic string, from int64, to int64, queryExp string) (h *GetHistogramsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		h, err = c.logClient.GetHistogramsToCompleted(project, logstore, topic, from, to, queryExp)
+		h, err = c.client().GetHistogramsToCompleted(project, logstore, topic, from, to, queryExp)
 		if !c.processError(err) {
 			return
 		}
@@ -953,7 +1016,7 @@ func (c *TokenAutoUpdateClient) GetHistogramsToCompleted(project, logstore strin
 
 func (c *TokenAutoUpdateClient) GetHistogramsToCompletedV2(project, logstore string, ghr *GetHistogramRequest) (h *GetHistogramsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		h, err = c.logClient.GetHistogramsToCompletedV2(project, logstore, ghr)
+		h, err = c.client().GetHistogramsToCompletedV2(project, logstore, ghr)
 		if !c.processError(err) {
 			return
 		}
@@ -963,7 +1026,7 @@ func (c *TokenAutoUpdateClient) GetHistogramsToCompletedV2(project, logstore str
 
 func (c *TokenAutoUpdateClient) GetLogsV2(project, logstore string, req *GetLogRequest) (r *GetLogsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogsV2(project, logstore, req)
+		r, err = c.client().GetLogsV2(project, logstore, req)
 		if !c.processError(err) {
 			return
 		}
@@ -973,7 +1036,7 @@ func (c *TokenAutoUpdateClient) GetLogsV2(project, logstore string, req *GetLogR
 
 func (c *TokenAutoUpdateClient) GetLogsV3(project, logstore string, req *GetLogRequest) (r *GetLogsV3Response, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogsV3(project, logstore, req)
+		r, err = c.client().GetLogsV3(project, logstore, req)
 		if !c.processError(err) {
 			return
 		}
@@ -983,7 +1046,7 @@ func (c *TokenAutoUpdateClient) GetLogsV3(project, logstore string, req *GetLogR
 
 func (c *TokenAutoUpdateClient) GetLogsToCompletedV2(project, logstore string, req *GetLogRequest) (r *GetLogsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogsToCompletedV2(project, logstore, req)
+		r, err = c.client().GetLogsToCompletedV2(project, logstore, req)
 		if !c.processError(err) {
 			return
 		}
@@ -993,7 +1056,17 @@ func (c *TokenAutoUpdateClient) GetLogsToCompletedV2(project, logstore string, r
 
 func (c *TokenAutoUpdateClient) GetLogsToCompletedV3(project, logstore string, req *GetLogRequest) (r *GetLogsV3Response, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogsToCompletedV3(project, logstore, req)
+		r, err = c.client().GetLogsToCompletedV3(project, logstore, req)
+		if !c.processError(err) {
+			return
+		}
+	}
+	return
+}
+
+func (c *TokenAutoUpdateClient) GetContextLogs(project, logstore string, backLines, forwardLines int32, packID, packMeta string) (r *GetContextLogsResponse, err error) {
+	for i := 0; i < c.maxTryTimes; i++ {
+		r, err = c.client().GetContextLogs(project, logstore, backLines, forwardLines, packID, packMeta)
 		if !c.processError(err) {
 			return
 		}
@@ -1003,7 +1076,7 @@ func (c *TokenAutoUpdateClient) GetLogsToCompletedV3(project, logstore string, r
 
 func (c *TokenAutoUpdateClient) GetLogLinesV2(project, logstore string, req *GetLogRequest) (r *GetLogLinesResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogLinesV2(project, logstore, req)
+		r, err = c.client().GetLogLinesV2(project, logstore, req)
 		if !c.processError(err) {
 			return
 		}
@@ -1014,7 +1087,7 @@ func (c *TokenAutoUpdateClient) GetLogLinesV2(project, logstore string, req *Get
 func (c *TokenAutoUpdateClient) GetLogs(project, logstore string, topic string, from int64, to int64, queryExp string,
 	maxLineNum int64, offset int64, reverse bool) (r *GetLogsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogs(project, logstore, topic, from, to, queryExp, maxLineNum, offset, reverse)
+		r, err = c.client().GetLogs(project, logstore, topic, from, to, queryExp, maxLineNum, offset, reverse)
 		if !c.processError(err) {
 			return
 		}
@@ -1025,7 +1098,7 @@ func (c *TokenAutoUpdateClient) GetLogs(project, logstore string, topic string,
 func (c *TokenAutoUpdateClient) GetLogsByNano(project, logstore string, topic string, fromInNs int64, toInNs int64, queryExp string,
 	maxLineNum int64, offset int64, reverse bool) (r *GetLogsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogsByNano(project, logstore, topic, fromInNs, toInNs, queryExp, maxLineNum, offset, reverse)
+		r, err = c.client().GetLogsByNano(project, logstore, topic, fromInNs, toInNs, queryExp, maxLineNum, offset, reverse)
 		if !c.processError(err) {
 			return
 		}
@@ -1036,7 +1109,7 @@ func (c *TokenAutoUpdateClient) GetLogsByNano(project, logstore string, topic st
 func (c *TokenAutoUpdateClient) GetLogsToCompleted(project, logstore string, topic string, from int64, to int64, queryExp string,
 	maxLineNum int64, offset int64, reverse bool) (r *GetLogsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogsToCompleted(project, logstore, topic, from, to, queryExp, maxLineNum, offset, reverse)
+		r, err = c.client().GetLogsToCompleted(project, logstore, topic, from, to, queryExp, maxLineNum, offset, reverse)
 		if !c.processError(err) {
 			return
 		}
@@ -1047,7 +1120,7 @@ func (c *TokenAutoUpdateClient) GetLogsToCompleted(project, logstore string, top
 func (c *TokenAutoUpdateClient) GetLogLines(project, logstore string, topic string, from int64, to int64, queryExp string,
 	maxLineNum int64, offset int64, reverse bool) (r *GetLogLinesResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogLines(project, logstore, topic, from, to, queryExp, maxLineNum, offset, reverse)
+		r, err = c.client().GetLogLines(project, logstore, topic, from, to, queryExp, maxLineNum, offset, reverse)
 		if !c.processError(err) {
 			return
 		}
@@ -1058,7 +1131,7 @@ func (c *TokenAutoUpdateClient) GetLogLines(project, logstore string, topic stri
 func (c *TokenAutoUpdateClient) GetLogLinesByNano(project, logstore string, topic string, fromInNs int64, toInNS int64, queryExp string,
 	maxLineNum int64, offset int64, reverse bool) (r *GetLogLinesResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		r, err = c.logClient.GetLogLinesByNano(project, logstore, topic, fromInNs, toInNS, queryExp, maxLineNum, offset, reverse)
+		r, err = c.client().GetLogLinesByNano(project, logstore, topic, fromInNs, toInNS, queryExp, maxLineNum, offset, reverse)
 		if !c.processError(err) {
 			return
 		}
@@ -1068,7 +1141,7 @@ func (c *TokenAutoUpdateClient) GetLogLinesByNano(project, logstore string, topi
 
 func (c *TokenAutoUpdateClient) CreateIndex(project, logstore string, index Index) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateIndex(project, logstore, index)
+		err = c.client().CreateIndex(project, logstore, index)
 		if !c.processError(err) {
 			return
 		}
@@ -1078,7 +1151,7 @@ func (c *TokenAutoUpdateClient) CreateIndex(project, logstore string, index Inde
 
 func (c *TokenAutoUpdateClient) UpdateIndex(project, logstore string, index Index) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateIndex(project, logstore, index)
+		err = c.client().UpdateIndex(project, logstore, index)
 		if !c.processError(err) {
 			return
 		}
@@ -1088,7 +1161,7 @@ func (c *TokenAutoUpdateClient) UpdateIndex(project, logstore string, index Inde
 
 func (c *TokenAutoUpdateClient) DeleteIndex(project, logstore string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteIndex(project, logstore)
+		err = c.client().DeleteIndex(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -1098,7 +1171,7 @@ func (c *TokenAutoUpdateClient) DeleteIndex(project, logstore string) (err error
 
 func (c *TokenAutoUpdateClient) GetIndex(project, logstore string) (index *Index, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		index, err = c.logClient.GetIndex(project, logstore)
+		index, err = c.client().GetIndex(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -1108,7 +1181,7 @@ func (c *TokenAutoUpdateClient) GetIndex(project, logstore string) (index *Index
 
 func (c *TokenAutoUpdateClient) ListDashboard(project string, dashboardName string, offset, size int) (dashboardList []string, count, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		dashboardList, count, total, err = c.logClient.ListDashboard(project, dashboardName, offset, size)
+		dashboardList, count, total, err = c.client().ListDashboard(project, dashboardName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1118,7 +1191,7 @@ func (c *TokenAutoUpdateClient) ListDashboard(project string, dashboardName stri
 
 func (c *TokenAutoUpdateClient) ListDashboardV2(project string, dashboardName string, offset, size int) (dashboardList []string, dashboardItems []ResponseDashboardItem, count, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		dashboardList, dashboardItems, count, total, err = c.logClient.ListDashboardV2(project, dashboardName, offset, size)
+		dashboardList, dashboardItems, count, total, err = c.client().ListDashboardV2(project, dashboardName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1128,7 +1201,7 @@ func (c *TokenAutoUpdateClient) ListDashboardV2(project string, dashboardName st
 
 func (c *TokenAutoUpdateClient) GetDashboard(project, name string) (dashboard *Dashboard, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		dashboard, err = c.logClient.GetDashboard(project, name)
+		dashboard, err = c.client().GetDashboard(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1137,7 +1210,7 @@ func (c *TokenAutoUpdateClient) GetDashboard(project, name string) (dashboard *D
 }
 func (c *TokenAutoUpdateClient) DeleteDashboard(project, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteDashboard(project, name)
+		err = c.client().DeleteDashboard(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1146,7 +1219,7 @@ func (c *TokenAutoUpdateClient) DeleteDashboard(project, name string) (err error
 }
 func (c *TokenAutoUpdateClient) UpdateDashboard(project string, dashboard Dashboard) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateDashboard(project, dashboard)
+		err = c.client().UpdateDashboard(project, dashboard)
 		if !c.processError(err) {
 			return
 		}
@@ -1155,7 +1228,7 @@ func (c *TokenAutoUpdateClient) UpdateDashboard(project string, dashboard Dashbo
 }
 func (c *TokenAutoUpdateClient) CreateDashboard(project string, dashboard Dashboard) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateDashboard(project, dashboard)
+		err = c.client().CreateDashboard(project, dashboard)
 		if !c.processError(err) {
 			return
 		}
@@ -1164,7 +1237,7 @@ func (c *TokenAutoUpdateClient) CreateDashboard(project string, dashboard Dashbo
 }
 func (c *TokenAutoUpdateClient) GetChart(project, dashboardName, chartName string) (chart *Chart, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		chart, err = c.logClient.GetChart(project, dashboardName, chartName)
+		chart, err = c.client().GetChart(project, dashboardName, chartName)
 		if !c.processError(err) {
 			return
 		}
@@ -1173,7 +1246,7 @@ func (c *TokenAutoUpdateClient) GetChart(project, dashboardName, chartName strin
 }
 func (c *TokenAutoUpdateClient) DeleteChart(project, dashboardName, chartName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteChart(project, dashboardName, chartName)
+		err = c.client().DeleteChart(project, dashboardName, chartName)
 		if !c.processError(err) {
 			return
 		}
@@ -1182,7 +1255,7 @@ func (c *TokenAutoUpdateClient) DeleteChart(project, dashboardName, chartName st
 }
 func (c *TokenAutoUpdateClient) UpdateChart(project, dashboardName string, chart Chart) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateChart(project, dashboardName, chart)
+		err = c.client().UpdateChart(project, dashboardName, chart)
 		if !c.processError(err) {
 			return
 		}
@@ -1191,7 +1264,7 @@ func (c *TokenAutoUpdateClient) UpdateChart(project, dashboardName string, chart
 }
 func (c *TokenAutoUpdateClient) CreateChart(project, dashboardName string, chart Chart) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateChart(project, dashboardName, chart)
+		err = c.client().CreateChart(project, dashboardName, chart)
 		if !c.processError(err) {
 			return
 		}
@@ -1201,7 +1274,7 @@ func (c *TokenAutoUpdateClient) CreateChart(project, dashboardName string, chart
 
 func (c *TokenAutoUpdateClient) CreateSavedSearch(project string, savedSearch *SavedSearch) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateSavedSearch(project, savedSearch)
+		err = c.client().CreateSavedSearch(project, savedSearch)
 		if !c.processError(err) {
 			return
 		}
@@ -1211,7 +1284,7 @@ func (c *TokenAutoUpdateClient) CreateSavedSearch(project string, savedSearch *S
 
 func (c *TokenAutoUpdateClient) UpdateSavedSearch(project string, savedSearch *SavedSearch) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateSavedSearch(project, savedSearch)
+		err = c.client().UpdateSavedSearch(project, savedSearch)
 		if !c.processError(err) {
 			return
 		}
@@ -1221,7 +1294,7 @@ func (c *TokenAutoUpdateClient) UpdateSavedSearch(project string, savedSearch *S
 
 func (c *TokenAutoUpdateClient) DeleteSavedSearch(project string, savedSearchName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteSavedSearch(project, savedSearchName)
+		err = c.client().DeleteSavedSearch(project, savedSearchName)
 		if !c.processError(err) {
 			return
 		}
@@ -1231,7 +1304,7 @@ func (c *TokenAutoUpdateClient) DeleteSavedSearch(project string, savedSearchNam
 
 func (c *TokenAutoUpdateClient) GetSavedSearch(project string, savedSearchName string) (savedSearch *SavedSearch, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		savedSearch, err = c.logClient.GetSavedSearch(project, savedSearchName)
+		savedSearch, err = c.client().GetSavedSearch(project, savedSearchName)
 		if !c.processError(err) {
 			return
 		}
@@ -1241,7 +1314,7 @@ func (c *TokenAutoUpdateClient) GetSavedSearch(project string, savedSearchName s
 
 func (c *TokenAutoUpdateClient) ListSavedSearch(project string, savedSearchName string, offset, size int) (savedSearches []string, total int, count int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		savedSearches, total, count, err = c.logClient.ListSavedSearch(project, savedSearchName, offset, size)
+		savedSearches, total, count, err = c.client().ListSavedSearch(project, savedSearchName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1251,7 +1324,7 @@ func (c *TokenAutoUpdateClient) ListSavedSearch(project string, savedSearchName
 
 func (c *TokenAutoUpdateClient) ListSavedSearchV2(project string, savedSearchName string, offset, size int) (savedSearches []string, savedsearchItems []ResponseSavedSearchItem, total int, count int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		savedSearches, savedsearchItems, total, count, err = c.logClient.ListSavedSearchV2(project, savedSearchName, offset, size)
+		savedSearches, savedsearchItems, total, count, err = c.client().ListSavedSearchV2(project, savedSearchName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1261,7 +1334,7 @@ func (c *TokenAutoUpdateClient) ListSavedSearchV2(project string, savedSearchNam
 
 func (c *TokenAutoUpdateClient) CreateAlert(project string, alert *Alert) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateAlert(project, alert)
+		err = c.client().CreateAlert(project, alert)
 		if !c.processError(err) {
 			return
 		}
@@ -1271,7 +1344,7 @@ func (c *TokenAutoUpdateClient) CreateAlert(project string, alert *Alert) (err e
 
 func (c *TokenAutoUpdateClient) UpdateAlert(project string, alert *Alert) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateAlert(project, alert)
+		err = c.client().UpdateAlert(project, alert)
 		if !c.processError(err) {
 			return
 		}
@@ -1281,7 +1354,7 @@ func (c *TokenAutoUpdateClient) UpdateAlert(project string, alert *Alert) (err e
 
 func (c *TokenAutoUpdateClient) DeleteAlert(project string, alertName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteAlert(project, alertName)
+		err = c.client().DeleteAlert(project, alertName)
 		if !c.processError(err) {
 			return
 		}
@@ -1291,7 +1364,7 @@ func (c *TokenAutoUpdateClient) DeleteAlert(project string, alertName string) (e
 
 func (c *TokenAutoUpdateClient) GetAlert(project string, alertName string) (alert *Alert, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		alert, err = c.logClient.GetAlert(project, alertName)
+		alert, err = c.client().GetAlert(project, alertName)
 		if !c.processError(err) {
 			return
 		}
@@ -1301,7 +1374,7 @@ func (c *TokenAutoUpdateClient) GetAlert(project string, alertName string) (aler
 
 func (c *TokenAutoUpdateClient) DisableAlert(project string, alertName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DisableAlert(project, alertName)
+		err = c.client().DisableAlert(project, alertName)
 		if !c.processError(err) {
 			return
 		}
@@ -1311,7 +1384,7 @@ func (c *TokenAutoUpdateClient) DisableAlert(project string, alertName string) (
 
 func (c *TokenAutoUpdateClient) EnableAlert(project string, alertName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.EnableAlert(project, alertName)
+		err = c.client().EnableAlert(project, alertName)
 		if !c.processError(err) {
 			return
 		}
@@ -1321,7 +1394,7 @@ func (c *TokenAutoUpdateClient) EnableAlert(project string, alertName string) (e
 
 func (c *TokenAutoUpdateClient) ListAlert(project string, alertName string, dashboard string, offset, size int) (alerts []*Alert, total int, count int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		alerts, total, count, err = c.logClient.ListAlert(project, alertName, dashboard, offset, size)
+		alerts, total, count, err = c.client().ListAlert(project, alertName, dashboard, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1331,7 +1404,7 @@ func (c *TokenAutoUpdateClient) ListAlert(project string, alertName string, dash
 
 func (c *TokenAutoUpdateClient) CreateAlertString(project string, alert string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateAlertString(project, alert)
+		err = c.client().CreateAlertString(project, alert)
 		if !c.processError(err) {
 			return
 		}
@@ -1340,7 +1413,7 @@ func (c *TokenAutoUpdateClient) CreateAlertString(project string, alert string)
 }
 func (c *TokenAutoUpdateClient) UpdateAlertString(project string, alertName, alert string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateAlertString(project, alertName, alert)
+		err = c.client().UpdateAlertString(project, alertName, alert)
 		if !c.processError(err) {
 			return
 		}
@@ -1349,7 +1422,7 @@ func (c *TokenAutoUpdateClient) UpdateAlertString(project string, alertName, ale
 }
 func (c *TokenAutoUpdateClient) GetAlertString(project string, alertName string) (alert string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		alert, err = c.logClient.GetAlertString(project, alertName)
+		alert, err = c.client().GetAlertString(project, alertName)
 		if !c.processError(err) {
 			return
 		}
@@ -1359,7 +1432,7 @@ func (c *TokenAutoUpdateClient) GetAlertString(project string, alertName string)
 
 func (c *TokenAutoUpdateClient) CreateDashboardString(project string, dashboardStr string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateDashboardString(project, dashboardStr)
+		err = c.client().CreateDashboardString(project, dashboardStr)
 		if !c.processError(err) {
 			return
 		}
@@ -1369,7 +1442,7 @@ func (c *TokenAutoUpdateClient) CreateDashboardString(project string, dashboardS
 
 func (c *TokenAutoUpdateClient) UpdateDashboardString(project string, dashboardName, dashboardStr string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateDashboardString(project, dashboardName, dashboardStr)
+		err = c.client().UpdateDashboardString(project, dashboardName, dashboardStr)
 		if !c.processError(err) {
 			return
 		}
@@ -1379,7 +1452,7 @@ func (c *TokenAutoUpdateClient) UpdateDashboardString(project string, dashboardN
 
 func (c *TokenAutoUpdateClient) GetDashboardString(project, name string) (dashboard string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		dashboard, err = c.logClient.GetDashboardString(project, name)
+		dashboard, err = c.client().GetDashboardString(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1389,7 +1462,7 @@ func (c *TokenAutoUpdateClient) GetDashboardString(project, name string) (dashbo
 
 func (c *TokenAutoUpdateClient) GetConfigString(project string, config string) (logConfig string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		logConfig, err = c.logClient.GetConfigString(project, config)
+		logConfig, err = c.client().GetConfigString(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -1399,7 +1472,7 @@ func (c *TokenAutoUpdateClient) GetConfigString(project string, config string) (
 
 func (c *TokenAutoUpdateClient) CreateConfigString(project string, config string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateConfigString(project, config)
+		err = c.client().CreateConfigString(project, config)
 		if !c.processError(err) {
 			return
 		}
@@ -1409,7 +1482,7 @@ func (c *TokenAutoUpdateClient) CreateConfigString(project string, config string
 
 func (c *TokenAutoUpdateClient) UpdateConfigString(project string, configName, configDetail string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateConfigString(project, configName, configDetail)
+		err = c.client().UpdateConfigString(project, configName, configDetail)
 		if !c.processError(err) {
 			return
 		}
@@ -1419,7 +1492,7 @@ func (c *TokenAutoUpdateClient) UpdateConfigString(project string, configName, c
 
 func (c *TokenAutoUpdateClient) CreateIndexString(project, logstore string, index string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateIndexString(project, logstore, index)
+		err = c.client().CreateIndexString(project, logstore, index)
 		if !c.processError(err) {
 			return
 		}
@@ -1429,7 +1502,7 @@ func (c *TokenAutoUpdateClient) CreateIndexString(project, logstore string, inde
 
 func (c *TokenAutoUpdateClient) UpdateIndexString(project, logstore string, index string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateIndexString(project, logstore, index)
+		err = c.client().UpdateIndexString(project, logstore, index)
 		if !c.processError(err) {
 			return
 		}
@@ -1439,7 +1512,7 @@ func (c *TokenAutoUpdateClient) UpdateIndexString(project, logstore string, inde
 
 func (c *TokenAutoUpdateClient) GetIndexString(project, logstore string) (index string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		index, err = c.logClient.GetIndexString(project, logstore)
+		index, err = c.client().GetIndexString(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -1449,7 +1522,7 @@ func (c *TokenAutoUpdateClient) GetIndexString(project, logstore string) (index
 
 func (c *TokenAutoUpdateClient) CreateConsumerGroup(project, logstore string, cg ConsumerGroup) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateConsumerGroup(project, logstore, cg)
+		err = c.client().CreateConsumerGroup(project, logstore, cg)
 		if !c.processError(err) {
 			return
 		}
@@ -1459,7 +1532,7 @@ func (c *TokenAutoUpdateClient) CreateConsumerGroup(project, logstore string, cg
 
 func (c *TokenAutoUpdateClient) UpdateConsumerGroup(project, logstore string, cg ConsumerGroup) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateConsumerGroup(project, logstore, cg)
+		err = c.client().UpdateConsumerGroup(project, logstore, cg)
 		if !c.processError(err) {
 			return
 		}
@@ -1469,7 +1542,7 @@ func (c *TokenAutoUpdateClient) UpdateConsumerGroup(project, logstore string, cg
 
 func (c *TokenAutoUpdateClient) DeleteConsumerGroup(project, logstore string, cgName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteConsumerGroup(project, logstore, cgName)
+		err = c.client().DeleteConsumerGroup(project, logstore, cgName)
 		if !c.processError(err) {
 			return
 		}
@@ -1479,7 +1552,7 @@ func (c *TokenAutoUpdateClient) DeleteConsumerGroup(project, logstore string, cg
 
 func (c *TokenAutoUpdateClient) ListConsumerGroup(project, logstore string) (cgList []*ConsumerGroup, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		cgList, err = c.logClient.ListConsumerGroup(project, logstore)
+		cgList, err = c.client().ListConsumerGroup(project, logstore)
 		if !c.processError(err) {
 			return
 		}
@@ -1489,7 +1562,7 @@ func (c *TokenAutoUpdateClient) ListConsumerGroup(project, logstore string) (cgL
 
 func (c *TokenAutoUpdateClient) HeartBeat(project, logstore string, cgName, consumer string, heartBeatShardIDs []int) (shardIDs []int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		shardIDs, err = c.logClient.HeartBeat(project, logstore, cgName, consumer, heartBeatShardIDs)
+		shardIDs, err = c.client().HeartBeat(project, logstore, cgName, consumer, heartBeatShardIDs)
 		if !c.processError(err) {
 			return
 		}
@@ -1499,7 +1572,7 @@ func (c *TokenAutoUpdateClient) HeartBeat(project, logstore string, cgName, cons
 
 func (c *TokenAutoUpdateClient) UpdateCheckpoint(project, logstore string, cgName string, consumer string, shardID int, checkpoint string, forceSuccess bool) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateCheckpoint(project, logstore, cgName, consumer, shardID, checkpoint, forceSuccess)
+		err = c.client().UpdateCheckpoint(project, logstore, cgName, consumer, shardID, checkpoint, forceSuccess)
 		if !c.processError(err) {
 			return
 		}
@@ -1509,7 +1582,7 @@ func (c *TokenAutoUpdateClient) UpdateCheckpoint(project, logstore string, cgNam
 
 func (c *TokenAutoUpdateClient) GetCheckpoint(project, logstore string, cgName string) (checkPointList []*ConsumerGroupCheckPoint, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		checkPointList, err = c.logClient.GetCheckpoint(project, logstore, cgName)
+		checkPointList, err = c.client().GetCheckpoint(project, logstore, cgName)
 		if !c.processError(err) {
 			return
 		}
@@ -1521,7 +1594,7 @@ func (c *TokenAutoUpdateClient) GetCheckpoint(project, logstore string, cgName s
 // TagResources tag specific resource
 func (c *TokenAutoUpdateClient) TagResources(project string, tags *ResourceTags) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.TagResources(project, tags)
+		err = c.client().TagResources(project, tags)
 		if !c.processError(err) {
 			return
 		}
@@ -1532,7 +1605,7 @@ func (c *TokenAutoUpdateClient) TagResources(project string, tags *ResourceTags)
 // TagResourcesSystemTags tag specific resource
 func (c *TokenAutoUpdateClient) TagResourcesSystemTags(project string, tags *ResourceSystemTags) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.TagResourcesSystemTags(project, tags)
+		err = c.client().TagResourcesSystemTags(project, tags)
 		if !c.processError(err) {
 			return
 		}
@@ -1543,7 +1616,7 @@ func (c *TokenAutoUpdateClient) TagResourcesSystemTags(project string, tags *Res
 // UnTagResources untag specific resource
 func (c *TokenAutoUpdateClient) UnTagResources(project string, tags *ResourceUnTags) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UnTagResources(project, tags)
+		err = c.client().UnTagResources(project, tags)
 		if !c.processError(err) {
 			return
 		}
@@ -1554,7 +1627,7 @@ func (c *TokenAutoUpdateClient) UnTagResources(project string, tags *ResourceUnT
 // UnTagResourcesSystemTags untag specific resource
 func (c *TokenAutoUpdateClient) UnTagResourcesSystemTags(project string, tags *ResourceUnSystemTags) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UnTagResourcesSystemTags(project, tags)
+		err = c.client().UnTagResourcesSystemTags(project, tags)
 		if !c.processError(err) {
 			return
 		}
@@ -1569,7 +1642,7 @@ func (c *TokenAutoUpdateClient) ListTagResources(project string,
 	tags []ResourceFilterTag,
 	nextToken string) (respTags []*ResourceTagResponse, respNextToken string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		respTags, respNextToken, err = c.logClient.ListTagResources(project, resourceType, resourceIDs, tags, nextToken)
+		respTags, respNextToken, err = c.client().ListTagResources(project, resourceType, resourceIDs, tags, nextToken)
 		if !c.processError(err) {
 			return
 		}
@@ -1587,7 +1660,7 @@ func (c *TokenAutoUpdateClient) ListSystemTagResources(project string,
 	scope string,
 	nextToken string) (respTags []*ResourceTagResponse, respNextToken string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		respTags, respNextToken, err = c.logClient.ListSystemTagResources(project, resourceType, resourceIDs, tags, tagOwnerUid, category, scope, nextToken)
+		respTags, respNextToken, err = c.client().ListSystemTagResources(project, resourceType, resourceIDs, tags, tagOwnerUid, category, scope, nextToken)
 		if !c.processError(err) {
 			return
 		}
@@ -1598,7 +1671,7 @@ func (c *TokenAutoUpdateClient) ListSystemTagResources(project string,
 // ####################### Scheduled SQL API ######################
 func (c *TokenAutoUpdateClient) CreateScheduledSQL(project string, scheduledsql *ScheduledSQL) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateScheduledSQL(project, scheduledsql)
+		err = c.client().CreateScheduledSQL(project, scheduledsql)
 		if !c.processError(err) {
 			return
 		}
@@ -1608,7 +1681,7 @@ func (c *TokenAutoUpdateClient) CreateScheduledSQL(project string, scheduledsql
 
 func (c *TokenAutoUpdateClient) DeleteScheduledSQL(project string, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteScheduledSQL(project, name)
+		err = c.client().DeleteScheduledSQL(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1618,7 +1691,7 @@ func (c *TokenAutoUpdateClient) DeleteScheduledSQL(project string, name string)
 
 func (c *TokenAutoUpdateClient) UpdateScheduledSQL(project string, scheduledsql *ScheduledSQL) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateScheduledSQL(project, scheduledsql)
+		err = c.client().UpdateScheduledSQL(project, scheduledsql)
 		if !c.processError(err) {
 			return
 		}
@@ -1628,7 +1701,7 @@ func (c *TokenAutoUpdateClient) UpdateScheduledSQL(project string, scheduledsql
 
 func (c *TokenAutoUpdateClient) GetScheduledSQL(project string, name string) (s *ScheduledSQL, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		s, err = c.logClient.GetScheduledSQL(project, name)
+		s, err = c.client().GetScheduledSQL(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1638,7 +1711,7 @@ func (c *TokenAutoUpdateClient) GetScheduledSQL(project string, name string) (s
 
 func (c *TokenAutoUpdateClient) ListScheduledSQL(project, name, displayName string, offset, size int) (scheduledsqls []*ScheduledSQL, total, count int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		scheduledsqls, total, count, err = c.logClient.ListScheduledSQL(project, name, displayName, offset, size)
+		scheduledsqls, total, count, err = c.client().ListScheduledSQL(project, name, displayName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1648,7 +1721,7 @@ func (c *TokenAutoUpdateClient) ListScheduledSQL(project, name, displayName stri
 
 func (c *TokenAutoUpdateClient) GetScheduledSQLJobInstance(projectName, jobName, instanceId string, result bool) (instance *ScheduledSQLJobInstance, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		instance, err = c.logClient.GetScheduledSQLJobInstance(projectName, jobName, instanceId, result)
+		instance, err = c.client().GetScheduledSQLJobInstance(projectName, jobName, instanceId, result)
 		if !c.processError(err) {
 			return
 		}
@@ -1658,7 +1731,7 @@ func (c *TokenAutoUpdateClient) GetScheduledSQLJobInstance(projectName, jobName,
 
 func (c *TokenAutoUpdateClient) ModifyScheduledSQLJobInstanceState(projectName, jobName, instanceId string, state ScheduledSQLState) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.ModifyScheduledSQLJobInstanceState(projectName, jobName, instanceId, state)
+		err = c.client().ModifyScheduledSQLJobInstanceState(projectName, jobName, instanceId, state)
 		if !c.processError(err) {
 			return
 		}
@@ -1668,7 +1741,7 @@ func (c *TokenAutoUpdateClient) ModifyScheduledSQLJobInstanceState(projectName,
 
 func (c *TokenAutoUpdateClient) ListScheduledSQLJobInstances(projectName, jobName string, status *InstanceStatus) (instances []*ScheduledSQLJobInstance, total, count int64, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		instances, total, count, err = c.logClient.ListScheduledSQLJobInstances(projectName, jobName, status)
+		instances, total, count, err = c.client().ListScheduledSQLJobInstances(projectName, jobName, status)
 		if !c.processError(err) {
 			return
 		}
@@ -1679,7 +1752,7 @@ func (c *TokenAutoUpdateClient) ListScheduledSQLJobInstances(projectName, jobNam
 // ####################### Resource API ######################
 func (c *TokenAutoUpdateClient) ListResource(resourceType string, resourceName string, offset, size int) (resourceList []*Resource, count, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		resourceList, count, total, err = c.logClient.ListResource(resourceType, resourceName, offset, size)
+		resourceList, count, total, err = c.client().ListResource(resourceType, resourceName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1689,7 +1762,7 @@ func (c *TokenAutoUpdateClient) ListResource(resourceType string, resourceName s
 
 func (c *TokenAutoUpdateClient) GetResource(name string) (resource *Resource, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		resource, err = c.logClient.GetResource(name)
+		resource, err = c.client().GetResource(name)
 		if !c.processError(err) {
 			return
 		}
@@ -1699,7 +1772,7 @@ func (c *TokenAutoUpdateClient) GetResource(name string) (resource *Resource, er
 
 func (c *TokenAutoUpdateClient) GetResourceString(name string) (resource string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		resource, err = c.logClient.GetResourceString(name)
+		resource, err = c.client().GetResourceString(name)
 		if !c.processError(err) {
 			return
 		}
@@ -1709,7 +1782,7 @@ func (c *TokenAutoUpdateClient) GetResourceString(name string) (resource string,
 
 func (c *TokenAutoUpdateClient) DeleteResource(name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteResource(name)
+		err = c.client().DeleteResource(name)
 		if !c.processError(err) {
 			return
 		}
@@ -1719,7 +1792,7 @@ func (c *TokenAutoUpdateClient) DeleteResource(name string) (err error) {
 
 func (c *TokenAutoUpdateClient) UpdateResource(resource *Resource) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateResource(resource)
+		err = c.client().UpdateResource(resource)
 		if !c.processError(err) {
 			return
 		}
@@ -1729,7 +1802,7 @@ func (c *TokenAutoUpdateClient) UpdateResource(resource *Resource) (err error) {
 
 func (c *TokenAutoUpdateClient) UpdateResourceString(resourceName, resourceStr string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateResourceString(resourceName, resourceStr)
+		err = c.client().UpdateResourceString(resourceName, resourceStr)
 		if !c.processError(err) {
 			return
 		}
@@ -1739,7 +1812,7 @@ func (c *TokenAutoUpdateClient) UpdateResourceString(resourceName, resourceStr s
 
 func (c *TokenAutoUpdateClient) CreateResource(resource *Resource) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateResource(resource)
+		err = c.client().CreateResource(resource)
 		if !c.processError(err) {
 			return
 		}
@@ -1749,7 +1822,7 @@ func (c *TokenAutoUpdateClient) CreateResource(resource *Resource) (err error) {
 
 func (c *TokenAutoUpdateClient) CreateResourceString(resourceStr string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateResourceString(resourceStr)
+		err = c.client().CreateResourceString(resourceStr)
 		if !c.processError(err) {
 			return
 		}
@@ -1760,7 +1833,7 @@ func (c *TokenAutoUpdateClient) CreateResourceString(resourceStr string) (err er
 // ####################### Resource Record API ######################
 func (c *TokenAutoUpdateClient) ListResourceRecord(resourceName string, offset, size int) (recordList []*ResourceRecord, count, total int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		recordList, count, total, err = c.logClient.ListResourceRecord(resourceName, offset, size)
+		recordList, count, total, err = c.client().ListResourceRecord(resourceName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1770,7 +1843,7 @@ func (c *TokenAutoUpdateClient) ListResourceRecord(resourceName string, offset,
 
 func (c *TokenAutoUpdateClient) GetResourceRecord(resourceName, recordId string) (record *ResourceRecord, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		record, err = c.logClient.GetResourceRecord(resourceName, recordId)
+		record, err = c.client().GetResourceRecord(resourceName, recordId)
 		if !c.processError(err) {
 			return
 		}
@@ -1780,7 +1853,7 @@ func (c *TokenAutoUpdateClient) GetResourceRecord(resourceName, recordId string)
 
 func (c *TokenAutoUpdateClient) GetResourceRecordString(resourceName, name string) (record string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		record, err = c.logClient.GetResourceRecordString(resourceName, name)
+		record, err = c.client().GetResourceRecordString(resourceName, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1790,7 +1863,7 @@ func (c *TokenAutoUpdateClient) GetResourceRecordString(resourceName, name strin
 
 func (c *TokenAutoUpdateClient) DeleteResourceRecord(resourceName, recordId string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteResourceRecord(resourceName, recordId)
+		err = c.client().DeleteResourceRecord(resourceName, recordId)
 		if !c.processError(err) {
 			return
 		}
@@ -1800,7 +1873,7 @@ func (c *TokenAutoUpdateClient) DeleteResourceRecord(resourceName, recordId stri
 
 func (c *TokenAutoUpdateClient) UpdateResourceRecord(resourceName string, record *ResourceRecord) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateResourceRecord(resourceName, record)
+		err = c.client().UpdateResourceRecord(resourceName, record)
 		if !c.processError(err) {
 			return
 		}
@@ -1810,7 +1883,7 @@ func (c *TokenAutoUpdateClient) UpdateResourceRecord(resourceName string, record
 
 func (c *TokenAutoUpdateClient) UpdateResourceRecordString(resourceName, recordStr string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateResourceString(resourceName, recordStr)
+		err = c.client().UpdateResourceString(resourceName, recordStr)
 		if !c.processError(err) {
 			return
 		}
@@ -1820,7 +1893,7 @@ func (c *TokenAutoUpdateClient) UpdateResourceRecordString(resourceName, recordS
 
 func (c *TokenAutoUpdateClient) CreateResourceRecord(resourceName string, record *ResourceRecord) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateResourceRecord(resourceName, record)
+		err = c.client().CreateResourceRecord(resourceName, record)
 		if !c.processError(err) {
 			return
 		}
@@ -1830,7 +1903,7 @@ func (c *TokenAutoUpdateClient) CreateResourceRecord(resourceName string, record
 
 func (c *TokenAutoUpdateClient) CreateResourceRecordString(resourceName, recordStr string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateResourceRecordString(resourceName, recordStr)
+		err = c.client().CreateResourceRecordString(resourceName, recordStr)
 		if !c.processError(err) {
 			return
 		}
@@ -1841,7 +1914,7 @@ func (c *TokenAutoUpdateClient) CreateResourceRecordString(resourceName, recordS
 // ####################### Ingestion API ######################
 func (c *TokenAutoUpdateClient) CreateIngestion(project string, ingestion *Ingestion) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateIngestion(project, ingestion)
+		err = c.client().CreateIngestion(project, ingestion)
 		if !c.processError(err) {
 			return
 		}
@@ -1851,7 +1924,7 @@ func (c *TokenAutoUpdateClient) CreateIngestion(project string, ingestion *Inges
 
 func (c *TokenAutoUpdateClient) UpdateIngestion(project string, ingestion *Ingestion) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateIngestion(project, ingestion)
+		err = c.client().UpdateIngestion(project, ingestion)
 		if !c.processError(err) {
 			return
 		}
@@ -1861,7 +1934,7 @@ func (c *TokenAutoUpdateClient) UpdateIngestion(project string, ingestion *Inges
 
 func (c *TokenAutoUpdateClient) GetIngestion(project string, name string) (ingestion *Ingestion, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ingestion, err = c.logClient.GetIngestion(project, name)
+		ingestion, err = c.client().GetIngestion(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1871,7 +1944,7 @@ func (c *TokenAutoUpdateClient) GetIngestion(project string, name string) (inges
 
 func (c *TokenAutoUpdateClient) ListIngestion(project, logstore, name, displayName string, offset, size int) (ingestions []*Ingestion, total, count int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		ingestions, total, count, err = c.logClient.ListIngestion(project, logstore, name, displayName, offset, size)
+		ingestions, total, count, err = c.client().ListIngestion(project, logstore, name, displayName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1881,7 +1954,7 @@ func (c *TokenAutoUpdateClient) ListIngestion(project, logstore, name, displayNa
 
 func (c *TokenAutoUpdateClient) DeleteIngestion(project string, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteIngestion(project, name)
+		err = c.client().DeleteIngestion(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1891,7 +1964,7 @@ func (c *TokenAutoUpdateClient) DeleteIngestion(project string, name string) (er
 
 func (c *TokenAutoUpdateClient) CreateExport(project string, export *Export) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateExport(project, export)
+		err = c.client().CreateExport(project, export)
 		if !c.processError(err) {
 			return
 		}
@@ -1900,7 +1973,7 @@ func (c *TokenAutoUpdateClient) CreateExport(project string, export *Export) (er
 }
 func (c *TokenAutoUpdateClient) UpdateExport(project string, export *Export) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateExport(project, export)
+		err = c.client().UpdateExport(project, export)
 		if !c.processError(err) {
 			return
 		}
@@ -1909,7 +1982,7 @@ func (c *TokenAutoUpdateClient) UpdateExport(project string, export *Export) (er
 }
 func (c *TokenAutoUpdateClient) GetExport(project, name string) (export *Export, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		export, err = c.logClient.GetExport(project, name)
+		export, err = c.client().GetExport(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1918,7 +1991,7 @@ func (c *TokenAutoUpdateClient) GetExport(project, name string) (export *Export,
 }
 func (c *TokenAutoUpdateClient) ListExport(project, logstore, name, displayName string, offset, size int) (exports []*Export, total, count int, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		exports, total, count, err = c.logClient.ListExport(project, logstore, name, displayName, offset, size)
+		exports, total, count, err = c.client().ListExport(project, logstore, name, displayName, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -1927,7 +2000,7 @@ func (c *TokenAutoUpdateClient) ListExport(project, logstore, name, displayName
 }
 func (c *TokenAutoUpdateClient) DeleteExport(project string, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteExport(project, name)
+		err = c.client().DeleteExport(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1936,7 +2009,7 @@ func (c *TokenAutoUpdateClient) DeleteExport(project string, name string) (err e
 }
 func (c *TokenAutoUpdateClient) RestartExport(project string, export *Export) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.RestartExport(project, export)
+		err = c.client().RestartExport(project, export)
 		if !c.processError(err) {
 			return
 		}
@@ -1945,7 +2018,7 @@ func (c *TokenAutoUpdateClient) RestartExport(project string, export *Export) (e
 }
 func (c *TokenAutoUpdateClient) CreateMetricStore(project string, metricStore *LogStore) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateMetricStore(project, metricStore)
+		err = c.client().CreateMetricStore(project, metricStore)
 		if !c.processError(err) {
 			return
 		}
@@ -1954,7 +2027,7 @@ func (c *TokenAutoUpdateClient) CreateMetricStore(project string, metricStore *L
 }
 func (c *TokenAutoUpdateClient) UpdateMetricStore(project string, metricStore *LogStore) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateMetricStore(project, metricStore)
+		err = c.client().UpdateMetricStore(project, metricStore)
 		if !c.processError(err) {
 			return
 		}
@@ -1963,7 +2036,7 @@ func (c *TokenAutoUpdateClient) UpdateMetricStore(project string, metricStore *L
 }
 func (c *TokenAutoUpdateClient) DeleteMetricStore(project, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteMetricStore(project, name)
+		err = c.client().DeleteMetricStore(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -1972,7 +2045,53 @@ func (c *TokenAutoUpdateClient) DeleteMetricStore(project, name string) (err err
 }
 func (c *TokenAutoUpdateClient) GetMetricStore(project, name string) (metricStore *LogStore, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		metricStore, err = c.logClient.GetMetricStore(project, name)
+		metricStore, err = c.client().GetMetricStore(project, name)
+		if !c.processError(err) {
+			return
+		}
+	}
+	return
+}
+
+// promClient returns the client to make PromQL queries by, the underlying client is created by CreateNormalInterface,
+// which always supports PromQL
+func (c *TokenAutoUpdateClient) promClient() PromQLClient {
+	return c.client().(PromQLClient)
+}
+
+func (c *TokenAutoUpdateClient) QueryInstant(project, metricStore, query string, ts time.Time) (result *PromQueryResult, err error) {
+	for i := 0; i < c.maxTryTimes; i++ {
+		result, err = c.promClient().QueryInstant(project, metricStore, query, ts)
+		if !c.processError(err) {
+			return
+		}
+	}
+	return
+}
+
+func (c *TokenAutoUpdateClient) QueryRange(project, metricStore, query string, start, end time.Time, step time.Duration) (result *PromQueryResult, err error) {
+	for i := 0; i < c.maxTryTimes; i++ {
+		result, err = c.promClient().QueryRange(project, metricStore, query, start, end, step)
+		if !c.processError(err) {
+			return
+		}
+	}
+	return
+}
+
+func (c *TokenAutoUpdateClient) Series(project, metricStore string, matchers []string, start, end time.Time) (series []model.LabelSet, err error) {
+	for i := 0; i < c.maxTryTimes; i++ {
+		series, err = c.promClient().Series(project, metricStore, matchers, start, end)
+		if !c.processError(err) {
+			return
+		}
+	}
+	return
+}
+
+func (c *TokenAutoUpdateClient) LabelValues(project, metricStore, label string, matchers []string, start, end time.Time) (values []string, err error) {
+	for i := 0; i < c.maxTryTimes; i++ {
+		values, err = c.promClient().LabelValues(project, metricStore, label, matchers, start, end)
 		if !c.processError(err) {
 			return
 		}
@@ -1982,7 +2101,7 @@ func (c *TokenAutoUpdateClient) GetMetricStore(project, name string) (metricStor
 
 func (c *TokenAutoUpdateClient) UpdateProjectPolicy(project, policy string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateProjectPolicy(project, policy)
+		err = c.client().UpdateProjectPolicy(project, policy)
 		if !c.processError(err) {
 			return
 		}
@@ -1992,7 +2111,7 @@ func (c *TokenAutoUpdateClient) UpdateProjectPolicy(project, policy string) (err
 
 func (c *TokenAutoUpdateClient) DeleteProjectPolicy(project string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteProjectPolicy(project)
+		err = c.client().DeleteProjectPolicy(project)
 		if !c.processError(err) {
 			return
 		}
@@ -2002,7 +2121,7 @@ func (c *TokenAutoUpdateClient) DeleteProjectPolicy(project string) (err error)
 
 func (c *TokenAutoUpdateClient) GetProjectPolicy(project string) (policy string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		policy, err = c.logClient.GetProjectPolicy(project)
+		policy, err = c.client().GetProjectPolicy(project)
 		if !c.processError(err) {
 			return
 		}
@@ -2013,7 +2132,7 @@ func (c *TokenAutoUpdateClient) GetProjectPolicy(project string) (policy string,
 func (c *TokenAutoUpdateClient) PublishAlertEvent(project string, alertResult []byte) error {
 	var err error = nil
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PublishAlertEvent(project, alertResult)
+		err = c.client().PublishAlertEvent(project, alertResult)
 		if err == nil {
 			break
 		}
@@ -2023,7 +2142,7 @@ func (c *TokenAutoUpdateClient) PublishAlertEvent(project string, alertResult []
 
 func (c *TokenAutoUpdateClient) CreateEventStore(project string, eventStore *LogStore) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateEventStore(project, eventStore)
+		err = c.client().CreateEventStore(project, eventStore)
 		if !c.processError(err) {
 			return
 		}
@@ -2033,7 +2152,7 @@ func (c *TokenAutoUpdateClient) CreateEventStore(project string, eventStore *Log
 
 func (c *TokenAutoUpdateClient) UpdateEventStore(project string, eventStore *LogStore) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateEventStore(project, eventStore)
+		err = c.client().UpdateEventStore(project, eventStore)
 		if !c.processError(err) {
 			return
 		}
@@ -2043,7 +2162,7 @@ func (c *TokenAutoUpdateClient) UpdateEventStore(project string, eventStore *Log
 
 func (c *TokenAutoUpdateClient) DeleteEventStore(project, name string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteEventStore(project, name)
+		err = c.client().DeleteEventStore(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -2053,7 +2172,7 @@ func (c *TokenAutoUpdateClient) DeleteEventStore(project, name string) (err erro
 
 func (c *TokenAutoUpdateClient) GetEventStore(project, name string) (eventStore *LogStore, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		eventStore, err = c.logClient.GetEventStore(project, name)
+		eventStore, err = c.client().GetEventStore(project, name)
 		if !c.processError(err) {
 			return
 		}
@@ -2063,7 +2182,7 @@ func (c *TokenAutoUpdateClient) GetEventStore(project, name string) (eventStore
 
 func (c *TokenAutoUpdateClient) ListEventStore(project string, offset, size int) (eventStores []string, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		eventStores, err = c.logClient.ListEventStore(project, offset, size)
+		eventStores, err = c.client().ListEventStore(project, offset, size)
 		if !c.processError(err) {
 			return
 		}
@@ -2073,7 +2192,7 @@ func (c *TokenAutoUpdateClient) ListEventStore(project string, offset, size int)
 
 func (c *TokenAutoUpdateClient) PostLogStoreLogsV2(project, logstore string, req *PostLogStoreLogsRequest) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.PostLogStoreLogsV2(project, logstore, req)
+		err = c.client().PostLogStoreLogsV2(project, logstore, req)
 		if !c.processError(err) {
 			return
 		}
@@ -2083,7 +2202,7 @@ func (c *TokenAutoUpdateClient) PostLogStoreLogsV2(project, logstore string, req
 
 func (c *TokenAutoUpdateClient) CreateStoreView(project string, storeView *StoreView) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.CreateStoreView(project, storeView)
+		err = c.client().CreateStoreView(project, storeView)
 		if !c.processError(err) {
 			return
 		}
@@ -2093,7 +2212,7 @@ func (c *TokenAutoUpdateClient) CreateStoreView(project string, storeView *Store
 
 func (c *TokenAutoUpdateClient) UpdateStoreView(project string, storeView *StoreView) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.UpdateStoreView(project, storeView)
+		err = c.client().UpdateStoreView(project, storeView)
 		if !c.processError(err) {
 			return
 		}
@@ -2103,7 +2222,7 @@ func (c *TokenAutoUpdateClient) UpdateStoreView(project string, storeView *Store
 
 func (c *TokenAutoUpdateClient) DeleteStoreView(project string, storeViewName string) (err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		err = c.logClient.DeleteStoreView(project, storeViewName)
+		err = c.client().DeleteStoreView(project, storeViewName)
 		if !c.processError(err) {
 			return
 		}
@@ -2113,7 +2232,7 @@ func (c *TokenAutoUpdateClient) DeleteStoreView(project string, storeViewName st
 
 func (c *TokenAutoUpdateClient) GetStoreView(project string, storeViewName string) (storeView *StoreView, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		storeView, err = c.logClient.GetStoreView(project, storeViewName)
+		storeView, err = c.client().GetStoreView(project, storeViewName)
 		if !c.processError(err) {
 			return
 		}
@@ -2123,7 +2242,7 @@ func (c *TokenAutoUpdateClient) GetStoreView(project string, storeViewName strin
 
 func (c *TokenAutoUpdateClient) ListStoreViews(project string, req *ListStoreViewsRequest) (resp *ListStoreViewsResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		resp, err = c.logClient.ListStoreViews(project, req)
+		resp, err = c.client().ListStoreViews(project, req)
 		if !c.processError(err) {
 			return
 		}
@@ -2133,7 +2252,7 @@ func (c *TokenAutoUpdateClient) ListStoreViews(project string, req *ListStoreVie
 
 func (c *TokenAutoUpdateClient) GetStoreViewIndex(project string, storeViewName string) (resp *GetStoreViewIndexResponse, err error) {
 	for i := 0; i < c.maxTryTimes; i++ {
-		resp, err = c.logClient.GetStoreViewIndex(project, storeViewName)
+		resp, err = c.client().GetStoreViewIndex(project, storeViewName)
 		if !c.processError(err) {
 			return
 		}
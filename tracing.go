@@ -0,0 +1,181 @@
+package sls
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of spans created by the sdk
+const TracerName = "github.com/aliyun/aliyun-log-go-sdk"
+
+// attribute keys of spans created by the sdk
+const (
+	AttrProject        = attribute.Key("sls.project")
+	AttrLogstore       = attribute.Key("sls.logstore")
+	AttrErrorCode      = attribute.Key("sls.error_code")
+	AttrRequestID      = attribute.Key("sls.request_id")
+	AttrRetryCount     = attribute.Key("sls.retry_count")
+	AttrAttempt        = attribute.Key("sls.attempt")
+	AttrHTTPMethod     = attribute.Key("http.method")
+	AttrHTTPRoute      = attribute.Key("http.route")
+	AttrHTTPStatusCode = attribute.Key("http.status_code")
+)
+
+// uriCollections maps the collection segments of an uri to the placeholder of the next segment
+var uriCollections = map[string]string{
+	"logstores":      "{logstore}",
+	"shards":         "{shard}",
+	"consumergroups": "{consumergroup}",
+	"configs":        "{config}",
+	"machinegroups":  "{machinegroup}",
+	"dashboards":     "{dashboard}",
+	"charts":         "{chart}",
+	"savedsearches":  "{savedsearch}",
+	"jobs":           "{job}",
+	"jobinstances":   "{jobinstance}",
+	"resources":      "{resource}",
+	"records":        "{record}",
+	"storeviews":     "{storeview}",
+	"metricstores":   "{metricstore}",
+	"etlmetas":       "{etlmeta}",
+	"ingestions":     "{ingestion}",
+	"exports":        "{export}",
+}
+
+// uriActions are fixed segments which should not be replaced by placeholder
+var uriActions = map[string]bool{
+	"lb":    true,
+	"index": true,
+	"api":   true,
+}
+
+// uriTemplate returns the route template of uri and the logstore name in it,
+// eg. "/logstores/test/shards/0?type=log" -> "/logstores/{logstore}/shards/{shard}", "test"
+func uriTemplate(uri string) (template, logstore string) {
+	if i := strings.IndexByte(uri, '?'); i >= 0 {
+		uri = uri[:i]
+	}
+	segments := strings.Split(uri, "/")
+	for i := 0; i < len(segments)-1; i++ {
+		placeholder, ok := uriCollections[segments[i]]
+		next := segments[i+1]
+		if !ok || next == "" || uriActions[next] {
+			continue
+		}
+		if _, isCollection := uriCollections[next]; isCollection {
+			continue
+		}
+		if segments[i] == "logstores" && logstore == "" {
+			logstore = next
+		}
+		segments[i+1] = placeholder
+		i++
+	}
+	return strings.Join(segments, "/"), logstore
+}
+
+// WithTracerProvider enables OpenTelemetry tracing of the client and returns the same client.
+// A span is created for each api call, and a child span for each attempt of the call.
+// nil disables tracing.
+func (c *Client) WithTracerProvider(tp trace.TracerProvider) *Client {
+	c.accessKeyLock.Lock()
+	c.tracerProvider = tp
+	c.accessKeyLock.Unlock()
+	return c
+}
+
+// WithTracerProvider enables OpenTelemetry tracing of the project and returns the same project.
+// nil disables tracing.
+func (p *LogProject) WithTracerProvider(tp trace.TracerProvider) *LogProject {
+	p.tracerProvider = tp
+	return p
+}
+
+// apiSpan traces an api call, a nil apiSpan does nothing
+type apiSpan struct {
+	tracer  trace.Tracer
+	span    trace.Span
+	attempt int
+}
+
+// startAPISpan starts a span for an api call, returns nil if tp is nil
+func startAPISpan(ctx context.Context, tp trace.TracerProvider, project, method, uri string) (context.Context, *apiSpan) {
+	if tp == nil {
+		return ctx, nil
+	}
+	route, logstore := uriTemplate(uri)
+	attrs := []attribute.KeyValue{
+		AttrProject.String(project),
+		AttrHTTPMethod.String(method),
+		AttrHTTPRoute.String(route),
+	}
+	if logstore != "" {
+		attrs = append(attrs, AttrLogstore.String(logstore))
+	}
+	tracer := tp.Tracer(TracerName)
+	ctx, span := tracer.Start(ctx, method+" "+route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, &apiSpan{tracer: tracer, span: span}
+}
+
+// startAttempt starts a child span for an attempt of the call
+func (s *apiSpan) startAttempt(ctx context.Context) (context.Context, trace.Span) {
+	if s == nil {
+		return ctx, nil
+	}
+	s.attempt++
+	return s.tracer.Start(ctx, "attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(AttrAttempt.Int(s.attempt)))
+}
+
+func (s *apiSpan) endAttempt(span trace.Span, resp *http.Response, err error) {
+	if s == nil {
+		return
+	}
+	recordResult(span, resp, err)
+	span.End()
+}
+
+func (s *apiSpan) end(resp *http.Response, err error) {
+	if s == nil {
+		return
+	}
+	retryCount := s.attempt - 1
+	if retryCount < 0 {
+		retryCount = 0
+	}
+	s.span.SetAttributes(AttrRetryCount.Int(retryCount))
+	recordResult(s.span, resp, err)
+	s.span.End()
+}
+
+func recordResult(span trace.Span, resp *http.Response, err error) {
+	if err == nil {
+		if resp != nil {
+			span.SetAttributes(AttrHTTPStatusCode.Int(resp.StatusCode))
+			if resp.Header != nil {
+				span.SetAttributes(AttrRequestID.String(resp.Header.Get(RequestIDHeader)))
+			}
+		}
+		return
+	}
+	switch e := err.(type) {
+	case *Error:
+		span.SetAttributes(
+			AttrHTTPStatusCode.Int(int(e.HTTPCode)),
+			AttrErrorCode.String(e.Code),
+			AttrRequestID.String(e.RequestID),
+		)
+	case *BadResponseError:
+		span.SetAttributes(AttrHTTPStatusCode.Int(e.HTTPCode))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
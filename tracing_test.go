@@ -0,0 +1,126 @@
+package sls
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestURITemplate(t *testing.T) {
+	cases := []struct {
+		uri, template, logstore string
+	}{
+		{"/logstores", "/logstores", ""},
+		{"/logstores/test", "/logstores/{logstore}", "test"},
+		{"/logstores/test/shards/lb", "/logstores/{logstore}/shards/lb", "test"},
+		{"/logstores/test/shards/0?type=log", "/logstores/{logstore}/shards/{shard}", "test"},
+		{"/logstores/test/consumergroups/cg/shards/1", "/logstores/{logstore}/consumergroups/{consumergroup}/shards/{shard}", "test"},
+		{"/logstores/test/index", "/logstores/{logstore}/index", "test"},
+		{"/machinegroups/mg/configs", "/machinegroups/{machinegroup}/configs", ""},
+	}
+	for _, c := range cases {
+		template, logstore := uriTemplate(c.uri)
+		assert.Equal(t, c.template, template, c.uri)
+		assert.Equal(t, c.logstore, logstore, c.uri)
+	}
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingRetriedCall(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RequestIDHeader, "request-id")
+		if atomic.AddInt32(&requestCount, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"errorCode":"ServerBusy","errorMessage":"server busy"}`))
+			return
+		}
+		w.Write([]byte(`{"logstoreName":"test-logstore","ttl":1,"shardCount":2}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := CreateNormalInterface(server.URL, "id", "secret", "").(*Client)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+	policy := DefaultRetryPolicy()
+	policy.InitialInterval = time.Millisecond
+	client.SetRetryPolicy(policy)
+	client.WithTracerProvider(tp)
+
+	_, err := client.GetLogStore("test-project", "test-logstore")
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	attempts, call := spans[:2], spans[2]
+	assert.Equal(t, "GET /logstores/{logstore}", call.Name())
+	assert.Equal(t, "test-project", spanAttr(call, AttrProject).AsString())
+	assert.Equal(t, "test-logstore", spanAttr(call, AttrLogstore).AsString())
+	assert.Equal(t, int64(1), spanAttr(call, AttrRetryCount).AsInt64())
+	assert.Equal(t, int64(http.StatusOK), spanAttr(call, AttrHTTPStatusCode).AsInt64())
+	assert.Equal(t, "request-id", spanAttr(call, AttrRequestID).AsString())
+
+	for i, attempt := range attempts {
+		assert.Equal(t, "attempt", attempt.Name())
+		assert.Equal(t, call.SpanContext().SpanID(), attempt.Parent().SpanID())
+		assert.Equal(t, int64(i+1), spanAttr(attempt, AttrAttempt).AsInt64())
+	}
+	assert.Equal(t, codes.Error, attempts[0].Status().Code)
+	assert.Equal(t, "ServerBusy", spanAttr(attempts[0], AttrErrorCode).AsString())
+	assert.Equal(t, codes.Unset, attempts[1].Status().Code)
+}
+
+func TestTokenAutoUpdateClientTracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"logstoreName":"test-logstore","ttl":1,"shardCount":2}`))
+	}))
+	defer server.Close()
+
+	updateFunc := func() (string, string, string, time.Time, error) {
+		return "id", "secret", "token", time.Now().Add(time.Hour), nil
+	}
+	shutdown := make(chan struct{})
+	defer close(shutdown)
+	client, err := CreateTokenAutoUpdateClient(server.URL, updateFunc, shutdown)
+	require.NoError(t, err)
+	client.SetHTTPClient(newTestServerHTTPClient(server))
+
+	// the tracer provider set on a context client is used by the parent and the context clients after
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctxClient := client.(ClientWithContext).WithContext(context.Background())
+	ctxClient.(*TokenAutoUpdateClient).WithTracerProvider(tp)
+	_, err = client.GetLogStore("test-project", "test-logstore")
+	require.NoError(t, err)
+	_, err = ctxClient.GetLogStore("test-project", "test-logstore")
+	require.NoError(t, err)
+	assert.Len(t, recorder.Ended(), 4)
+}
+
+func TestTracingDisabled(t *testing.T) {
+	ctx, span := startAPISpan(nil, nil, "p", http.MethodGet, "/logstores")
+	assert.Nil(t, ctx)
+	assert.Nil(t, span)
+	_, attempt := span.startAttempt(nil)
+	span.endAttempt(attempt, nil, nil)
+	span.end(nil, nil)
+}